@@ -14,12 +14,15 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
+	"net/url"
+	"os"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -31,16 +34,75 @@ const (
 	httpErrorBoundary = 300
 )
 
-func NewHttpClient(skipVerify bool) *http.Client {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
+// ErrUnauthorized matches the errors of the requests whose token the server
+// rejects
+var ErrUnauthorized = errors.New("token expired or invalid, run mender-cli login")
+
+// caCertPool holds the trusted CA certificates, or nil to use the system
+// ones only
+var caCertPool *x509.CertPool
+
+// AddCACertificate makes the clients trust the PEM encoded certificates in
+// the file, in addition to the system ones
+func AddCACertificate(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "Cannot read the CA certificate")
+	}
+	if caCertPool == nil {
+		caCertPool, err = x509.SystemCertPool()
+		if err != nil {
+			caCertPool = x509.NewCertPool()
+		}
+	}
+	if !caCertPool.AppendCertsFromPEM(data) {
+		return errors.Errorf("no certificates found in %s", path)
+	}
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+	resetTransports()
+	return nil
+}
+
+// clientCertificates holds the certificate presented to servers asking for
+// one, for mutual TLS
+var clientCertificates []tls.Certificate
+
+// SetClientCertificate makes the clients authenticate with the PEM encoded
+// certificate and private key
+func SetClientCertificate(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return errors.Wrap(err, "Cannot load the client certificate")
 	}
+	clientCertificates = []tls.Certificate{cert}
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+	resetTransports()
+	return nil
+}
 
-	return &http.Client{
-		Transport: tr,
+// ResetTLSConfig forgets the CA certificates and the client certificate set
+// before, so that the clients only trust the system CA certificates again
+func ResetTLSConfig() {
+	caCertPool = nil
+	clientCertificates = nil
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+	resetTransports()
+}
+
+// NewTLSConfig returns the TLS configuration shared by all the clients:
+// the REST requests, the storage requests and the websocket connections
+func NewTLSConfig(skipVerify bool) *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: skipVerify,
+		RootCAs:            caCertPool,
+		Certificates:       clientCertificates,
 	}
 }
 
+// JoinURL joins the server URL and an API path
 func JoinURL(base, url string) string {
 	url = strings.TrimPrefix(url, "/")
 	if !strings.HasSuffix(base, "/") {
@@ -49,65 +111,162 @@ func JoinURL(base, url string) string {
 	return base + url
 }
 
-func DoGetRequest(token, urlPath string, client *http.Client) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+// DoGetRequest sends a GET request and returns the response body
+func DoGetRequest(
+	ctx context.Context,
+	token, urlPath string,
+	client *http.Client,
+) ([]byte, error) {
+	body, _, err := DoGetRequestWithHeaders(ctx, token, urlPath, client)
+	return body, err
+}
+
+// DoGetRequestWithHeaders works like DoGetRequest, but also returns the
+// response headers, e.g. for following the pagination links
+func DoGetRequestWithHeaders(
+	ctx context.Context,
+	token, urlPath string,
+	client *http.Client,
+) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlPath, nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to create HTTP request")
+		return nil, nil, errors.Wrap(err, "Failed to create HTTP request")
 	}
 	req.Header.Set("Authorization", "Bearer "+string(token))
 
-	reqDump, err := httputil.DumpRequest(req, false)
+	reqDump, err := DumpRequest(req, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	log.Verbf("sending request: \n%s", string(reqDump))
 
 	rsp, err := client.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("Get %s request failed", urlPath))
-	}
-	if rsp.StatusCode != 200 {
-		return nil, fmt.Errorf("Get %s request failed with status %d\n", urlPath, rsp.StatusCode)
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("Get %s request failed", urlPath))
 	}
-
 	defer rsp.Body.Close()
 
+	if rsp.StatusCode >= httpErrorBoundary {
+		return nil, nil, NewAPIError(rsp)
+	}
+
 	body, err := ioutil.ReadAll(rsp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return body, nil
+	return body, rsp.Header, nil
 }
 
+// NextPageURL returns the URL of the next page from the "Link" response
+// header, resolved against the URL of the current page; it returns an
+// empty string if there is no next page
+func NextPageURL(currentURL string, header http.Header) string {
+	for _, link := range header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			fields := strings.Split(part, ";")
+			if len(fields) < 2 {
+				continue
+			}
+			isNext := false
+			for _, param := range fields[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+				if param == `rel="next"` || param == "rel=next" {
+					isNext = true
+					break
+				}
+			}
+			if !isNext {
+				continue
+			}
+			target := strings.Trim(strings.TrimSpace(fields[0]), "<>")
+			base, err := url.Parse(currentURL)
+			if err != nil {
+				return ""
+			}
+			next, err := base.Parse(target)
+			if err != nil {
+				return ""
+			}
+			return next.String()
+		}
+	}
+	return ""
+}
+
+// DoPostRequest sends a POST request with a JSON body and returns the
+// response body
 func DoPostRequest(
+	ctx context.Context,
+	token, urlPath string,
+	client *http.Client,
+	requestBody io.Reader,
+) ([]byte, error) {
+	return DoRequest(ctx, http.MethodPost, token, urlPath, client, requestBody)
+}
+
+// DoPutRequest sends a PUT request with a JSON body and returns the
+// response body
+func DoPutRequest(
+	ctx context.Context,
 	token, urlPath string,
 	client *http.Client,
 	requestBody io.Reader,
 ) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodPost, urlPath, requestBody)
+	return DoRequest(ctx, http.MethodPut, token, urlPath, client, requestBody)
+}
+
+// DoPatchRequest sends a PATCH request with a JSON body and returns the
+// response body
+func DoPatchRequest(
+	ctx context.Context,
+	token, urlPath string,
+	client *http.Client,
+	requestBody io.Reader,
+) ([]byte, error) {
+	return DoRequest(ctx, http.MethodPatch, token, urlPath, client, requestBody)
+}
+
+// DoDeleteRequest sends a DELETE request
+func DoDeleteRequest(ctx context.Context, token, urlPath string, client *http.Client) error {
+	_, err := DoRequest(ctx, http.MethodDelete, token, urlPath, client, nil)
+	return err
+}
+
+// DoRequest sends a request with an optional JSON body and returns the
+// response body
+func DoRequest(
+	ctx context.Context,
+	method, token, urlPath string,
+	client *http.Client,
+	requestBody io.Reader,
+) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlPath, requestBody)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create HTTP request")
 	}
 	req.Header.Set("Authorization", "Bearer "+string(token))
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if method != http.MethodDelete || requestBody != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
 
-	reqDump, err := httputil.DumpRequest(req, false)
+	reqDump, err := DumpRequest(req, false)
 	if err != nil {
 		return nil, err
 	}
 	log.Verbf("sending request: \n%s", string(reqDump))
 
+	name := method[:1] + strings.ToLower(method[1:])
 	rsp, err := client.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("Post %s request failed", urlPath))
-	}
-	if rsp.StatusCode > httpErrorBoundary {
-		return nil, fmt.Errorf("Post %s request failed with status %d\n", urlPath, rsp.StatusCode)
+		return nil, errors.Wrap(err, fmt.Sprintf("%s %s request failed", name, urlPath))
 	}
-
 	defer rsp.Body.Close()
 
+	if rsp.StatusCode >= httpErrorBoundary {
+		return nil, NewAPIError(rsp)
+	}
+
 	body, err := io.ReadAll(rsp.Body)
 	if err != nil {
 		return nil, err
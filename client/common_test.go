@@ -0,0 +1,72 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+func TestNextPageURL(t *testing.T) {
+	const current = "https://example.com/api/management/v2/devauth/devices?page=1&per_page=2"
+	tests := []struct {
+		name  string
+		links []string
+		next  string
+	}{
+		{name: "no Link header"},
+		{
+			name:  "relative URL",
+			links: []string{`</api/management/v2/devauth/devices?page=2&per_page=2>; rel="next"`},
+			next:  "https://example.com/api/management/v2/devauth/devices?page=2&per_page=2",
+		},
+		{
+			name:  "absolute URL",
+			links: []string{`<https://other.example.com/devices?page=2>; rel="next"`},
+			next:  "https://other.example.com/devices?page=2",
+		},
+		{
+			name: "several rels in one header",
+			links: []string{`</devices?page=1>; rel="first", </devices?page=2>; rel="next", ` +
+				`</devices?page=3>; rel="last"`},
+			next: "https://example.com/devices?page=2",
+		},
+		{
+			name:  "several headers",
+			links: []string{`</devices?page=1>; rel="first"`, `</devices?page=2>; rel="next"`},
+			next:  "https://example.com/devices?page=2",
+		},
+		{
+			name:  "unquoted rel and other params",
+			links: []string{`</devices?page=2>; title="Next page" ; rel = next`},
+			next:  "https://example.com/devices?page=2",
+		},
+		{
+			name:  "last page",
+			links: []string{`</devices?page=1>; rel="first", </devices?page=1>; rel="prev"`},
+		},
+		{name: "no params", links: []string{`</devices?page=2>`}},
+	}
+	for _, tc := range tests {
+		header := http.Header{}
+		for _, link := range tc.links {
+			header.Add("Link", link)
+		}
+		if next := client.NextPageURL(current, header); next != tc.next {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.next, next)
+		}
+	}
+}
@@ -15,10 +15,10 @@ package deployments
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httputil"
@@ -27,7 +27,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/pkg/errors"
 
 	"github.com/mendersoftware/mender-artifact/areader"
@@ -38,42 +37,36 @@ import (
 
 const (
 	httpErrorBoundary = 300
+
+	partFileSuffix      = ".part"
+	validatorFileSuffix = ".validator"
 )
 
-type artifactsList struct {
-	artifacts []artifactData
+type Artifact struct {
+	ID                    string           `json:"id"`
+	Description           string           `json:"description"`
+	Name                  string           `json:"name"`
+	DeviceTypesCompatible []string         `json:"device_types_compatible"`
+	Info                  ArtifactInfo     `json:"info"`
+	Signed                bool             `json:"signed"`
+	Updates               []Update         `json:"updates"`
+	ArtifactProvides      ArtifactProvides `json:"artifact_provides"`
+	ArtifactDepends       ArtifactDepends  `json:"artifact_depends"`
+	Size                  int64            `json:"size"`
+	Modified              time.Time        `json:"modified"`
+}
+
+type ArtifactInfo struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+type ArtifactProvides struct {
+	ArtifactName string `json:"artifact_name"`
 }
 
-type artifactData struct {
-	ID                    string   `json:"id"`
-	Description           string   `json:"description"`
-	Name                  string   `json:"name"`
-	DeviceTypesCompatible []string `json:"device_types_compatible"`
-	Info                  struct {
-		Format  string `json:"format"`
-		Version int    `json:"version"`
-	} `json:"info"`
-	Signed  bool `json:"signed"`
-	Updates []struct {
-		TypeInfo struct {
-			Type string `json:"type"`
-		} `json:"type_info"`
-		Files []struct {
-			Name     string    `json:"name"`
-			Checksum string    `json:"checksum"`
-			Size     int       `json:"size"`
-			Date     time.Time `json:"date"`
-		} `json:"files"`
-		MetaData []interface{} `json:"meta_data"`
-	} `json:"updates"`
-	ArtifactProvides struct {
-		ArtifactName string `json:"artifact_name"`
-	} `json:"artifact_provides"`
-	ArtifactDepends struct {
-		DeviceType []string `json:"device_type"`
-	} `json:"artifact_depends"`
-	Size     int       `json:"size"`
-	Modified time.Time `json:"modified"`
+type ArtifactDepends struct {
+	DeviceType []string `json:"device_type"`
 }
 
 const (
@@ -84,6 +77,7 @@ const (
 	transferCompleteURL = "/api/management/v1/deployments/artifacts/directupload/:id/complete"
 	artifactURL         = "/api/management/v1/deployments/artifacts/:id"
 	artifactDownloadURL = "/api/management/v1/deployments/artifacts/:id/download"
+	deploymentsURL      = "/api/management/v1/deployments/deployments"
 )
 
 type Client struct {
@@ -94,6 +88,7 @@ type Client struct {
 	artifactsListURL    string
 	artifactDeleteURL   string
 	directUploadURL     string
+	deploymentsURL      string
 	client              *http.Client
 }
 
@@ -108,6 +103,7 @@ type UploadLink struct {
 	Link
 }
 
+// NewClient returns a client of the server at the URL
 func NewClient(url string, skipVerify bool) *Client {
 	return &Client{
 		url:                 url,
@@ -117,14 +113,17 @@ func NewClient(url string, skipVerify bool) *Client {
 		artifactsListURL:    client.JoinURL(url, artifactsListURL),
 		artifactDeleteURL:   client.JoinURL(url, artifactsDeleteURL),
 		directUploadURL:     client.JoinURL(url, directUploadURL),
+		deploymentsURL:      client.JoinURL(url, deploymentsURL),
 		client:              client.NewHttpClient(skipVerify),
 	}
 }
 
-func (c *Client) DirectDownloadLink(token string) (*UploadLink, error) {
+// DirectUploadLink returns a pre-signed link for uploading an artifact
+// straight to the storage; see DirectUpload
+func (c *Client) DirectUploadLink(ctx context.Context, token string) (*UploadLink, error) {
 	var link UploadLink
 
-	body, err := client.DoPostRequest(token, c.directUploadURL, c.client, nil)
+	body, err := client.DoPostRequest(ctx, token, c.directUploadURL, c.client, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -137,69 +136,20 @@ func (c *Client) DirectDownloadLink(token string) (*UploadLink, error) {
 	return &link, nil
 }
 
-func (c *Client) ListArtifacts(token string, detailLevel int) error {
-	if detailLevel > 3 || detailLevel < 0 {
-		return fmt.Errorf("FAILURE: invalid artifact detail")
-	}
-
-	body, err := client.DoGetRequest(token, c.artifactsListURL, c.client)
+// ListArtifacts returns all the artifacts on the server
+func (c *Client) ListArtifacts(ctx context.Context, token string) ([]Artifact, error) {
+	body, err := client.DoGetRequest(ctx, token, c.artifactsListURL, c.client)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var list artifactsList
-	err = json.Unmarshal(body, &list.artifacts)
+	var list []Artifact
+	err = json.Unmarshal(body, &list)
 	if err != nil {
-		return err
-	}
-	for _, v := range list.artifacts {
-		listArtifact(v, detailLevel)
-	}
-
-	return nil
-}
-
-func listArtifact(a artifactData, detailLevel int) {
-	fmt.Printf("ID: %s\n", a.ID)
-	fmt.Printf("Name: %s\n", a.Name)
-	if detailLevel >= 1 {
-		fmt.Printf("Signed: %t\n", a.Signed)
-		fmt.Printf("Modfied: %s\n", a.Modified)
-		fmt.Printf("Size: %d\n", a.Size)
-		fmt.Printf("Description: %s\n", a.Description)
-		fmt.Println("Compatible device types:")
-		for _, v := range a.DeviceTypesCompatible {
-			fmt.Printf("  %s\n", v)
-		}
-		fmt.Printf("Artifact format: %s\n", a.Info.Format)
-		fmt.Printf("Format version: %d\n", a.Info.Version)
-	}
-	if detailLevel >= 2 {
-		fmt.Printf("Artifact provides: %s\n", a.ArtifactProvides.ArtifactName)
-		fmt.Println("Artifact depends:")
-		for _, v := range a.ArtifactDepends.DeviceType {
-			fmt.Printf("  %s\n", v)
-		}
-		fmt.Println("Updates:")
-		for _, v := range a.Updates {
-			fmt.Printf("  Type: %s\n", v.TypeInfo.Type)
-			fmt.Println("  Files:")
-			for _, f := range v.Files {
-				fmt.Printf("\tName: %s\n", f.Name)
-				fmt.Printf("\tChecksum: %s\n", f.Checksum)
-				fmt.Printf("\tSize: %d\n", f.Size)
-				fmt.Printf("\tDate: %s\n", f.Date)
-				if len(v.Files) > 1 {
-					fmt.Println()
-				}
-			}
-			if detailLevel == 3 {
-				fmt.Printf("  MetaData: %v\n", v.MetaData)
-			}
-		}
+		return nil, err
 	}
 
-	fmt.Println("--------------------------------------------------------------------------------")
+	return list, nil
 }
 
 // Type info structure
@@ -278,13 +228,51 @@ func readArtifactMetadata(path string, size int64) io.Reader {
 	return bytes.NewBuffer(data)
 }
 
+// DirectUpload uploads the artifact to the pre-signed link given by
+// DirectUploadLink, and tells the server once done; progress may be nil
 func (c *Client) DirectUpload(
+	ctx context.Context,
 	token, artifactPath, id, url string,
 	headers map[string]string,
-	noProgress bool,
+	progress client.ProgressFunc,
 ) error {
-	var bar *pb.ProgressBar
+	// the storage replaces an object uploaded twice
+	err := client.Retry(ctx, true, func() error {
+		return c.putArtifact(ctx, artifactPath, url, headers, progress)
+	})
+	if err != nil {
+		return err
+	}
 
+	size, err := fileSize(artifactPath)
+	if err != nil {
+		return err
+	}
+	body := readArtifactMetadata(artifactPath, size)
+	_, err = client.DoPostRequest(
+		ctx,
+		token,
+		client.JoinURL(
+			c.url,
+			strings.ReplaceAll(transferCompleteURL, ":id", id),
+		),
+		c.client,
+		body,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to notify on complete upload")
+	}
+
+	return nil
+}
+
+// putArtifact uploads the artifact file to the storage
+func (c *Client) putArtifact(
+	ctx context.Context,
+	artifactPath, url string,
+	headers map[string]string,
+	progress client.ProgressFunc,
+) error {
 	artifact, err := os.Open(artifactPath)
 	if err != nil {
 		return errors.Wrap(err, "Cannot read artifact file")
@@ -296,24 +284,15 @@ func (c *Client) DirectUpload(
 		return errors.Wrap(err, "Cannot read artifact file stats")
 	}
 
-	var req *http.Request
-	if !noProgress {
-		// create progress bar
-		bar = pb.New64(artifactStats.Size()).
-			Set(pb.Bytes, true).
-			SetRefreshRate(time.Millisecond * 100)
-		bar.Start()
-		req, err = http.NewRequest(http.MethodPut, url, bar.NewProxyReader(artifact))
-	} else {
-		req, err = http.NewRequest(http.MethodPut, url, artifact)
-	}
+	body := client.NewProgressReader(artifact, 0, artifactStats.Size(), progress)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
 	if err != nil {
 		return errors.Wrap(err, "Cannot create request")
 	}
 	req.Header.Set("Content-Type", "application/vnd.mender-artifact")
 	req.ContentLength = artifactStats.Size()
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
 	for k, h := range headers {
@@ -329,34 +308,30 @@ func (c *Client) DirectUpload(
 	log.Verbf("response: \n%v\n", string(rspDump))
 
 	if rsp.StatusCode >= httpErrorBoundary {
-		return errors.New(
-			fmt.Sprintf("artifact upload to '%s' failed with status %d", req.Host, rsp.StatusCode),
-		)
-	} else {
-		body := readArtifactMetadata(artifactPath, artifactStats.Size())
-		_, err := client.DoPostRequest(
-			token,
-			client.JoinURL(
-				c.url,
-				strings.ReplaceAll(transferCompleteURL, ":id", id),
-			),
-			c.client,
-			body,
-		)
-		if err != nil {
-			return errors.Wrap(err, "failed to notify on complete upload")
-		}
+		return errors.Wrapf(client.NewAPIError(rsp), "artifact upload to '%s' failed", req.Host)
 	}
-
 	return nil
 }
 
+// UploadArtifact uploads the artifact file through the server; progress
+// may be nil
 func (c *Client) UploadArtifact(
+	ctx context.Context,
 	description, artifactPath, token string,
-	noProgress bool,
+	progress client.ProgressFunc,
 ) error {
-	var bar *pb.ProgressBar
+	// the artifact may have been stored before the connection was lost,
+	// the server would then reject it as a duplicate
+	return client.Retry(ctx, false, func() error {
+		return c.uploadArtifact(ctx, description, artifactPath, token, progress)
+	})
+}
 
+func (c *Client) uploadArtifact(
+	ctx context.Context,
+	description, artifactPath, token string,
+	progress client.ProgressFunc,
+) error {
 	artifact, err := os.Open(artifactPath)
 	if err != nil {
 		return errors.Wrap(err, "Cannot read artifact file")
@@ -364,6 +339,7 @@ func (c *Client) UploadArtifact(
 
 	artifactStats, err := artifact.Stat()
 	if err != nil {
+		artifact.Close()
 		return errors.Wrap(err, "Cannot read artifact file stats")
 	}
 
@@ -373,26 +349,21 @@ func (c *Client) UploadArtifact(
 	// create multipart writer
 	writer := multipart.NewWriter(pW)
 
-	req, err := http.NewRequest(http.MethodPost, c.artifactUploadURL, pR)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.artifactUploadURL, pR)
 	if err != nil {
+		artifact.Close()
 		return errors.Wrap(err, "Cannot create request")
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+string(token))
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
-	if !noProgress {
-		// create progress bar
-		bar = pb.New64(artifactStats.Size()).
-			Set(pb.Bytes, true).
-			SetRefreshRate(time.Millisecond * 100)
-		bar.Start()
-	}
-
+	done := make(chan struct{})
 	go func() {
 		var part io.Writer
+		defer close(done)
 		defer pW.Close()
 		defer artifact.Close()
 
@@ -400,59 +371,73 @@ func (c *Client) UploadArtifact(
 		_ = writer.WriteField("description", description)
 		part, _ = writer.CreateFormFile("artifact", artifactStats.Name())
 
-		if !noProgress {
-			part = bar.NewProxyWriter(part)
-		}
-
-		if _, err := io.Copy(part, artifact); err != nil {
+		source := client.NewProgressReader(artifact, 0, artifactStats.Size(), progress)
+		if _, err := io.Copy(part, source); err != nil {
 			writer.Close()
 			_ = pR.CloseWithError(err)
 			return
 		}
 
 		writer.Close()
-		if !noProgress {
-			log.Info("Processing uploaded file. This may take around one minute.\n")
-		}
 	}()
 
 	rsp, err := c.client.Do(req)
+	pR.Close()
+	// no progress is reported once returned
+	<-done
 	if err != nil {
 		return errors.Wrap(err, "POST /artifacts request failed")
 	}
 	defer rsp.Body.Close()
-	pR.Close()
 
 	rspDump, _ := httputil.DumpResponse(rsp, true)
 	log.Verbf("response: \n%v\n", string(rspDump))
 
 	if rsp.StatusCode != http.StatusCreated {
-		if rsp.StatusCode == http.StatusUnauthorized {
-			log.Verbf("artifact upload to '%s' failed with status %d", req.Host, rsp.StatusCode)
-			return errors.New("Unauthorized. Please Login first")
-		} else if rsp.StatusCode == http.StatusConflict {
-			log.Verbf("artifact upload to '%s' failed with status %d", req.Host, rsp.StatusCode)
-			return errors.New("Artifact with same name or depends exists already")
-		}
-		return errors.New(
-			fmt.Sprintf("artifact upload to '%s' failed with status %d", req.Host, rsp.StatusCode),
-		)
+		return client.NewAPIError(rsp)
 	}
 
 	return nil
 }
 
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "Cannot read artifact file stats")
+	}
+	return info.Size(), nil
+}
+
+// ReadArtifactHeader reads the name and the compatible device types from
+// the header of a local artifact file
+func ReadArtifactHeader(artifactPath string) (string, []string, error) {
+	f, err := os.Open(artifactPath)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Cannot read artifact file")
+	}
+	defer f.Close()
+
+	ar := areader.NewReader(f)
+	if err := ar.ReadArtifactHeaders(); err != nil {
+		return "", nil, errors.Wrapf(err, "Cannot read artifact header of %s", artifactPath)
+	}
+	return ar.GetArtifactName(), ar.GetCompatibleDevices(), nil
+}
+
+// DeleteArtifact deletes the artifact from the server
 func (c *Client) DeleteArtifact(
+	ctx context.Context,
 	artifactID, token string,
 ) error {
 
-	req, err := http.NewRequest(http.MethodDelete, c.artifactDeleteURL+"/"+artifactID, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete,
+		c.artifactDeleteURL+"/"+artifactID, nil)
 	if err != nil {
 		return errors.Wrap(err, "Cannot create request")
 	}
 	req.Header.Set("Authorization", "Bearer "+string(token))
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
 	rsp, err := c.client.Do(req)
@@ -465,31 +450,27 @@ func (c *Client) DeleteArtifact(
 	log.Verbf("response: \n%v\n", string(rspDump))
 
 	if rsp.StatusCode != http.StatusNoContent {
-		body, err := ioutil.ReadAll(rsp.Body)
-		if err != nil {
-			return errors.Wrap(err, "can't read request body")
-		}
-		if rsp.StatusCode == http.StatusUnauthorized {
-			log.Verbf("artifact delete failed with status %d, reason: %s", rsp.StatusCode, body)
-			return errors.New("Unauthorized. Please Login first")
-		}
-		return errors.New(
-			fmt.Sprintf("artifact upload failed with status %d, reason: %s", rsp.StatusCode, body),
-		)
+		return client.NewAPIError(rsp)
 	}
 
 	return nil
 }
 
+// DownloadArtifact downloads the artifact to NAME.mender in the directory,
+// resuming the download after the transient failures; the partial file is
+// kept as NAME.ID.mender.part and the artifact is verified before renaming
+// it; progress may be nil
 func (c *Client) DownloadArtifact(
-	sourcePath, artifactID, token string, noProgress bool,
+	ctx context.Context,
+	sourcePath, artifactID, token string,
+	progress client.ProgressFunc,
 ) error {
 
-	link, err := c.getLink(artifactID, token)
+	link, err := c.getLink(ctx, artifactID, token)
 	if err != nil {
 		return errors.Wrap(err, "Cannot get artifact link")
 	}
-	artifact, err := c.getArtifact(artifactID, token)
+	artifact, err := c.GetArtifact(ctx, artifactID, token)
 	if err != nil {
 		return errors.Wrap(err, "Cannot get artifact details")
 	}
@@ -499,61 +480,119 @@ func (c *Client) DownloadArtifact(
 	if sourcePath != "" {
 		sourcePath += "/"
 	}
+	partPath := sourcePath + artifact.Name + "." + artifact.ID + ".mender" + partFileSuffix
 	sourcePath += artifact.Name + ".mender"
 
-	req, err := http.NewRequest(http.MethodGet, link.Uri, nil)
-	if err != nil {
-		return errors.Wrap(err, "Cannot create request")
+	for attempt := 0; ; attempt++ {
+		retry, err := c.downloadFile(ctx, artifact.Size, link.Uri, partPath, progress)
+		if err == nil {
+			break
+		}
+		if !retry || attempt >= client.MaxRetries() || ctx.Err() != nil {
+			return err
+		}
+
+		delay := client.RetryBackoff(attempt)
+		log.Verbf("download failed: %s; retrying in %s\n", err.Error(), delay)
+		if err := client.SleepContext(ctx, delay); err != nil {
+			return err
+		}
+
+		// the pre-signed link is short lived, get a new one once expired
+		expired := !link.Expire.IsZero() && time.Now().After(link.Expire)
+		if expired || err == errDownloadForbidden {
+			link, err = c.getLink(ctx, artifactID, token)
+			if err != nil {
+				return errors.Wrap(err, "Cannot get artifact link")
+			}
+		}
 	}
 
-	reqDump, _ := httputil.DumpRequest(req, false)
-	log.Verbf("sending request: \n%v", string(reqDump))
-	resp, err := c.client.Do(req)
+	if err := verifyDownload(partPath, artifact); err != nil {
+		removePartFile(partPath)
+		return err
+	}
+	os.Remove(partPath + validatorFileSuffix)
+	return os.Rename(partPath, sourcePath)
+}
+
+// verifyDownload checks the size of the downloaded file, the checksums of
+// its payloads, and that they match the artifact on the server
+func verifyDownload(path string, artifact *Artifact) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != artifact.Size {
+		return errors.Errorf(
+			"The downloaded file size %d does not match the artifact size %d",
+			info.Size(), artifact.Size,
+		)
+	}
+	local, err := ReadLocalArtifact(path, nil)
 	if err != nil {
-		return errors.Wrap(err, "GET /artifacts request failed")
+		return errors.Wrap(err, "The downloaded artifact is corrupted")
 	}
-	defer resp.Body.Close()
+	if local.Name != artifact.Name {
+		return errors.Errorf(
+			"The downloaded artifact name %s does not match the artifact name %s",
+			local.Name, artifact.Name,
+		)
+	}
+	checksums := map[string]string{}
+	for _, p := range local.Payloads {
+		for _, f := range p.Files {
+			checksums[f.Name] = f.Checksum
+		}
+	}
+	for _, u := range artifact.Updates {
+		for _, f := range u.Files {
+			if f.Checksum != "" && checksums[f.Name] != f.Checksum {
+				return errors.Errorf(
+					"The checksum of %s in the downloaded artifact does not match",
+					f.Name,
+				)
+			}
+		}
+	}
+	return nil
+}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return c.downloadFile(artifact.Size, sourcePath, resp, noProgress)
-	case http.StatusBadRequest:
-		return errors.New("Bad request\n")
-	case http.StatusForbidden:
-		return errors.New("Forbidden")
-	case http.StatusNotFound:
-		return errors.New("File not found on the device\n")
-	case http.StatusConflict:
-		return errors.New("The device is not connected\n")
-	case http.StatusInternalServerError:
-		return errors.New("Internal server error\n")
-	default:
-		return errors.New("Error: Received unexpected response code: " +
-			strconv.Itoa(resp.StatusCode))
+// removePartFile removes the partial download and its validator
+func removePartFile(path string) {
+	os.Remove(path)
+	os.Remove(path + validatorFileSuffix)
+}
+
+// downloadValidator returns the validator of the downloaded content to send
+// in the If-Range header when resuming; weak entity tags can't be used
+func downloadValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
 	}
+	return header.Get("Last-Modified")
 }
 
+var errDownloadForbidden = errors.New("Forbidden")
+
 type DownloadLink struct {
 	Uri    string    `json:"uri"`
 	Expire time.Time `json:"expire"`
 }
 
-type Artifact struct {
-	Size int64  `json:"size"`
-	Name string `json:"name"`
-}
-
-func (c *Client) getArtifact(
+// GetArtifact returns the artifact with the given ID
+func (c *Client) GetArtifact(
+	ctx context.Context,
 	artifactID, token string,
 ) (*Artifact, error) {
-	req, err := http.NewRequest(http.MethodGet,
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
 		strings.ReplaceAll(c.artifactURL, ":id", artifactID), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "Cannot create request")
 	}
 	req.Header.Set("Authorization", "Bearer "+string(token))
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
 	rsp, err := c.client.Do(req)
@@ -565,6 +604,10 @@ func (c *Client) getArtifact(
 	rspDump, _ := httputil.DumpResponse(rsp, true)
 	log.Verbf("response: \n%v\n", string(rspDump))
 
+	if rsp.StatusCode != http.StatusOK {
+		return nil, client.NewAPIError(rsp)
+	}
+
 	body, err := io.ReadAll(rsp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, "GET /artifacts request failed")
@@ -580,16 +623,17 @@ func (c *Client) getArtifact(
 }
 
 func (c *Client) getLink(
+	ctx context.Context,
 	artifactID, token string,
 ) (*DownloadLink, error) {
-	req, err := http.NewRequest(http.MethodGet,
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
 		strings.ReplaceAll(c.artifactDownloadURL, ":id", artifactID), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "Cannot create request")
 	}
 	req.Header.Set("Authorization", "Bearer "+string(token))
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
 	rsp, err := c.client.Do(req)
@@ -601,6 +645,10 @@ func (c *Client) getLink(
 	rspDump, _ := httputil.DumpResponse(rsp, true)
 	log.Verbf("response: \n%v\n", string(rspDump))
 
+	if rsp.StatusCode != http.StatusOK {
+		return nil, client.NewAPIError(rsp)
+	}
+
 	body, err := io.ReadAll(rsp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, "GET /artifacts request failed")
@@ -615,68 +663,115 @@ func (c *Client) getLink(
 	return &link, nil
 }
 
-func (c *Client) downloadFile(size int64, localFileName string, resp *http.Response,
-	noProgress bool) error {
-	path := resp.Header.Get("X-MEN-FILE-PATH")
-	uid := resp.Header.Get("X-MEN-FILE-UID")
-	gid := resp.Header.Get("X-MEN-FILE-GID")
-	var n int64
+// downloadFile downloads the file to localFileName, resuming from the data
+// already there if the validator of its content was stored; it tells
+// whether the download can be retried on error, which the client doesn't
+// do itself: an interrupted transfer, or an expired link or range
+func (c *Client) downloadFile(
+	ctx context.Context,
+	size int64,
+	uri, localFileName string,
+	progress client.ProgressFunc,
+) (bool, error) {
+	var offset int64
+	validator, err := os.ReadFile(localFileName + validatorFileSuffix)
+	if err == nil && len(validator) > 0 {
+		if info, err := os.Stat(localFileName); err == nil {
+			offset = info.Size()
+		}
+	}
+	if offset > size {
+		offset = 0
+	} else if offset == size && size > 0 {
+		return false, nil
+	}
 
-	file, err := os.Create(localFileName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
 	if err != nil {
-		return errors.Wrap(err, "Cannot create file")
+		return false, errors.Wrap(err, "Cannot create request")
 	}
-	defer file.Close()
+	if offset > 0 {
+		// the server sends the whole file if it changed since
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+
+	reqDump, _ := client.DumpRequest(req, false)
+	log.Verbf("sending request: \n%v", string(reqDump))
+	resp, err := c.client.Do(req)
 	if err != nil {
-		log.Errf("Failed to create the file %s locally\n", path)
-		return err
+		// the client retried already after the transient errors
+		return false, errors.Wrap(err, "GET /artifacts request failed")
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			// the partial file is unusable; start over on the next attempt
+			removePartFile(localFileName)
+			return true, errors.Errorf("Unexpected Content-Range header: %s",
+				resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// the whole file is sent again
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is unusable; start over on the next attempt
+		removePartFile(localFileName)
+		return true, errors.New("Requested range not satisfiable")
+	case http.StatusForbidden:
+		// the pre-signed link may have expired
+		return true, errDownloadForbidden
+	default:
+		return false, client.NewAPIError(resp)
 	}
 
 	if resp.Header.Get("Content-Type") != "application/vnd.mender-artifact" {
-		return fmt.Errorf("Unexpected Content-Type header: %s", resp.Header.Get("Content-Type"))
+		return false, fmt.Errorf("Unexpected Content-Type header: %s",
+			resp.Header.Get("Content-Type"))
 	}
+
+	file, err := os.OpenFile(localFileName, flags, 0644)
 	if err != nil {
-		log.Err("downloadFile: Failed to parse the Content-Type header")
-		return err
+		return false, errors.Wrap(err, "Cannot create file")
 	}
-	i, _ := strconv.Atoi(resp.Header.Get("Content-Length"))
-	sourceSize := int64(i)
-	source := resp.Body
-
-	if !noProgress {
-		var bar *pb.ProgressBar = pb.New64(sourceSize).
-			Set(pb.Bytes, true).
-			SetRefreshRate(time.Millisecond * 100)
-		bar.Start()
-		// create proxy reader
-		reader := bar.NewProxyReader(source)
-		n, err = io.Copy(file, reader)
-	} else {
-		n, err = io.Copy(file, source)
+	defer file.Close()
+	if resp.StatusCode == http.StatusOK {
+		// without a validator the next attempts start over
+		err := os.WriteFile(localFileName+validatorFileSuffix,
+			[]byte(downloadValidator(resp.Header)), 0644)
+		if err != nil {
+			return false, errors.Wrap(err, "Cannot create file")
+		}
 	}
+
+	n, err := io.Copy(file, client.NewProgressReader(resp.Body, offset, size, progress))
 	log.Verbf("wrote: %d\n", n)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, errors.Wrap(err, "Download interrupted")
 	}
-	if n != size {
-		return errors.New(
-			"The downloaded file does not match the expected length in 'X-MEN-FILE-SIZE'",
-		)
+	if offset+n != size {
+		return true, errors.Errorf("Download interrupted after %d of %d bytes",
+			offset+n, size)
 	}
-	// Set the proper permissions and {G,U}ID's if present
-	if uid != "" && gid != "" {
-		uidi, err := strconv.Atoi(uid)
-		if err != nil {
-			return err
-		}
-		gidi, err := strconv.Atoi(gid)
-		if err != nil {
-			return err
-		}
-		err = os.Chown(file.Name(), uidi, gidi)
-		if err != nil {
-			return err
-		}
+	return false, nil
+}
+
+// contentRangeStart returns the first byte position from a Content-Range
+// header of the form "bytes START-END/SIZE", or -1 if it can't be parsed
+func contentRangeStart(contentRange string) int64 {
+	var start, end int64
+	var total string
+	_, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &start, &end, &total)
+	if err != nil {
+		return -1
 	}
-	return nil
+	return start
 }
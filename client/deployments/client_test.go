@@ -0,0 +1,272 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package deployments_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+const testUser = "user@example.com"
+
+func writeArtifact(t *testing.T, name string, deviceTypes ...string) string {
+	t.Helper()
+	data, err := fakeserver.NewArtifact(name, deviceTypes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name+".mender")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestArtifactDownloadRetry(t *testing.T) {
+	client.SetRetryOptions(client.RetryOptions{Retries: 2, MaxDelay: time.Millisecond})
+	defer client.SetRetryOptions(client.RetryOptions{
+		Retries:  client.DefaultRetries,
+		MaxDelay: client.DefaultRetryMaxDelay,
+	})
+	path := writeArtifact(t, "release-1", "rpi4")
+
+	tests := []struct {
+		name      string
+		status    int
+		count     int
+		fail      bool
+		downloads int
+	}{
+		{name: "connection lost", status: fakeserver.ConnectionLost, count: 1, downloads: 2},
+		{name: "server error", status: http.StatusServiceUnavailable, count: 2, downloads: 3},
+		{name: "link expired", status: http.StatusForbidden, count: 1, downloads: 2},
+		// the client retries the failed requests, not the download; the
+		// transport sends the request again on a new connection when the
+		// reused one is lost
+		{
+			name:      "connection lost again",
+			status:    fakeserver.ConnectionLost,
+			count:     10,
+			fail:      true,
+			downloads: 4,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := fakeserver.New()
+			defer srv.Close()
+			token := srv.Token(testUser)
+			ctx := context.Background()
+			c := deployments.NewClient(srv.URL, false)
+			if err := c.UploadArtifact(ctx, "", path, token, nil); err != nil {
+				t.Fatal(err)
+			}
+			id := srv.Artifacts()[0].ID
+			download := "/storage/artifacts/" + id
+
+			srv.Fail(http.MethodGet, download, tc.status, tc.count)
+			err := c.DownloadArtifact(ctx, t.TempDir(), id, token, nil)
+			if tc.fail != (err != nil) {
+				t.Errorf("unexpected error %v", err)
+			}
+			downloads := 0
+			for _, r := range srv.Requests() {
+				if r == http.MethodGet+" "+download {
+					downloads++
+				}
+			}
+			if downloads != tc.downloads {
+				t.Errorf("expected %d downloads, got %d", tc.downloads, downloads)
+			}
+		})
+	}
+}
+
+func TestArtifactUploadRetry(t *testing.T) {
+	client.SetRetryOptions(client.RetryOptions{Retries: 1, MaxDelay: time.Millisecond})
+	defer client.SetRetryOptions(client.RetryOptions{
+		Retries:  client.DefaultRetries,
+		MaxDelay: client.DefaultRetryMaxDelay,
+	})
+	path := writeArtifact(t, "release-1", "rpi4")
+	const upload = "POST /api/management/v1/deployments/artifacts"
+
+	tests := []struct {
+		status  int
+		fail    bool
+		uploads int
+	}{
+		// the request was not processed
+		{status: http.StatusTooManyRequests, uploads: 2},
+		// the artifact may have been stored
+		{status: http.StatusInternalServerError, fail: true, uploads: 1},
+	}
+	for _, tc := range tests {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			srv := fakeserver.New()
+			defer srv.Close()
+			token := srv.Token(testUser)
+			c := deployments.NewClient(srv.URL, false)
+
+			srv.Fail(http.MethodPost, "/api/management/v1/deployments/artifacts", tc.status, 1)
+			err := c.UploadArtifact(context.Background(), "", path, token, nil)
+			if tc.fail != (err != nil) {
+				t.Errorf("unexpected error %v", err)
+			}
+			uploads := 0
+			for _, r := range srv.Requests() {
+				if r == upload {
+					uploads++
+				}
+			}
+			if uploads != tc.uploads {
+				t.Errorf("expected %d uploads, got %d", tc.uploads, uploads)
+			}
+		})
+	}
+}
+
+func TestArtifactDownloadResume(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	path := writeArtifact(t, "release-1", "rpi4")
+	if err := c.UploadArtifact(ctx, "", path, token, nil); err != nil {
+		t.Fatal(err)
+	}
+	list, err := c.ListArtifacts(ctx, token)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("unexpected artifacts %+v, %v", list, err)
+	}
+	id := list[0].ID
+	data, _ := os.ReadFile(path)
+	etag := fmt.Sprintf("\"%x\"", sha256.Sum256(data))
+	garbage := bytes.Repeat([]byte{'x'}, len(data)/2)
+
+	tests := []struct {
+		name      string
+		part      []byte
+		validator string
+		fail      bool
+	}{
+		{name: "no partial file"},
+		{name: "partial file", part: data[:len(data)/2], validator: etag},
+		{name: "partial file without validator", part: garbage},
+		{name: "partial file changed on the server", part: garbage, validator: `"other"`},
+		{name: "corrupted partial file", part: garbage, validator: etag, fail: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			partPath := filepath.Join(dir, "release-1."+id+".mender.part")
+			if tc.part != nil {
+				if err := os.WriteFile(partPath, tc.part, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.validator != "" {
+				err := os.WriteFile(partPath+".validator", []byte(tc.validator), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			// a partial file of another artifact with the same name is ignored
+			stale := filepath.Join(dir, "release-1.other.mender.part")
+			if err := os.WriteFile(stale, garbage, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.DownloadArtifact(ctx, dir, id, token, nil)
+			if tc.fail {
+				if err == nil {
+					t.Fatal("expected the corrupted download to fail")
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				got, _ := os.ReadFile(filepath.Join(dir, "release-1.mender"))
+				if !bytes.Equal(got, data) {
+					t.Error("the downloaded artifact differs from the uploaded one")
+				}
+			}
+			for _, path := range []string{partPath, partPath + ".validator"} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s was not removed", filepath.Base(path))
+				}
+			}
+		})
+	}
+}
+
+func TestArtifactDownloadBadRange(t *testing.T) {
+	client.SetRetryOptions(client.RetryOptions{Retries: 2, MaxDelay: time.Millisecond})
+	defer client.SetRetryOptions(client.RetryOptions{
+		Retries:  client.DefaultRetries,
+		MaxDelay: client.DefaultRetryMaxDelay,
+	})
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	path := writeArtifact(t, "release-1", "rpi4")
+	if err := c.UploadArtifact(ctx, "", path, token, nil); err != nil {
+		t.Fatal(err)
+	}
+	id := srv.Artifacts()[0].ID
+	data, _ := os.ReadFile(path)
+	dir := t.TempDir()
+	partPath := filepath.Join(dir, "release-1."+id+".mender.part")
+	if err := os.WriteFile(partPath, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	etag := fmt.Sprintf("\"%x\"", sha256.Sum256(data))
+	if err := os.WriteFile(partPath+".validator", []byte(etag), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a partial content response without the expected Content-Range
+	download := "/storage/artifacts/" + id
+	srv.Fail(http.MethodGet, download, http.StatusPartialContent, 1)
+	if err := c.DownloadArtifact(ctx, dir, id, token, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "release-1.mender")); !bytes.Equal(got, data) {
+		t.Error("the downloaded artifact differs from the uploaded one")
+	}
+	downloads := 0
+	for _, r := range srv.Requests() {
+		if r == http.MethodGet+" "+download {
+			downloads++
+		}
+	}
+	if downloads != 2 {
+		t.Errorf("expected the download to start over once, got %d downloads", downloads)
+	}
+}
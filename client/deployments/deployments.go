@@ -0,0 +1,414 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package deployments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	DeploymentStatusAborted  = "aborted"
+	DeploymentStatusFinished = "finished"
+
+	defaultPerPage = 20
+)
+
+// device deployment statuses in which the device will not make any further
+// progress
+var deviceStatusFinal = map[string]bool{
+	"success":           true,
+	"already-installed": true,
+	"noartifact":        true,
+	"failure":           true,
+	"aborted":           true,
+	"decommissioned":    true,
+}
+
+// device deployment statuses considered unsuccessful
+var deviceStatusFailed = map[string]bool{
+	"failure": true,
+	"aborted": true,
+}
+
+// deployment statistics, in the order they are displayed
+var deploymentStatsOrder = []string{
+	"pending",
+	"downloading",
+	"pause_before_installing",
+	"installing",
+	"pause_before_committing",
+	"rebooting",
+	"pause_before_rebooting",
+	"success",
+	"already-installed",
+	"noartifact",
+	"failure",
+	"aborted",
+	"decommissioned",
+}
+
+type Deployment struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	ArtifactName string     `json:"artifact_name"`
+	Created      time.Time  `json:"created"`
+	Finished     *time.Time `json:"finished,omitempty"`
+	Status       string     `json:"status"`
+	DeviceCount  int        `json:"device_count"`
+	MaxDevices   int        `json:"max_devices,omitempty"`
+	Artifacts    []string   `json:"artifacts,omitempty"`
+	Groups       []string   `json:"groups,omitempty"`
+	Type         string     `json:"type,omitempty"`
+}
+
+// DeploymentStatistics maps the device deployment status to the number
+// of devices in that status
+type DeploymentStatistics map[string]int
+
+// NewDeployment describes a deployment to be created; exactly one of
+// Devices, Group and AllDevices selects the target devices
+type NewDeployment struct {
+	Name         string   `json:"name"`
+	ArtifactName string   `json:"artifact_name"`
+	Devices      []string `json:"devices,omitempty"`
+	AllDevices   bool     `json:"all_devices,omitempty"`
+	Retries      uint     `json:"retries,omitempty"`
+
+	Group string `json:"-"`
+}
+
+// Validate checks that the deployment has a name, an artifact and exactly
+// one kind of target devices
+func (d *NewDeployment) Validate() error {
+	if d.Name == "" {
+		return errors.New("deployment name is required")
+	}
+	if d.ArtifactName == "" {
+		return errors.New("artifact name is required")
+	}
+	targets := 0
+	if len(d.Devices) > 0 {
+		targets++
+	}
+	if d.Group != "" {
+		targets++
+	}
+	if d.AllDevices {
+		targets++
+	}
+	if targets != 1 {
+		return errors.New("exactly one of devices, group or all devices must be specified")
+	}
+	return nil
+}
+
+// CreateDeployment creates the deployment and returns its ID
+func (c *Client) CreateDeployment(
+	ctx context.Context,
+	deployment *NewDeployment,
+	token string,
+) (string, error) {
+	if err := deployment.Validate(); err != nil {
+		return "", err
+	}
+
+	reqURL := c.deploymentsURL
+	if deployment.Group != "" {
+		reqURL = client.JoinURL(c.deploymentsURL, "group/"+url.PathEscape(deployment.Group))
+	}
+
+	data, err := json.Marshal(deployment)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(data))
+	if err != nil {
+		return "", errors.Wrap(err, "Cannot create request")
+	}
+	req.Header.Set("Authorization", "Bearer "+string(token))
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	reqDump, _ := client.DumpRequest(req, true)
+	log.Verbf("sending request: \n%v", string(reqDump))
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "POST /deployments request failed")
+	}
+	defer rsp.Body.Close()
+
+	rspDump, _ := httputil.DumpResponse(rsp, true)
+	log.Verbf("response: \n%v\n", string(rspDump))
+
+	if rsp.StatusCode != http.StatusCreated {
+		return "", errors.Wrap(client.NewAPIError(rsp), "deployment create failed")
+	}
+
+	// the ID of the new deployment is the last element of the Location
+	return path.Base(rsp.Header.Get("Location")), nil
+}
+
+// ListDeploymentsOptions select the deployments to list
+type ListDeploymentsOptions struct {
+	// Status filters the deployments by status
+	Status string
+	// PerPage is the number of deployments requested from the server at once
+	PerPage int
+	// Limit is the maximum number of deployments returned; 0 means no limit
+	Limit int
+}
+
+// ListDeployments returns the deployments matching the options, following
+// the pages of the server
+func (c *Client) ListDeployments(
+	ctx context.Context,
+	token string,
+	opts ListDeploymentsOptions,
+) ([]Deployment, error) {
+	if opts.PerPage <= 0 {
+		opts.PerPage = defaultPerPage
+	}
+	q := url.Values{}
+	q.Set("page", "1")
+	q.Set("per_page", strconv.Itoa(opts.PerPage))
+	if opts.Status != "" {
+		q.Set("status", opts.Status)
+	}
+
+	list := []Deployment{}
+	page := 1
+	reqURL := c.deploymentsURL + "?" + q.Encode()
+	for reqURL != "" {
+		body, header, err := client.DoGetRequestWithHeaders(ctx, token, reqURL, c.client)
+		if err != nil {
+			return nil, err
+		}
+
+		var deployments []Deployment
+		err = json.Unmarshal(body, &deployments)
+		if err != nil {
+			return nil, errors.Wrap(err, "GET /deployments request failed")
+		}
+		for _, d := range deployments {
+			list = append(list, d)
+			if opts.Limit > 0 && len(list) >= opts.Limit {
+				return list, nil
+			}
+		}
+
+		// follow the Link header; if the server doesn't send one, keep
+		// requesting pages until a page is not full
+		next := client.NextPageURL(reqURL, header)
+		if next == "" && header.Get("Link") == "" && len(deployments) == opts.PerPage {
+			page++
+			q.Set("page", strconv.Itoa(page))
+			next = c.deploymentsURL + "?" + q.Encode()
+		}
+		reqURL = next
+	}
+
+	return list, nil
+}
+
+// GetDeployment returns the deployment with the given ID
+func (c *Client) GetDeployment(
+	ctx context.Context,
+	deploymentID, token string,
+) (*Deployment, error) {
+	body, err := client.DoGetRequest(
+		ctx,
+		token,
+		client.JoinURL(c.deploymentsURL, url.PathEscape(deploymentID)),
+		c.client,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var deployment Deployment
+	err = json.Unmarshal(body, &deployment)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET /deployments request failed")
+	}
+
+	return &deployment, nil
+}
+
+// GetDeploymentStatistics returns the number of devices in each status
+func (c *Client) GetDeploymentStatistics(
+	ctx context.Context,
+	deploymentID, token string,
+) (DeploymentStatistics, error) {
+	body, err := client.DoGetRequest(
+		ctx,
+		token,
+		client.JoinURL(c.deploymentsURL, url.PathEscape(deploymentID)+"/statistics"),
+		c.client,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := DeploymentStatistics{}
+	err = json.Unmarshal(body, &stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET /deployments/statistics request failed")
+	}
+
+	return stats, nil
+}
+
+// Total returns the number of devices in the deployment
+func (s DeploymentStatistics) Total() int {
+	total := 0
+	for _, n := range s {
+		total += n
+	}
+	return total
+}
+
+// Finished returns the number of devices which reached a final status
+func (s DeploymentStatistics) Finished() int {
+	finished := 0
+	for k, n := range s {
+		if deviceStatusFinal[k] {
+			finished += n
+		}
+	}
+	return finished
+}
+
+// Failed returns the number of devices which failed or were aborted
+func (s DeploymentStatistics) Failed() int {
+	failed := 0
+	for k, n := range s {
+		if deviceStatusFailed[k] {
+			failed += n
+		}
+	}
+	return failed
+}
+
+// IsFailed tells whether the device deployment status is unsuccessful
+func IsFailed(status string) bool {
+	return deviceStatusFailed[status]
+}
+
+// Keys returns the statistics keys in the order they should be displayed:
+// the known statuses first, followed by any other status in sorted order
+func (s DeploymentStatistics) Keys() []string {
+	keys := make([]string, 0, len(s))
+	known := make(map[string]bool, len(deploymentStatsOrder))
+	for _, k := range deploymentStatsOrder {
+		known[k] = true
+		if _, ok := s[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	extra := []string{}
+	for k := range s {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+// AbortDeployment aborts the deployment on the devices not finished yet
+func (c *Client) AbortDeployment(ctx context.Context, deploymentID, token string) error {
+	data, err := json.Marshal(map[string]string{"status": DeploymentStatusAborted})
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DoPutRequest(
+		ctx,
+		token,
+		client.JoinURL(c.deploymentsURL, url.PathEscape(deploymentID)+"/status"),
+		c.client,
+		bytes.NewReader(data),
+	)
+	return err
+}
+
+type DeploymentDevice struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Substate   string     `json:"substate,omitempty"`
+	DeviceType string     `json:"device_type,omitempty"`
+	Created    *time.Time `json:"created,omitempty"`
+	Finished   *time.Time `json:"finished,omitempty"`
+	Log        bool       `json:"log"`
+}
+
+// ListDeploymentDevices returns the devices of the deployment and their
+// status
+func (c *Client) ListDeploymentDevices(
+	ctx context.Context,
+	deploymentID, token string,
+) ([]DeploymentDevice, error) {
+	body, err := client.DoGetRequest(
+		ctx,
+		token,
+		client.JoinURL(c.deploymentsURL, url.PathEscape(deploymentID)+"/devices"),
+		c.client,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []DeploymentDevice
+	err = json.Unmarshal(body, &devices)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET /deployments/devices request failed")
+	}
+
+	return devices, nil
+}
+
+// GetDeploymentDeviceLog returns the deployment log of the device
+func (c *Client) GetDeploymentDeviceLog(
+	ctx context.Context,
+	deploymentID, deviceID, token string,
+) (string, error) {
+	body, err := client.DoGetRequest(
+		ctx,
+		token,
+		client.JoinURL(
+			c.deploymentsURL,
+			url.PathEscape(deploymentID)+"/devices/"+url.PathEscape(deviceID)+"/log",
+		),
+		c.client,
+	)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package deployments_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+func TestListDeployments(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	srv.AddArtifact(deployments.Artifact{Name: "release-1"}, []byte("data"))
+	srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+	for i := 0; i < 5; i++ {
+		_, err := c.CreateDeployment(ctx, &deployments.NewDeployment{
+			Name:         fmt.Sprintf("update-%d", i),
+			ArtifactName: "release-1",
+			AllDevices:   true,
+		}, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		options  deployments.ListDeploymentsOptions
+		count    int
+		requests int
+	}{
+		{name: "default page size", count: 5, requests: 1},
+		{name: "several pages", options: deployments.ListDeploymentsOptions{PerPage: 2},
+			count: 5, requests: 3},
+		{name: "full last page", options: deployments.ListDeploymentsOptions{PerPage: 5},
+			count: 5, requests: 1},
+		{name: "limit", options: deployments.ListDeploymentsOptions{PerPage: 2, Limit: 3},
+			count: 3, requests: 2},
+		{name: "status", options: deployments.ListDeploymentsOptions{
+			PerPage: 2,
+			Status:  deployments.DeploymentStatusFinished,
+		}, count: 0, requests: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := len(srv.Requests())
+			list, err := c.ListDeployments(ctx, token, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != tc.count {
+				t.Errorf("expected %d deployments, got %d", tc.count, len(list))
+			}
+			requests := 0
+			for _, r := range srv.Requests()[before:] {
+				if strings.HasPrefix(r, "GET ") {
+					requests++
+				}
+			}
+			if requests != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, requests)
+			}
+		})
+	}
+}
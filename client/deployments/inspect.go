@@ -0,0 +1,161 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package deployments
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-artifact/areader"
+	"github.com/mendersoftware/mender-artifact/artifact"
+)
+
+const (
+	SignatureNone        = "unsigned"
+	SignatureNotVerified = "signed, not verified"
+	SignatureVerified    = "signed, verified"
+	SignatureInvalid     = "signed, verification failed"
+)
+
+// LocalArtifact describes a mender artifact file read from the disk
+type LocalArtifact struct {
+	Path                  string                 `json:"path"`
+	Name                  string                 `json:"name"`
+	Info                  ArtifactInfo           `json:"info"`
+	DeviceTypesCompatible []string               `json:"device_types_compatible"`
+	Signature             string                 `json:"signature"`
+	ArtifactProvides      map[string]string      `json:"artifact_provides,omitempty"`
+	ArtifactDepends       map[string]interface{} `json:"artifact_depends,omitempty"`
+	ClearsProvides        []string               `json:"clears_artifact_provides,omitempty"`
+	Scripts               []string               `json:"scripts,omitempty"`
+	Payloads              []Payload              `json:"payloads"`
+
+	// signatureError is the reason of the failed signature verification
+	signatureError error
+}
+
+// Payload describes one of the updates contained in an artifact file
+type Payload struct {
+	Type           string                 `json:"type"`
+	Provides       map[string]string      `json:"provides,omitempty"`
+	Depends        map[string]interface{} `json:"depends,omitempty"`
+	ClearsProvides []string               `json:"clears_provides,omitempty"`
+	MetaData       map[string]interface{} `json:"meta_data,omitempty"`
+	Files          []UpdateFile           `json:"files"`
+}
+
+// SignatureError returns the reason of the failed signature verification,
+// or nil if the signature was not checked or is valid
+func (a *LocalArtifact) SignatureError() error {
+	return a.signatureError
+}
+
+// ReadLocalArtifact reads the whole artifact file, which verifies the
+// checksums of all the payload files; the signature is verified with the
+// public key, if given in PEM format
+func ReadLocalArtifact(artifactPath string, publicKey []byte) (*LocalArtifact, error) {
+	var verifier artifact.Verifier
+	if publicKey != nil {
+		v, err := artifact.NewPKIVerifier(publicKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot read the public key")
+		}
+		verifier = v
+	}
+
+	f, err := os.Open(artifactPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read artifact file")
+	}
+	defer f.Close()
+
+	local := &LocalArtifact{
+		Path:      artifactPath,
+		Signature: SignatureNone,
+	}
+
+	ar := areader.NewReader(f)
+	ar.VerifySignatureCallback = func(message, sig []byte) error {
+		// report the signature status instead of failing, so that the
+		// payloads are still checked
+		local.Signature = SignatureNotVerified
+		if verifier != nil {
+			if err := verifier.Verify(message, sig); err != nil {
+				local.Signature = SignatureInvalid
+				local.signatureError = err
+			} else {
+				local.Signature = SignatureVerified
+			}
+		}
+		return nil
+	}
+	ar.ScriptsReadCallback = func(_ io.Reader, info os.FileInfo) error {
+		local.Scripts = append(local.Scripts, info.Name())
+		return nil
+	}
+	if err := ar.ReadArtifact(); err != nil {
+		return nil, errors.Wrapf(err, "Cannot read artifact file %s", artifactPath)
+	}
+
+	info := ar.GetInfo()
+	local.Name = ar.GetArtifactName()
+	local.Info = ArtifactInfo{Format: info.Format, Version: info.Version}
+	local.DeviceTypesCompatible = ar.GetCompatibleDevices()
+	if provides, err := ar.MergeArtifactProvides(); err == nil {
+		local.ArtifactProvides = provides
+	}
+	if depends, err := ar.MergeArtifactDepends(); err == nil {
+		local.ArtifactDepends = depends
+	}
+	local.ClearsProvides = ar.MergeArtifactClearsProvides()
+
+	handlers := ar.GetHandlers()
+	indexes := make([]int, 0, len(handlers))
+	for i := range handlers {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		h := handlers[i]
+		payload := Payload{
+			Type:           h.GetUpdateType(),
+			ClearsProvides: h.GetUpdateClearsProvides(),
+			Files:          []UpdateFile{},
+		}
+		if provides, err := h.GetUpdateProvides(); err == nil {
+			payload.Provides = provides
+		}
+		if depends, err := h.GetUpdateDepends(); err == nil {
+			payload.Depends = depends
+		}
+		if metaData, err := h.GetUpdateMetaData(); err == nil {
+			payload.MetaData = metaData
+		}
+		for _, f := range h.GetUpdateAllFiles() {
+			date := f.Date
+			payload.Files = append(payload.Files, UpdateFile{
+				Name:     f.Name,
+				Checksum: string(f.Checksum),
+				Size:     f.Size,
+				Date:     &date,
+			})
+		}
+		local.Payloads = append(local.Payloads, payload)
+	}
+
+	return local, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package deployments_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+)
+
+// testdata/artifact.mender is an uncompressed artifact signed with the
+// private key of testdata/public.key, with a single payload file
+const (
+	testArtifact        = "testdata/artifact.mender"
+	testArtifactPayload = "hello from the test artifact\n"
+)
+
+func readTestData(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReadLocalArtifact(t *testing.T) {
+	tests := []struct {
+		name      string
+		publicKey []byte
+		signature string
+	}{
+		{name: "without key", signature: deployments.SignatureNotVerified},
+		{name: "with key", publicKey: readTestData(t, "public.key"),
+			signature: deployments.SignatureVerified},
+		{name: "with another key", publicKey: readTestData(t, "other-public.key"),
+			signature: deployments.SignatureInvalid},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := deployments.ReadLocalArtifact(testArtifact, tc.publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Signature != tc.signature {
+				t.Errorf("expected the signature %q, got %q", tc.signature, a.Signature)
+			}
+			if (a.SignatureError() != nil) != (tc.signature == deployments.SignatureInvalid) {
+				t.Errorf("unexpected signature error: %v", a.SignatureError())
+			}
+
+			if a.Name != "test-artifact" || a.Info.Format != "mender" || a.Info.Version != 3 {
+				t.Errorf("unexpected artifact: %+v", a)
+			}
+			if len(a.DeviceTypesCompatible) != 1 ||
+				a.DeviceTypesCompatible[0] != "raspberrypi4" {
+				t.Errorf("unexpected device types: %v", a.DeviceTypesCompatible)
+			}
+			if len(a.Scripts) != 1 || a.Scripts[0] != "ArtifactInstall_Enter_00" {
+				t.Errorf("unexpected scripts: %v", a.Scripts)
+			}
+			if len(a.Payloads) != 1 || a.Payloads[0].Type != "single-file" ||
+				len(a.Payloads[0].Files) != 1 {
+				t.Fatalf("unexpected payloads: %+v", a.Payloads)
+			}
+			f := a.Payloads[0].Files[0]
+			checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(testArtifactPayload)))
+			if f.Name != "hello.txt" || f.Checksum != checksum ||
+				f.Size != int64(len(testArtifactPayload)) {
+				t.Errorf("unexpected payload file: %+v", f)
+			}
+		})
+	}
+}
+
+func TestReadLocalArtifactCorrupted(t *testing.T) {
+	data := readTestData(t, "artifact.mender")
+	payload := []byte(testArtifactPayload)
+	if !bytes.Contains(data, payload) {
+		t.Fatal("the payload is not in the test artifact")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "tampered payload",
+			data: bytes.Replace(data, payload, bytes.ToUpper(payload), 1)},
+		{name: "tampered header",
+			data: bytes.Replace(data, []byte("raspberrypi4"), []byte("raspberrypi5"), -1)},
+		{name: "truncated", data: data[:len(data)/2]},
+		{name: "not an artifact", data: []byte("not an artifact")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "artifact.mender")
+			if err := os.WriteFile(path, tc.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range [][]byte{nil, readTestData(t, "public.key")} {
+				if a, err := deployments.ReadLocalArtifact(path, key); err == nil {
+					t.Errorf("expected an error, got %+v", a)
+				}
+			}
+		})
+	}
+
+	if _, err := deployments.ReadLocalArtifact(testArtifact, []byte("not a key")); err == nil {
+		t.Error("expected an error for an invalid public key")
+	}
+	if _, err := deployments.ReadLocalArtifact("testdata/missing.mender", nil); err == nil {
+		t.Error("expected an error for a missing artifact")
+	}
+}
@@ -16,11 +16,9 @@ package deviceconnect
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httputil"
@@ -67,8 +65,11 @@ type Client struct {
 	writeMutex *sync.Mutex
 	token      string
 	client     *http.Client
+	trace      *client.WebsocketTrace
 }
 
+// NewClient returns a client for the remote terminal and port forwarding
+// sessions with the devices of the server at the URL
 func NewClient(url string, token string, skipVerify bool) *Client {
 	return &Client{
 		url:        url,
@@ -81,8 +82,7 @@ func NewClient(url string, token string, skipVerify bool) *Client {
 }
 
 // Connect to the websocket
-func (c *Client) Connect(deviceID string, token string) error {
-	fmt.Fprintf(os.Stderr, "Connecting to the device %s...\n", deviceID)
+func (c *Client) Connect(ctx context.Context, deviceID string, token string) error {
 	u, err := url.Parse(
 		strings.TrimSuffix(
 			c.url,
@@ -101,10 +101,13 @@ func (c *Client) Connect(deviceID string, token string) error {
 
 	headers := http.Header{}
 	headers.Set("Authorization", "Bearer "+string(token))
-	websocket.DefaultDialer.TLSClientConfig = &tls.Config{
-		InsecureSkipVerify: c.skipVerify,
+	dialer := client.NewWebsocketDialer(c.skipVerify)
+	started := time.Now()
+	conn, rsp, err := dialer.DialContext(ctx, u.String(), headers)
+	c.trace = client.TraceWebsocket(u, headers, started, rsp, err)
+	if err == websocket.ErrBadHandshake && rsp != nil {
+		return errors.Wrap(client.NewAPIError(rsp), "Unable to connect to the device")
 	}
-	conn, rsp, err := websocket.DefaultDialer.Dial(u.String(), headers)
 	if err != nil {
 		return errors.Wrap(err, "Unable to connect to the device")
 	}
@@ -120,9 +123,9 @@ func (c *Client) Connect(deviceID string, token string) error {
 }
 
 // GetDevice returns the device
-func (c *Client) GetDevice(deviceID string) (*Device, error) {
+func (c *Client) GetDevice(ctx context.Context, deviceID string) (*Device, error) {
 	path := strings.Replace(devicePath, ":deviceID", deviceID, 1)
-	body, err := client.DoGetRequest(c.token, client.JoinURL(c.url, path), c.client)
+	body, err := client.DoGetRequest(ctx, c.token, client.JoinURL(c.url, path), c.client)
 	if err != nil {
 		return nil, err
 	}
@@ -141,17 +144,20 @@ func (c *Client) PingPong(ctx context.Context) {
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
 
-	c.conn.SetPongHandler(func(string) error {
+	c.conn.SetPongHandler(func(msg string) error {
+		c.trace.Received(websocket.PongMessage, []byte(msg))
 		ticker.Reset(pingPeriod)
 		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	})
 
 	c.conn.SetPingHandler(func(msg string) error {
+		c.trace.Received(websocket.PingMessage, []byte(msg))
 		ticker.Reset(pingPeriod)
 		err := c.conn.SetReadDeadline(time.Now().Add(pongWait))
 		if err != nil {
 			return err
 		}
+		c.trace.Sent(websocket.PongMessage, []byte(msg))
 		return c.conn.WriteControl(
 			websocket.PongMessage,
 			[]byte(msg),
@@ -163,6 +169,7 @@ func (c *Client) PingPong(ctx context.Context) {
 		select {
 		case <-ticker.C:
 			pongWaitString := strconv.Itoa(int(pongWait.Seconds()))
+			c.trace.Sent(websocket.PingMessage, []byte(pongWaitString))
 			_ = c.conn.WriteControl(
 				websocket.PingMessage,
 				[]byte(pongWaitString),
@@ -179,10 +186,11 @@ func (c *Client) PingPong(ctx context.Context) {
 func (c *Client) ReadMessage() (*ws.ProtoMsg, error) {
 	c.readMutex.Lock()
 	defer c.readMutex.Unlock()
-	_, data, err := c.conn.ReadMessage()
+	messageType, data, err := c.conn.ReadMessage()
 	if err != nil {
 		return nil, err
 	}
+	c.trace.Received(messageType, data)
 
 	m := &ws.ProtoMsg{}
 	err = msgpack.Unmarshal(data, m)
@@ -206,6 +214,7 @@ func (c *Client) WriteMessage(m *ws.ProtoMsg) error {
 	if err := c.conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
 		return errors.Wrap(err, "Unable to write the message")
 	}
+	c.trace.Sent(websocket.BinaryMessage, data)
 	return nil
 }
 
@@ -214,6 +223,8 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
+// NewFileTransferClient returns a client for copying files to and from
+// the devices of the server at the URL
 func NewFileTransferClient(url string, token string, skipVerify bool) *Client {
 	return &Client{
 		url:    url,
@@ -222,41 +233,18 @@ func NewFileTransferClient(url string, token string, skipVerify bool) *Client {
 	}
 }
 
+// DeviceSpec is a path on a device
 type DeviceSpec struct {
 	DeviceID   string
 	DevicePath string
 }
 
-type DeviceConnectError struct {
-	ErrorStr  string `json:"error"`
-	RequestID string `json:"request_id"`
-}
-
-func (d *DeviceConnectError) Error() string {
-	if d.ErrorStr != "" {
-		if d.RequestID != "" {
-			return fmt.Sprintf("Error: [%s] %s", d.RequestID, d.ErrorStr)
-		}
-		return fmt.Sprintf("Error: %s", d.ErrorStr)
-	}
-	return "No Error string returned from the server. This is unexpected behaviour"
-}
-
-func NewDeviceConnectError(errCode int, r io.Reader) *DeviceConnectError {
-	body, err := ioutil.ReadAll(r)
-	if err != nil {
-		return &DeviceConnectError{
-			ErrorStr: fmt.Sprintf("Failed to upload the file. HTTP status code: %d", errCode),
-		}
-	}
-	d := &DeviceConnectError{}
-	if err = json.Unmarshal(body, d); err != nil {
-		d.ErrorStr = string(body) // Just hope there is something sensible in the body
-	}
-	return d
-}
-
-func (c *Client) Upload(sourcePath string, deviceSpec *DeviceSpec) error {
+// Upload copies the local file to the path on the device
+func (c *Client) Upload(
+	ctx context.Context,
+	sourcePath string,
+	deviceSpec *DeviceSpec,
+) error {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	file, err := os.Open(sourcePath)
@@ -284,7 +272,7 @@ func (c *Client) Upload(sourcePath string, deviceSpec *DeviceSpec) error {
 	if err = writer.Close(); err != nil {
 		return err
 	}
-	req, err := http.NewRequest(http.MethodPut,
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
 		c.url+fileUploadURL+"devices/"+deviceSpec.DeviceID+"/upload",
 		body)
 	if err != nil {
@@ -293,7 +281,7 @@ func (c *Client) Upload(sourcePath string, deviceSpec *DeviceSpec) error {
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+string(c.token))
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
 	resp, err := c.client.Do(req)
@@ -302,40 +290,31 @@ func (c *Client) Upload(sourcePath string, deviceSpec *DeviceSpec) error {
 	}
 	defer resp.Body.Close()
 
-	switch resp.StatusCode {
-	case http.StatusCreated:
-		return nil
-	case http.StatusBadRequest:
-		log.Err("Error: Bad request\n")
-	case http.StatusForbidden:
-		log.Err("Error: You are not allowed to access the given resource\n")
-	case http.StatusNotFound:
-		log.Err("Error: Resource not found\n")
-	case http.StatusConflict:
-		log.Err("Error: Device not connected\n")
-	case http.StatusInternalServerError:
-		log.Errf("Error: Internal Server Error\n")
-	default:
-		log.Errf("Error: Received unexpected response code: %d\n",
-			resp.StatusCode)
-	}
-	return NewDeviceConnectError(resp.StatusCode, resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		return errors.Wrap(client.NewAPIError(resp), "file upload failed")
+	}
+	return nil
 }
 
-func (c *Client) Download(deviceSpec *DeviceSpec, sourcePath string) error {
-	req, err := http.NewRequest(http.MethodGet,
+// Download copies the file at the path on the device to the local file
+func (c *Client) Download(
+	ctx context.Context,
+	deviceSpec *DeviceSpec,
+	sourcePath string,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
 		c.url+fileUploadURL+"devices/"+deviceSpec.DeviceID+"/download",
 		nil,
 	)
 	if err != nil {
-		return nil
+		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+string(c.token))
 	q := req.URL.Query()
 	q.Add("path", deviceSpec.DevicePath)
 	req.URL.RawQuery = q.Encode()
 
-	reqDump, _ := httputil.DumpRequest(req, false)
+	reqDump, _ := client.DumpRequest(req, false)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
 	resp, err := c.client.Do(req)
@@ -347,28 +326,13 @@ func (c *Client) Download(deviceSpec *DeviceSpec, sourcePath string) error {
 	rspDump, _ := httputil.DumpResponse(resp, true)
 	log.Verbf("Response: \n%v\n", string(rspDump))
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return c.downloadFile(sourcePath, resp)
-	case http.StatusBadRequest:
-		log.Err("Bad request\n")
-	case http.StatusForbidden:
-		log.Err("Forbidden")
-	case http.StatusNotFound:
-		log.Err("File not found on the device\n")
-	case http.StatusConflict:
-		log.Err("The device is not connected\n")
-	case http.StatusInternalServerError:
-		log.Err("Internal server error\n")
-	default:
-		log.Errf("Error: Received unexpected response code: %d\n",
-			resp.StatusCode)
-	}
-	return NewDeviceConnectError(resp.StatusCode, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrap(client.NewAPIError(resp), "file download failed")
+	}
+	return c.downloadFile(sourcePath, resp)
 }
 
 func (c *Client) downloadFile(localFileName string, resp *http.Response) error {
-	path := resp.Header.Get("X-MEN-FILE-PATH")
 	uid := resp.Header.Get("X-MEN-FILE-UID")
 	gid := resp.Header.Get("X-MEN-FILE-GID")
 	mode := resp.Header.Get("X-MEN-FILE-MODE")
@@ -387,18 +351,13 @@ func (c *Client) downloadFile(localFileName string, resp *http.Response) error {
 	var n int64
 	file, err := os.OpenFile(localFileName, os.O_CREATE|os.O_WRONLY, os.FileMode(modeo))
 	if err != nil {
-		log.Errf("Failed to create the file %s locally\n", path)
-		return err
+		return errors.Wrapf(err, "Failed to create the file %s locally", localFileName)
 	}
 	defer file.Close()
 
 	if resp.Header.Get("Content-Type") != "application/octet-stream" {
 		return fmt.Errorf("Unexpected Content-Type header: %s", resp.Header.Get("Content-Type"))
 	}
-	if err != nil {
-		log.Err("downloadFile: Failed to parse the Content-Type header")
-		return err
-	}
 	n, err = io.Copy(file, resp.Body)
 	log.Verbf("wrote: %d\n", n)
 	if err != nil {
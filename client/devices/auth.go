@@ -0,0 +1,135 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package devices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	AuthSetStatusPending  = "pending"
+	AuthSetStatusAccepted = "accepted"
+	AuthSetStatusRejected = "rejected"
+)
+
+type preauthRequest struct {
+	IdentityData map[string]string `json:"identity_data"`
+	PubKey       string            `json:"pubkey"`
+}
+
+func (c *Client) deviceURLFor(deviceID string) string {
+	return strings.ReplaceAll(c.deviceURL, ":id", deviceID)
+}
+
+func (c *Client) authSetURLFor(deviceID, authSetID string) string {
+	return strings.NewReplacer(":id", deviceID, ":aid", authSetID).Replace(c.authSetURL)
+}
+
+// GetDevice returns the authentication data of the device
+func (c *Client) GetDevice(ctx context.Context, deviceID, token string) (*Device, error) {
+	body, err := client.DoGetRequest(ctx, token, c.deviceURLFor(deviceID), c.client)
+	if err != nil {
+		return nil, err
+	}
+
+	var device Device
+	err = json.Unmarshal(body, &device)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET /devauth/devices request failed")
+	}
+	return &device, nil
+}
+
+// SetAuthSetStatus accepts or rejects the authentication set of a device
+func (c *Client) SetAuthSetStatus(
+	ctx context.Context,
+	deviceID, authSetID, status, token string,
+) error {
+	data, err := json.Marshal(map[string]string{"status": status})
+	if err != nil {
+		return err
+	}
+	_, err = client.DoPutRequest(
+		ctx,
+		token,
+		c.authSetURLFor(deviceID, authSetID)+"/status",
+		c.client,
+		bytes.NewReader(data),
+	)
+	return err
+}
+
+// DismissAuthSet removes the authentication set of a device
+func (c *Client) DismissAuthSet(ctx context.Context, deviceID, authSetID, token string) error {
+	return client.DoDeleteRequest(ctx, token, c.authSetURLFor(deviceID, authSetID), c.client)
+}
+
+// DecommissionDevice removes the device and all its data from the server
+func (c *Client) DecommissionDevice(ctx context.Context, deviceID, token string) error {
+	return client.DoDeleteRequest(ctx, token, c.deviceURLFor(deviceID), c.client)
+}
+
+// PreauthorizeDevice adds a preauthorized authentication set with the
+// given identity and public key (in PEM format)
+func (c *Client) PreauthorizeDevice(
+	ctx context.Context,
+	identity map[string]string,
+	pubKey, token string,
+) error {
+	if len(identity) == 0 {
+		return errors.New("the device identity is required")
+	}
+	data, err := json.Marshal(preauthRequest{
+		IdentityData: identity,
+		PubKey:       pubKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.devicesListURL,
+		bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "Cannot create request")
+	}
+	req.Header.Set("Authorization", "Bearer "+string(token))
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	reqDump, _ := client.DumpRequest(req, false)
+	log.Verbf("sending request: \n%v", string(reqDump))
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "POST /devauth/devices request failed")
+	}
+	defer rsp.Body.Close()
+
+	rspDump, _ := httputil.DumpResponse(rsp, true)
+	log.Verbf("response: \n%v\n", string(rspDump))
+
+	if rsp.StatusCode != http.StatusCreated {
+		return errors.Wrap(client.NewAPIError(rsp), "device preauthorization failed")
+	}
+	return nil
+}
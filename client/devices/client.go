@@ -14,124 +14,220 @@
 package devices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/mendersoftware/mender-cli/client"
 )
 
-type devicesList struct {
-	devices []deviceData
+type Device struct {
+	ID              string       `json:"id"`
+	IdentityData    IdentityData `json:"identity_data"`
+	Status          string       `json:"status"`
+	CreatedTs       string       `json:"created_ts"`
+	UpdatedTs       string       `json:"updated_ts"`
+	AuthSets        []AuthSet    `json:"auth_sets"`
+	Decommissioning bool         `json:"decommissioning"`
 }
 
-type deviceData struct {
-	ID           string `json:"id"`
-	IdentityData struct {
-		Mac string `json:"mac"`
-		Sku string `json:"sku"`
-		Sn  string `json:"sn"`
-	} `json:"identity_data"`
-	Status    string `json:"status"`
-	CreatedTs string `json:"created_ts"`
-	UpdatedTs string `json:"updated_ts"`
-	AuthSets  []struct {
-		ID           string `json:"id"`
-		PubKey       string `json:"pubkey"`
-		IdentityData struct {
-			Mac string `json:"mac"`
-			Sku string `json:"sku"`
-			Sn  string `json:"sn"`
-		} `json:"identity_data"`
-		Status string `json:"status"`
-		Ts     string `json:"ts"`
-	} `json:"auth_sets"`
-	Decommissioning bool `json:"decommissioning"`
+// IdentityData holds all the identity attributes of a device, such as
+// IdentityMac; a value is a string or a list of strings
+type IdentityData map[string]interface{}
+
+// the identity attributes common to most devices
+const (
+	IdentityMac = "mac"
+	IdentitySku = "sku"
+	IdentitySn  = "sn"
+)
+
+// Get returns the attribute as a string, empty if it is missing; the
+// values of a list are separated by commas
+func (i IdentityData) Get(name string) string {
+	switch v := i[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		values := make([]string, len(v))
+		for j, value := range v {
+			values[j] = fmt.Sprint(value)
+		}
+		return strings.Join(values, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type AuthSet struct {
+	ID           string       `json:"id"`
+	PubKey       string       `json:"pubkey"`
+	IdentityData IdentityData `json:"identity_data"`
+	Status       string       `json:"status"`
+	Ts           string       `json:"ts"`
 }
 
 const (
-	devicesListURL = "/api/management/v2/devauth/devices"
+	devicesListURL     = "/api/management/v2/devauth/devices"
+	deviceURL          = "/api/management/v2/devauth/devices/:id"
+	authSetURL         = "/api/management/v2/devauth/devices/:id/auth/:aid"
+	inventoryDeviceURL = "/api/management/v1/inventory/devices/:id"
+	inventorySearchURL = "/api/management/v2/inventory/filters/search"
+	inventoryGroupsURL = "/api/management/v1/inventory/groups"
+	inventoryFilterURL = "/api/management/v2/inventory/filters"
+
+	defaultPerPage = 20
 )
 
 type Client struct {
-	url            string
-	devicesListURL string
-	client         *http.Client
+	url                string
+	devicesListURL     string
+	deviceURL          string
+	authSetURL         string
+	inventoryDeviceURL string
+	inventorySearchURL string
+	inventoryGroupsURL string
+	inventoryFilterURL string
+	client             *http.Client
 }
 
+// NewClient returns a client of the server at the URL
 func NewClient(url string, skipVerify bool) *Client {
 	return &Client{
-		url:            url,
-		devicesListURL: client.JoinURL(url, devicesListURL),
-		client:         client.NewHttpClient(skipVerify),
+		url:                url,
+		devicesListURL:     client.JoinURL(url, devicesListURL),
+		deviceURL:          client.JoinURL(url, deviceURL),
+		authSetURL:         client.JoinURL(url, authSetURL),
+		inventoryDeviceURL: client.JoinURL(url, inventoryDeviceURL),
+		inventorySearchURL: client.JoinURL(url, inventorySearchURL),
+		inventoryGroupsURL: client.JoinURL(url, inventoryGroupsURL),
+		inventoryFilterURL: client.JoinURL(url, inventoryFilterURL),
+		client:             client.NewHttpClient(skipVerify),
 	}
 }
 
-func (c *Client) ListDevices(token string, detailLevel int, raw bool) error {
-	if detailLevel > 3 || detailLevel < 0 {
-		return fmt.Errorf("FAILURE: invalid devices detail")
+// ListDevicesOptions controls which devices are returned by ListDevices
+type ListDevicesOptions struct {
+	// Status filters the devices by authentication status on the server
+	Status string
+	// PerPage is the number of devices requested from the server at once
+	PerPage int
+	// Limit is the maximum number of devices returned; 0 means no limit
+	Limit int
+	// Identity filters the devices by the non-empty identity attributes
+	Identity map[string]string
+}
+
+// matchesIdentity tells whether the device identity contains all the
+// non-empty attributes of the filter; MAC addresses ignore the case
+func matchesIdentity(filter map[string]string, identity IdentityData) bool {
+	for name, value := range filter {
+		switch {
+		case value == "":
+		case name == IdentityMac:
+			if !strings.EqualFold(value, identity.Get(name)) {
+				return false
+			}
+		case value != identity.Get(name):
+			return false
+		}
 	}
+	return true
+}
+
+// ListDevices returns the devices matching the options, following the
+// pages of the server; on failure, the devices of the pages received before
+// are returned with the error
+func (c *Client) ListDevices(
+	ctx context.Context,
+	token string,
+	opts ListDevicesOptions,
+) ([]Device, error) {
+	list := []Device{}
+	err := c.listDevices(ctx, token, opts, func(d Device, _ json.RawMessage) {
+		list = append(list, d)
+	})
+	return list, err
+}
 
-	body, err := client.DoGetRequest(token, c.devicesListURL, c.client)
+// ListDevicesRaw returns the device list as received from the server
+func (c *Client) ListDevicesRaw(
+	ctx context.Context,
+	token string,
+	opts ListDevicesOptions,
+) ([]byte, error) {
+	list := []json.RawMessage{}
+	err := c.listDevices(ctx, token, opts, func(_ Device, raw json.RawMessage) {
+		list = append(list, raw)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return json.Marshal(list)
+}
 
-	if raw {
-		fmt.Println(string(body))
-	} else {
+// listDevices walks through all the pages of the device list, calling add
+// for each device matching the options
+func (c *Client) listDevices(
+	ctx context.Context,
+	token string,
+	opts ListDevicesOptions,
+	add func(Device, json.RawMessage),
+) error {
+	if opts.PerPage <= 0 {
+		opts.PerPage = defaultPerPage
+	}
+	q := url.Values{}
+	q.Set("page", "1")
+	q.Set("per_page", strconv.Itoa(opts.PerPage))
+	if opts.Status != "" {
+		q.Set("status", opts.Status)
+	}
 
-		var list devicesList
-		err = json.Unmarshal(body, &list.devices)
+	count := 0
+	page := 1
+	reqURL := c.devicesListURL + "?" + q.Encode()
+	for reqURL != "" {
+		body, header, err := client.DoGetRequestWithHeaders(ctx, token, reqURL, c.client)
 		if err != nil {
 			return err
 		}
-		for _, v := range list.devices {
-			listDevice(v, detailLevel)
-		}
-	}
-	return nil
-}
 
-func listDevice(a deviceData, detailLevel int) {
-	fmt.Printf("ID: %s\n", a.ID)
-	fmt.Printf("Status: %s\n", a.Status)
-	if detailLevel >= 1 {
-		fmt.Println("IdentityData:")
-		if a.IdentityData.Mac != "" {
-			fmt.Printf("  MAC address: %s\n", a.IdentityData.Mac)
-		}
-		if a.IdentityData.Sku != "" {
-			fmt.Printf("  Stock keeping unit: %s\n", a.IdentityData.Sku)
-		}
-		if a.IdentityData.Sn != "" {
-			fmt.Printf("  Serial number: %s\n", a.IdentityData.Sn)
+		var raw []json.RawMessage
+		err = json.Unmarshal(body, &raw)
+		if err != nil {
+			return err
 		}
-	}
-	if detailLevel >= 1 {
-		fmt.Printf("CreatedTs: %s\n", a.CreatedTs)
-		fmt.Printf("UpdatedTs: %s\n", a.UpdatedTs)
-		fmt.Printf("Decommissioning: %t\n", a.Decommissioning)
-	}
-	if detailLevel >= 2 {
-		for i, v := range a.AuthSets {
-			fmt.Printf("AuthSet[%d]:\n", i)
-			fmt.Printf("  ID: %s\n", v.ID)
-			fmt.Printf("  PubKey:\n%s", v.PubKey)
-			fmt.Println("  IdentityData:")
-			if v.IdentityData.Mac != "" {
-				fmt.Printf("    MAC address: %s\n", v.IdentityData.Mac)
+		for _, r := range raw {
+			var d Device
+			if err := json.Unmarshal(r, &d); err != nil {
+				return err
 			}
-			if v.IdentityData.Sku != "" {
-				fmt.Printf("    Stock keeping unit: %s\n", v.IdentityData.Sku)
+			if !matchesIdentity(opts.Identity, d.IdentityData) {
+				continue
 			}
-			if v.IdentityData.Sn != "" {
-				fmt.Printf("    Serial number: %s\n", v.IdentityData.Sn)
+			add(d, r)
+			count++
+			if opts.Limit > 0 && count >= opts.Limit {
+				return nil
 			}
-			fmt.Printf("  Status: %s\n", v.Status)
-			fmt.Printf("  Ts: %s\n", v.Ts)
 		}
-	}
 
-	fmt.Println("--------------------------------------------------------------------------------")
+		// follow the Link header; if the server doesn't send one, keep
+		// requesting pages until a page is not full
+		next := client.NextPageURL(reqURL, header)
+		if next == "" && header.Get("Link") == "" && len(raw) == opts.PerPage {
+			page++
+			q.Set("page", strconv.Itoa(page))
+			next = c.devicesListURL + "?" + q.Encode()
+		}
+		reqURL = next
+	}
+	return nil
 }
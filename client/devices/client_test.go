@@ -0,0 +1,105 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package devices_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+// TestListDevicesWithoutLinks checks that the pages are counted when the
+// server doesn't send the Link header
+func TestListDevicesWithoutLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		devices  int
+		options  devices.ListDevicesOptions
+		count    int
+		requests int
+	}{
+		{name: "last page not full", devices: 5, options: devices.ListDevicesOptions{PerPage: 2},
+			count: 5, requests: 3},
+		{name: "last page full", devices: 4, options: devices.ListDevicesOptions{PerPage: 2},
+			count: 4, requests: 3},
+		{name: "no devices", options: devices.ListDevicesOptions{PerPage: 2}, requests: 1},
+		{name: "limit", devices: 5,
+			options: devices.ListDevicesOptions{PerPage: 2, Limit: 3}, count: 3, requests: 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+				list := []devices.Device{}
+				for i := (page - 1) * perPage; i < page*perPage && i < tc.devices; i++ {
+					list = append(list, devices.Device{ID: fmt.Sprint(i)})
+				}
+				_ = json.NewEncoder(w).Encode(list)
+			}
+			srv := httptest.NewServer(http.HandlerFunc(handler))
+			defer srv.Close()
+
+			c := devices.NewClient(srv.URL, false)
+			list, err := c.ListDevices(context.Background(), "token", tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != tc.count {
+				t.Errorf("expected %d devices, got %d", tc.count, len(list))
+			}
+			for i, d := range list {
+				if d.ID != fmt.Sprint(i) {
+					t.Errorf("unexpected device %d: %s", i, d.ID)
+				}
+			}
+			if requests != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, requests)
+			}
+		})
+	}
+}
+
+// TestListDevicesPartial checks that the devices of the pages received
+// before a failure are returned with the error
+func TestListDevicesPartial(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Link", `<?page=2&per_page=2>; rel="next"`)
+		_ = json.NewEncoder(w).Encode([]devices.Device{{ID: "0"}, {ID: "1"}})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	defer srv.Close()
+
+	c := devices.NewClient(srv.URL, false)
+	list, err := c.ListDevices(context.Background(), "token",
+		devices.ListDevicesOptions{PerPage: 2})
+	if err == nil {
+		t.Error("expected an error")
+	}
+	if len(list) != 2 || list[0].ID != "0" || list[1].ID != "1" {
+		t.Errorf("expected the devices of the first page, got %+v", list)
+	}
+}
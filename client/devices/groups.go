@@ -0,0 +1,215 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package devices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+const (
+	GroupTypeStatic  = "static"
+	GroupTypeDynamic = "dynamic"
+
+	// inventory attribute holding the static group of a device
+	groupAttribute = "group"
+)
+
+// Group is a static group, or a dynamic group defined by filter terms
+type Group struct {
+	ID    string            `json:"id,omitempty"`
+	Name  string            `json:"name"`
+	Type  string            `json:"type"`
+	Terms []InventoryFilter `json:"terms,omitempty"`
+}
+
+type savedFilter struct {
+	ID    string            `json:"id,omitempty"`
+	Name  string            `json:"name"`
+	Terms []InventoryFilter `json:"terms"`
+}
+
+// ListGroups returns the static groups followed by the dynamic ones
+func (c *Client) ListGroups(ctx context.Context, token string) ([]Group, error) {
+	body, err := client.DoGetRequest(ctx, token, c.inventoryGroupsURL, c.client)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(body, &names); err != nil {
+		return nil, errors.Wrap(err, "GET /inventory/groups request failed")
+	}
+	groups := make([]Group, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, Group{Name: name, Type: GroupTypeStatic})
+	}
+
+	filters, err := c.listSavedFilters(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range filters {
+		groups = append(groups, Group{
+			ID:    f.ID,
+			Name:  f.Name,
+			Type:  GroupTypeDynamic,
+			Terms: f.Terms,
+		})
+	}
+	return groups, nil
+}
+
+func (c *Client) listSavedFilters(ctx context.Context, token string) ([]savedFilter, error) {
+	body, err := client.DoGetRequest(ctx, token, c.inventoryFilterURL, c.client)
+	if err != nil {
+		return nil, err
+	}
+	var filters []savedFilter
+	if err := json.Unmarshal(body, &filters); err != nil {
+		return nil, errors.Wrap(err, "GET /inventory/filters request failed")
+	}
+	return filters, nil
+}
+
+// GetGroup returns the group with the given name; dynamic groups take
+// precedence over the static ones
+func (c *Client) GetGroup(ctx context.Context, name, token string) (*Group, error) {
+	groups, err := c.ListGroups(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	var found *Group
+	for i, g := range groups {
+		if g.Name != name {
+			continue
+		}
+		if found == nil || g.Type == GroupTypeDynamic {
+			found = &groups[i]
+		}
+	}
+	if found == nil {
+		return nil, errors.Errorf("group %q not found", name)
+	}
+	return found, nil
+}
+
+// ListGroupDevices returns the inventory of the devices in the group
+func (c *Client) ListGroupDevices(
+	ctx context.Context,
+	group *Group,
+	token string,
+	perPage, limit int,
+) ([]InventoryDevice, error) {
+	terms := group.Terms
+	if group.Type == GroupTypeStatic {
+		terms = []InventoryFilter{{
+			Scope:     ScopeSystem,
+			Attribute: groupAttribute,
+			Type:      FilterEqual,
+			Value:     group.Name,
+		}}
+	}
+	return c.SearchInventory(ctx, token, terms, perPage, limit)
+}
+
+func (c *Client) groupDevicesURL(name string) string {
+	return client.JoinURL(c.inventoryGroupsURL, url.PathEscape(name)+"/devices")
+}
+
+// AddToGroup adds the devices to the static group, creating it if needed
+func (c *Client) AddToGroup(
+	ctx context.Context,
+	name string,
+	deviceIDs []string,
+	token string,
+) error {
+	data, err := json.Marshal(deviceIDs)
+	if err != nil {
+		return err
+	}
+	_, err = client.DoPatchRequest(
+		ctx,
+		token,
+		c.groupDevicesURL(name),
+		c.client,
+		bytes.NewReader(data),
+	)
+	return err
+}
+
+// RemoveFromGroup removes the devices from the static group
+func (c *Client) RemoveFromGroup(
+	ctx context.Context,
+	name string,
+	deviceIDs []string,
+	token string,
+) error {
+	data, err := json.Marshal(deviceIDs)
+	if err != nil {
+		return err
+	}
+	_, err = client.DoRequest(
+		ctx,
+		http.MethodDelete,
+		token,
+		c.groupDevicesURL(name),
+		c.client,
+		bytes.NewReader(data),
+	)
+	return err
+}
+
+// CreateDynamicGroup saves the filter terms as a dynamic group
+func (c *Client) CreateDynamicGroup(
+	ctx context.Context,
+	name string,
+	terms []InventoryFilter,
+	token string,
+) error {
+	if len(terms) == 0 {
+		return errors.New("a dynamic group requires at least one filter")
+	}
+	data, err := json.Marshal(savedFilter{Name: name, Terms: terms})
+	if err != nil {
+		return err
+	}
+	_, err = client.DoPostRequest(ctx, token, c.inventoryFilterURL, c.client, bytes.NewReader(data))
+	return err
+}
+
+// DeleteGroup deletes the dynamic group, or removes all the devices from
+// the static group
+func (c *Client) DeleteGroup(ctx context.Context, group *Group, token string) error {
+	if group.Type == GroupTypeDynamic {
+		return client.DoDeleteRequest(
+			ctx,
+			token,
+			client.JoinURL(c.inventoryFilterURL, url.PathEscape(group.ID)),
+			c.client,
+		)
+	}
+	return client.DoDeleteRequest(
+		ctx,
+		token,
+		client.JoinURL(c.inventoryGroupsURL, url.PathEscape(group.Name)),
+		c.client,
+	)
+}
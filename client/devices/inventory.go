@@ -0,0 +1,176 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package devices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+const (
+	ScopeInventory = "inventory"
+	ScopeIdentity  = "identity"
+	ScopeSystem    = "system"
+	ScopeTags      = "tags"
+
+	FilterEqual    = "$eq"
+	FilterNotEqual = "$ne"
+)
+
+type InventoryDevice struct {
+	ID         string               `json:"id"`
+	Attributes []InventoryAttribute `json:"attributes"`
+	UpdatedTs  string               `json:"updated_ts,omitempty"`
+}
+
+type InventoryAttribute struct {
+	Name        string      `json:"name"`
+	Scope       string      `json:"scope"`
+	Value       interface{} `json:"value"`
+	Description string      `json:"description,omitempty"`
+}
+
+// Attribute returns the value of the attribute with the given scope and name
+func (d *InventoryDevice) Attribute(scope, name string) (interface{}, bool) {
+	for _, a := range d.Attributes {
+		if a.Scope == scope && a.Name == name {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// InventoryFilter is a single search term of an inventory search
+type InventoryFilter struct {
+	Scope     string      `json:"scope"`
+	Attribute string      `json:"attribute"`
+	Type      string      `json:"type"`
+	Value     interface{} `json:"value"`
+}
+
+// ParseInventoryFilter parses a filter expression in the form
+// [SCOPE:]ATTRIBUTE=VALUE or [SCOPE:]ATTRIBUTE!=VALUE; the scope
+// defaults to "inventory"
+func ParseInventoryFilter(expr string) (*InventoryFilter, error) {
+	filter := &InventoryFilter{
+		Scope: ScopeInventory,
+		Type:  FilterEqual,
+	}
+	i := strings.Index(expr, "=")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid filter %q: expected ATTRIBUTE=VALUE", expr)
+	}
+	key, value := expr[:i], expr[i+1:]
+	if strings.HasSuffix(key, "!") {
+		filter.Type = FilterNotEqual
+		key = strings.TrimSuffix(key, "!")
+	}
+	if j := strings.Index(key, ":"); j >= 0 {
+		filter.Scope, key = key[:j], key[j+1:]
+	}
+	if key == "" || filter.Scope == "" {
+		return nil, fmt.Errorf("invalid filter %q: expected ATTRIBUTE=VALUE", expr)
+	}
+	filter.Attribute = key
+	filter.Value = value
+	return filter, nil
+}
+
+type inventorySearch struct {
+	Page    int               `json:"page"`
+	PerPage int               `json:"per_page"`
+	Filters []InventoryFilter `json:"filters"`
+}
+
+// GetInventoryDevice returns the inventory attributes of the device
+func (c *Client) GetInventoryDevice(
+	ctx context.Context,
+	deviceID, token string,
+) (*InventoryDevice, error) {
+	body, err := client.DoGetRequest(
+		ctx,
+		token,
+		strings.ReplaceAll(c.inventoryDeviceURL, ":id", deviceID),
+		c.client,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var device InventoryDevice
+	err = json.Unmarshal(body, &device)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET /inventory/devices request failed")
+	}
+	return &device, nil
+}
+
+// SearchInventory returns the devices matching all the filters, walking
+// through all the result pages; limit 0 returns all the devices
+func (c *Client) SearchInventory(
+	ctx context.Context,
+	token string,
+	filters []InventoryFilter,
+	perPage, limit int,
+) ([]InventoryDevice, error) {
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	if filters == nil {
+		filters = []InventoryFilter{}
+	}
+	list := []InventoryDevice{}
+	for page := 1; ; page++ {
+		data, err := json.Marshal(inventorySearch{
+			Page:    page,
+			PerPage: perPage,
+			Filters: filters,
+		})
+		if err != nil {
+			return nil, err
+		}
+		body, err := client.DoPostRequest(
+			ctx,
+			token,
+			c.inventorySearchURL,
+			c.client,
+			bytes.NewReader(data),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var devices []InventoryDevice
+		err = json.Unmarshal(body, &devices)
+		if err != nil {
+			return nil, errors.Wrap(err, "POST /inventory/filters/search request failed")
+		}
+		for _, d := range devices {
+			list = append(list, d)
+			if limit > 0 && len(list) >= limit {
+				return list, nil
+			}
+		}
+		if len(devices) < perPage {
+			return list, nil
+		}
+	}
+}
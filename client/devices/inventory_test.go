@@ -0,0 +1,88 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package devices_test
+
+import (
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+func TestParseInventoryFilter(t *testing.T) {
+	tests := []struct {
+		expr   string
+		filter *devices.InventoryFilter
+	}{
+		{
+			expr: "device_type=raspberrypi4",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeInventory,
+				Attribute: "device_type", Type: devices.FilterEqual, Value: "raspberrypi4"},
+		},
+		{
+			expr: "device_type!=raspberrypi4",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeInventory,
+				Attribute: "device_type", Type: devices.FilterNotEqual, Value: "raspberrypi4"},
+		},
+		{
+			expr: "identity:mac=00:11:22:33:44:55",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeIdentity,
+				Attribute: "mac", Type: devices.FilterEqual, Value: "00:11:22:33:44:55"},
+		},
+		{
+			expr: "tags:location!=lab",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeTags,
+				Attribute: "location", Type: devices.FilterNotEqual, Value: "lab"},
+		},
+		{
+			// numeric values are passed on as strings, like all the others
+			expr: "mem_total_kB=1024",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeInventory,
+				Attribute: "mem_total_kB", Type: devices.FilterEqual, Value: "1024"},
+		},
+		{
+			expr: "system:group=",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeSystem,
+				Attribute: "group", Type: devices.FilterEqual, Value: ""},
+		},
+		{
+			// only the first "=" separates the attribute from the value
+			expr: "kernel=console=ttyS0",
+			filter: &devices.InventoryFilter{Scope: devices.ScopeInventory,
+				Attribute: "kernel", Type: devices.FilterEqual, Value: "console=ttyS0"},
+		},
+		{expr: "device_type"},
+		{expr: "=raspberrypi4"},
+		{expr: "!=raspberrypi4"},
+		{expr: ":device_type=raspberrypi4"},
+		{expr: "identity:=raspberrypi4"},
+		{expr: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			filter, err := devices.ParseInventoryFilter(test.expr)
+			if test.filter == nil {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *filter != *test.filter {
+				t.Errorf("expected %+v, got %+v", test.filter, filter)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+
+// Package client holds what the Mender API clients share: the HTTP
+// transport and its TLS, proxy and retry settings, the request helpers,
+// the APIError returned for the error responses of the server, and the
+// HAR trace of the requests started by StartTrace.
+//
+// The clients of the services are in the subpackages:
+//
+//   - deployments: artifacts and deployments
+//   - devices: device authentication, inventory and groups
+//   - useradm: login, personal access tokens and tenants
+//   - deviceconnect: remote terminal, port forwarding and file transfer
+//
+// All their methods take a context, which cancels the request, and return
+// typed values; they never print. For example:
+//
+//	c := deployments.NewClient("https://hosted.mender.io", false)
+//	artifacts, err := c.ListArtifacts(ctx, token)
+package client
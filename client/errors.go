@@ -0,0 +1,93 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// the header holding the request ID, if it's not in the error body
+	requestIDHeader = "X-MEN-RequestID"
+
+	// only the beginning of an error body which isn't JSON is shown
+	maxErrorBodySize = 512
+)
+
+// APIError is an error response of the server; the Mender services send
+// the reason and the request ID in a JSON body
+type APIError struct {
+	Method     string `json:"-"`
+	Path       string `json:"-"`
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+	RequestID  string `json:"request_id"`
+
+	// the request was authorized with a token, not with the credentials
+	withToken bool
+	// the Retry-After header of the response
+	retryAfter string
+}
+
+// NewAPIError decodes the error response; the caller closes the body
+func NewAPIError(rsp *http.Response) *APIError {
+	e := &APIError{StatusCode: rsp.StatusCode}
+	if rsp.Request != nil {
+		e.Method = rsp.Request.Method
+		// the query may hold credentials, e.g. of pre-signed links
+		e.Path = rsp.Request.URL.Path
+		e.withToken = strings.HasPrefix(rsp.Request.Header.Get("Authorization"), "Bearer ")
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(rsp.Body, 64*1024))
+	if err := json.Unmarshal(body, e); err != nil || e.Message == "" {
+		e.Message = strings.Join(strings.Fields(string(body)), " ")
+		if len(e.Message) > maxErrorBodySize {
+			e.Message = e.Message[:maxErrorBodySize] + "..."
+		}
+	}
+	if e.RequestID == "" {
+		e.RequestID = rsp.Header.Get(requestIDHeader)
+	}
+	e.retryAfter = rsp.Header.Get("Retry-After")
+	return e
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	if e.tokenRejected() {
+		b.WriteString(ErrUnauthorized.Error() + ": ")
+	}
+	fmt.Fprintf(&b, "%s %s failed with status %d", e.Method, e.Path, e.StatusCode)
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request ID: %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *APIError) tokenRejected() bool {
+	return e.withToken && e.StatusCode == http.StatusUnauthorized
+}
+
+// Is makes the responses rejecting the token match ErrUnauthorized
+func (e *APIError) Is(target error) bool {
+	return target == ErrUnauthorized && e.tokenRejected()
+}
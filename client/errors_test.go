@@ -0,0 +1,119 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+func newResponse(status int, auth, body string, header http.Header) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet,
+		"https://example.com/api/management/v1/deployments/artifacts?secret=x", nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	const path = "/api/management/v1/deployments/artifacts"
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		header    http.Header
+		message   string
+		requestID string
+	}{
+		{
+			name:      "JSON",
+			status:    http.StatusBadRequest,
+			body:      `{"error":"invalid name","request_id":"r1"}`,
+			header:    http.Header{"X-Men-Requestid": {"r2"}},
+			message:   "invalid name",
+			requestID: "r1",
+		},
+		{
+			name:      "request ID header",
+			status:    http.StatusConflict,
+			body:      `{"error":"Artifact not unique"}`,
+			header:    http.Header{"X-Men-Requestid": {"r2"}},
+			message:   "Artifact not unique",
+			requestID: "r2",
+		},
+		{
+			name:    "HTML",
+			status:  http.StatusBadGateway,
+			body:    "<html>\n  <body>Bad   Gateway</body>\n</html>",
+			message: "<html> <body>Bad Gateway</body> </html>",
+		},
+		{
+			name:    "long body",
+			status:  http.StatusInternalServerError,
+			body:    strings.Repeat("x", 1000),
+			message: strings.Repeat("x", 512) + "...",
+		},
+		{name: "no body", status: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		e := client.NewAPIError(newResponse(tc.status, "", tc.body, tc.header))
+		if e.StatusCode != tc.status || e.Method != http.MethodGet || e.Path != path ||
+			e.Message != tc.message || e.RequestID != tc.requestID {
+			t.Errorf("%s: unexpected error %+v", tc.name, e)
+		}
+		if strings.Contains(e.Error(), "secret") {
+			t.Errorf("%s: the query is in the error: %s", tc.name, e)
+		}
+		if tc.requestID != "" && !strings.Contains(e.Error(), "(request ID: "+tc.requestID+")") {
+			t.Errorf("%s: the request ID is not in the error: %s", tc.name, e)
+		}
+	}
+}
+
+func TestAPIErrorUnauthorized(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		auth         string
+		unauthorized bool
+	}{
+		{name: "token rejected", status: http.StatusUnauthorized, auth: "Bearer x",
+			unauthorized: true},
+		{name: "wrong credentials", status: http.StatusUnauthorized, auth: "Basic x"},
+		{name: "forbidden", status: http.StatusForbidden, auth: "Bearer x"},
+		{name: "server error", status: http.StatusInternalServerError, auth: "Bearer x"},
+	}
+	for _, tc := range tests {
+		var err error = client.NewAPIError(newResponse(tc.status, tc.auth, "", nil))
+		err = errors.Wrap(err, "request failed")
+		if errors.Is(err, client.ErrUnauthorized) != tc.unauthorized {
+			t.Errorf("%s: unexpected match of %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client
+
+import (
+	"io"
+)
+
+// ProgressFunc is called during a transfer with the number of bytes
+// transferred so far and the total size; a transfer sent again after a
+// failure starts over from zero
+type ProgressFunc func(transferred, total int64)
+
+// ProgressReader reports the bytes read through it to the progress
+// function, which may be nil
+type ProgressReader struct {
+	r        io.Reader
+	n        int64
+	total    int64
+	progress ProgressFunc
+}
+
+// NewProgressReader returns a reader reporting the progress of a transfer
+// resumed at offset
+func NewProgressReader(r io.Reader, offset, total int64, progress ProgressFunc) *ProgressReader {
+	if progress != nil {
+		progress(offset, total)
+	}
+	return &ProgressReader{r: r, n: offset, total: total, progress: progress}
+}
+
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if n > 0 && r.progress != nil {
+		r.progress(r.n, r.total)
+	}
+	return n, err
+}
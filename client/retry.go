@@ -0,0 +1,260 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	DefaultRetries       = 3
+	DefaultRetryMaxDelay = time.Minute
+
+	retryBaseDelay = time.Second
+)
+
+// RetryOptions configures the retries of the failed requests
+type RetryOptions struct {
+	// Retries is the number of retries after the first attempt; zero
+	// disables the retries
+	Retries int
+	// MaxDelay limits the backoff, and the Retry-After delay the server
+	// may ask for; the request is not retried if it asks for more
+	MaxDelay time.Duration
+	// NonIdempotent retries the POST and PATCH requests after server
+	// errors and lost connections too, which may repeat their effect
+	NonIdempotent bool
+}
+
+var retryOptions = RetryOptions{
+	Retries:  DefaultRetries,
+	MaxDelay: DefaultRetryMaxDelay,
+}
+
+// SetRetryOptions configures the retries of all the clients
+func SetRetryOptions(opts RetryOptions) {
+	retryOptions = opts
+}
+
+// MaxRetries returns the number of retries after the first attempt
+func MaxRetries() int {
+	return retryOptions.Retries
+}
+
+// RetryBackoff returns the delay before the given retry, counted from
+// zero: an exponential backoff with jitter
+func RetryBackoff(attempt int) time.Duration {
+	delay := retryOptions.MaxDelay
+	if attempt < 16 && retryBaseDelay<<attempt < delay {
+		delay = retryBaseDelay << attempt
+	}
+	// wait between half and all of the delay, so that the clients
+	// failing together don't retry together
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut,
+		http.MethodDelete:
+		return true
+	}
+	return retryOptions.NonIdempotent
+}
+
+// parseRetryAfter returns the delay given by the Retry-After header, in
+// seconds or as a date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// isTransientError tells whether the request may succeed if sent again,
+// and whether it was sent at all
+func isTransientError(err error) (transient, notSent bool) {
+	if errors.Is(err, context.Canceled) {
+		return false, false
+	}
+	var certErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &hostErr) || errors.As(err, &invalidErr) {
+		return false, false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true, opErr.Op == "dial"
+	}
+	// the connection was closed, or timed out, e.g. awaiting the headers
+	var netErr net.Error
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return true, false
+	}
+	return false, false
+}
+
+// retryDelay tells whether to retry the request after the attempt failed
+// with the status or the error, and how long to wait first
+func retryDelay(
+	attempt int,
+	idempotent bool,
+	status int,
+	retryAfter string,
+	err error,
+) (time.Duration, bool) {
+	if attempt >= retryOptions.Retries {
+		return 0, false
+	}
+	if err != nil {
+		transient, notSent := isTransientError(err)
+		if !transient || !(idempotent || notSent) {
+			return 0, false
+		}
+		return RetryBackoff(attempt), true
+	}
+
+	switch status {
+	case http.StatusTooManyRequests:
+		// the request was rejected without being processed
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !idempotent {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if delay, ok := parseRetryAfter(retryAfter); ok {
+		if delay > retryOptions.MaxDelay {
+			return 0, false
+		}
+		return delay, true
+	}
+	return RetryBackoff(attempt), true
+}
+
+// SleepContext waits for the duration, or until the context is done
+func SleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retry calls do until it succeeds, or fails with an error which isn't
+// transient, or the retries are exhausted, or the context is done; the
+// errors of the responses must be APIErrors. Unless idempotent, or the
+// non-idempotent retries are enabled, only the requests which were not
+// processed are sent again
+func Retry(ctx context.Context, idempotent bool, do func() error) error {
+	idempotent = idempotent || retryOptions.NonIdempotent
+	for attempt := 0; ; attempt++ {
+		err := do()
+		if err == nil {
+			return nil
+		}
+
+		var delay time.Duration
+		var retry bool
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			delay, retry = retryDelay(attempt, idempotent, apiErr.StatusCode,
+				apiErr.retryAfter, nil)
+		} else {
+			delay, retry = retryDelay(attempt, idempotent, 0, "", err)
+		}
+		if !retry {
+			return err
+		}
+		log.Verbf("request failed: %s; retrying in %s\n", err, delay.Round(time.Millisecond))
+		if err := SleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// retryTransport sends the requests again after transient failures; the
+// requests whose body can't be sent again are not retried
+type retryTransport struct {
+	next http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	idempotent := isIdempotent(req.Method)
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		rsp, err := t.next.RoundTrip(r)
+		if !replayable {
+			return rsp, err
+		}
+		status, retryAfter := 0, ""
+		if rsp != nil {
+			status, retryAfter = rsp.StatusCode, rsp.Header.Get("Retry-After")
+		}
+		delay, retry := retryDelay(attempt, idempotent, status, retryAfter, err)
+		if !retry {
+			return rsp, err
+		}
+
+		if rsp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(rsp.Body, 64*1024))
+			rsp.Body.Close()
+			log.Verbf("%s %s failed with status %d; retrying in %s\n",
+				req.Method, req.URL.Path, status, delay.Round(time.Millisecond))
+		} else {
+			log.Verbf("%s %s failed: %s; retrying in %s\n",
+				req.Method, req.URL.Path, err, delay.Round(time.Millisecond))
+		}
+		if err := SleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
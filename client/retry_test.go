@@ -0,0 +1,269 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client_test
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+// setRetryOptions configures the retries for the test
+func setRetryOptions(t *testing.T, opts client.RetryOptions) {
+	t.Cleanup(func() {
+		client.SetRetryOptions(client.RetryOptions{
+			Retries:  client.DefaultRetries,
+			MaxDelay: client.DefaultRetryMaxDelay,
+		})
+	})
+	client.SetRetryOptions(opts)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	setRetryOptions(t, client.RetryOptions{Retries: 3, MaxDelay: 10 * time.Second})
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: time.Second},
+		{attempt: 1, max: 2 * time.Second},
+		{attempt: 3, max: 8 * time.Second},
+		{attempt: 4, max: 10 * time.Second},
+		{attempt: 100, max: 10 * time.Second},
+	}
+	for _, tc := range tests {
+		for i := 0; i < 100; i++ {
+			if d := client.RetryBackoff(tc.attempt); d < tc.max/2 || d > tc.max {
+				t.Fatalf("attempt %d: delay %s out of [%s, %s]",
+					tc.attempt, d, tc.max/2, tc.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{value: ""},
+		{value: "abc"},
+		{value: "-1"},
+		{value: "0", ok: true},
+		{value: "5", delay: 5 * time.Second, ok: true},
+		{value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), ok: true},
+		{
+			value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			delay: time.Hour,
+			ok:    true,
+		},
+	}
+	for _, tc := range tests {
+		delay, ok := client.ParseRetryAfter(tc.value)
+		// the date has a precision of a second
+		if ok != tc.ok || delay > tc.delay || delay < tc.delay-time.Second {
+			t.Errorf("%q: got %s, %v", tc.value, delay, ok)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsTransientError(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+		notSent   bool
+	}{
+		{name: "dial", err: dialErr, transient: true, notSent: true},
+		{
+			name:      "dial in URL error",
+			err:       &url.Error{Op: "Post", URL: "https://x", Err: dialErr},
+			transient: true,
+			notSent:   true,
+		},
+		{name: "read", err: readErr, transient: true},
+		{name: "EOF", err: io.EOF, transient: true},
+		{name: "unexpected EOF", err: errors.Wrap(io.ErrUnexpectedEOF, "x"), transient: true},
+		{name: "timeout", err: timeoutError{}, transient: true},
+		{name: "canceled", err: errors.Wrap(context.Canceled, "x")},
+		{name: "unknown authority", err: x509.UnknownAuthorityError{}},
+		{name: "hostname", err: x509.HostnameError{}},
+		{name: "other", err: errors.New("other")},
+	}
+	for _, tc := range tests {
+		transient, notSent := client.IsTransientError(tc.err)
+		if transient != tc.transient || notSent != tc.notSent {
+			t.Errorf("%s: got %v, %v", tc.name, transient, notSent)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	setRetryOptions(t, client.RetryOptions{Retries: 3, MaxDelay: time.Minute})
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name       string
+		attempt    int
+		idempotent bool
+		status     int
+		retryAfter string
+		err        error
+		retry      bool
+		// the exact delay, if asked for by the server
+		delay time.Duration
+	}{
+		{name: "too many requests", status: http.StatusTooManyRequests, retry: true},
+		{
+			name:       "too many requests with Retry-After",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "2",
+			retry:      true,
+			delay:      2 * time.Second,
+		},
+		{
+			name:       "Retry-After too long",
+			idempotent: true,
+			status:     http.StatusTooManyRequests,
+			retryAfter: "120",
+		},
+		{
+			name:       "server error",
+			idempotent: true,
+			status:     http.StatusServiceUnavailable,
+			retry:      true,
+		},
+		{
+			name:       "server error with Retry-After",
+			idempotent: true,
+			status:     http.StatusInternalServerError,
+			retryAfter: "1",
+			retry:      true,
+			delay:      time.Second,
+		},
+		{name: "server error not idempotent", status: http.StatusBadGateway},
+		{name: "client error", idempotent: true, status: http.StatusNotFound},
+		{
+			name:       "retries exhausted",
+			attempt:    3,
+			idempotent: true,
+			status:     http.StatusServiceUnavailable,
+		},
+		{name: "dial error", err: dialErr, retry: true},
+		{name: "read error", idempotent: true, err: readErr, retry: true},
+		{name: "read error not idempotent", err: readErr},
+		{name: "canceled", idempotent: true, err: context.Canceled},
+	}
+	for _, tc := range tests {
+		delay, retry := client.RetryDelay(tc.attempt, tc.idempotent, tc.status,
+			tc.retryAfter, tc.err)
+		if retry != tc.retry {
+			t.Errorf("%s: expected retry %v", tc.name, tc.retry)
+		} else if tc.delay != 0 && delay != tc.delay {
+			t.Errorf("%s: expected the delay %s, got %s", tc.name, tc.delay, delay)
+		} else if retry && tc.delay == 0 && delay > time.Second {
+			t.Errorf("%s: unexpected backoff %s", tc.name, delay)
+		}
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	for _, nonIdempotent := range []bool{false, true} {
+		setRetryOptions(t, client.RetryOptions{
+			Retries:       1,
+			MaxDelay:      time.Millisecond,
+			NonIdempotent: nonIdempotent,
+		})
+		attempts := 0
+		err := client.Retry(context.Background(), false, func() error {
+			attempts++
+			return &client.APIError{StatusCode: http.StatusServiceUnavailable}
+		})
+		if err == nil {
+			t.Error("expected the error")
+		}
+		if want := map[bool]int{false: 1, true: 2}[nonIdempotent]; attempts != want {
+			t.Errorf("non-idempotent retries %v: expected %d attempts, got %d",
+				nonIdempotent, want, attempts)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	setRetryOptions(t, client.RetryOptions{Retries: 1, MaxDelay: 200 * time.Millisecond})
+	const path = "/api/management/v1/deployments/artifacts"
+
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		requests int
+	}{
+		{name: "too many requests", method: http.MethodGet,
+			status: http.StatusTooManyRequests, requests: 2},
+		{name: "too many requests on POST", method: http.MethodPost,
+			status: http.StatusTooManyRequests, requests: 2},
+		{name: "server error", method: http.MethodGet,
+			status: http.StatusServiceUnavailable, requests: 2},
+		{name: "server error on POST", method: http.MethodPost,
+			status: http.StatusServiceUnavailable, requests: 1},
+		{name: "connection lost", method: http.MethodGet,
+			status: fakeserver.ConnectionLost, requests: 2},
+		{name: "connection lost on POST", method: http.MethodPost,
+			status: fakeserver.ConnectionLost, requests: 1},
+		{name: "client error", method: http.MethodGet,
+			status: http.StatusNotFound, requests: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := fakeserver.New()
+			defer srv.Close()
+			token := srv.Token("user@example.com")
+			c := client.NewHttpClient(false)
+
+			srv.Fail(tc.method, path, tc.status, 1)
+			start := time.Now()
+			_, _ = client.DoRequest(context.Background(), tc.method, token,
+				srv.URL+path, c, strings.NewReader("{}"))
+			if n := len(srv.Requests()); n != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, n)
+			}
+			// the backoff is half of the maximum delay at least
+			if tc.status == http.StatusTooManyRequests &&
+				time.Since(start) > 80*time.Millisecond {
+				t.Error("the Retry-After delay was not honoured")
+			}
+		})
+	}
+}
@@ -0,0 +1,599 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	harVersion = "1.2"
+
+	// the bodies are recorded up to this size
+	maxTraceBody = 1024 * 1024
+	// the data of the websocket messages is recorded up to this size per
+	// message, and per connection
+	maxTraceMessage   = 64 * 1024
+	maxTraceWebsocket = 16 * maxTraceBody
+
+	redacted = "REDACTED"
+)
+
+// the headers and query parameters holding credentials, which the trace
+// and the request dumps hide
+var (
+	redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	redactedParams  = []string{"signature", "credential", "token"}
+)
+
+// the trace being recorded, if any; all the trace data is guarded by
+// traceMutex
+var (
+	traceMutex sync.Mutex
+	trace      *harLog
+)
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	// the websocket connections, as recorded by the browsers
+	ResourceType      string                `json:"_resourceType,omitempty"`
+	WebSocketMessages []harWebSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harContent    `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	// the request failed without a response
+	Error string `json:"_error,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harContent is the content of a response, or the postData of a request
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+type harWebSocketMessage struct {
+	Type    string  `json:"type"`
+	Time    float64 `json:"time"`
+	Opcode  int     `json:"opcode"`
+	Data    string  `json:"data"`
+	Size    int     `json:"size"`
+	Comment string  `json:"comment,omitempty"`
+}
+
+// StartTrace starts recording the requests and responses of all the
+// clients, with their timings, and the websocket messages of the
+// deviceconnect sessions; the credentials are not recorded
+func StartTrace(creator, version string) {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	trace = &harLog{
+		Version: harVersion,
+		Creator: harCreator{Name: creator, Version: version},
+		Entries: []*harEntry{},
+	}
+}
+
+// WriteTrace writes what has been recorded since StartTrace in the HAR
+// format; the transfers in progress are written as far as they got
+func WriteTrace(w io.Writer) error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	if trace == nil {
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]*harLog{"log": trace})
+}
+
+// StopTrace stops recording, dropping what has been recorded
+func StopTrace() {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	trace = nil
+}
+
+func tracing() bool {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	return trace != nil
+}
+
+// addTraceEntry adds the entry to the trace, if any; the caller holds
+// traceMutex
+func addTraceEntry(e *harEntry) {
+	if trace != nil {
+		trace.Entries = append(trace.Entries, e)
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// roundMilliseconds rounds the sum of the durations in milliseconds to the
+// microsecond
+func roundMilliseconds(ms float64) float64 {
+	return math.Round(ms*1000) / 1000
+}
+
+func isRedacted(name string, list []string, substring bool) bool {
+	for _, r := range list {
+		if strings.EqualFold(name, r) ||
+			substring && strings.Contains(strings.ToLower(name), r) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeader returns a copy of the header with the credentials hidden
+func redactHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for name := range h {
+		if isRedacted(name, redactedHeaders, false) {
+			h[name] = []string{redacted}
+		}
+	}
+	return h
+}
+
+// redactURL returns the URL with the credentials in the query hidden,
+// e.g. the signature of a storage link
+func redactURL(u *url.URL) *url.URL {
+	r := *u
+	r.User = nil
+	q := r.Query()
+	for name := range q {
+		if isRedacted(name, redactedParams, true) {
+			q[name] = []string{redacted}
+		}
+	}
+	if len(q) > 0 {
+		r.RawQuery = q.Encode()
+	}
+	return &r
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	list := []harNameValue{}
+	for name, values := range redactHeader(header) {
+		for _, v := range values {
+			list = append(list, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func harQuery(u *url.URL) []harNameValue {
+	list := []harNameValue{}
+	for name, values := range u.Query() {
+		for _, v := range values {
+			list = append(list, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// isTextContent tells whether a body of the content type is recorded
+func isTextContent(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded" ||
+		mediaType == "application/jwt"
+}
+
+// newHarContent returns the content of the recorded body
+func newHarContent(contentType string, size int64, data []byte) harContent {
+	c := harContent{Size: size, MimeType: contentType}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case size == 0:
+	case mediaType == "application/jwt":
+		// the tokens returned by the login and the personal access tokens
+		c.Text = redacted
+	case !isTextContent(contentType):
+		c.Comment = "binary content not recorded"
+	case !utf8.Valid(data):
+		c.Text = base64.StdEncoding.EncodeToString(data)
+		c.Encoding = "base64"
+	default:
+		c.Text = string(data)
+	}
+	if size > int64(len(data)) && c.Text != "" && c.Text != redacted {
+		c.Comment = "truncated"
+	}
+	return c
+}
+
+// traceTransport records the requests sent through the next transport,
+// while a trace is being recorded
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+// traceTimer holds the times of the phases of a request, as reported by
+// httptrace; the caller holds traceMutex
+type traceTimer struct {
+	start, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, wroteRequest, firstByte time.Time
+}
+
+func (t *traceTimer) clientTrace() *httptrace.ClientTrace {
+	set := func(field *time.Time) {
+		traceMutex.Lock()
+		defer traceMutex.Unlock()
+		*field = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { set(&t.connectDone) },
+		TLSHandshakeStart:    func() { set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { set(&t.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { set(&t.firstByte) },
+	}
+}
+
+// timings returns the HAR timings of the request finished at the time;
+// the phases which didn't happen, e.g. with a reused connection, are -1
+func (t *traceTimer) timings(done time.Time) harTimings {
+	phase := func(start, end time.Time) float64 {
+		if start.IsZero() || end.IsZero() {
+			return -1
+		}
+		return milliseconds(end.Sub(start))
+	}
+	since := func(start, end time.Time) float64 {
+		if d := phase(start, end); d > 0 {
+			return d
+		}
+		return 0
+	}
+	timings := harTimings{
+		DNS:     phase(t.dnsStart, t.dnsDone),
+		Connect: phase(t.connectStart, t.connectDone),
+		SSL:     phase(t.tlsStart, t.tlsDone),
+		Send:    since(t.gotConn, t.wroteRequest),
+		Wait:    since(t.wroteRequest, t.firstByte),
+		Receive: since(t.firstByte, done),
+	}
+	// the connect time includes the TLS handshake
+	if timings.SSL >= 0 && !t.connectStart.IsZero() {
+		timings.Connect = milliseconds(t.tlsDone.Sub(t.connectStart))
+	}
+	timings.Blocked = since(t.start, t.gotConn)
+	for _, d := range []float64{timings.DNS, timings.Connect} {
+		if d > 0 {
+			timings.Blocked -= d
+		}
+	}
+	timings.Blocked = math.Max(roundMilliseconds(timings.Blocked), 0)
+	return timings
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !tracing() {
+		return t.next.RoundTrip(req)
+	}
+
+	timer := &traceTimer{start: time.Now()}
+	u := redactURL(req.URL)
+	entry := &harEntry{
+		StartedDateTime: timer.start,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         u.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: harQuery(u),
+			HeadersSize: -1,
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	r := req.WithContext(httptrace.WithClientTrace(req.Context(), timer.clientTrace()))
+	var reqBody *traceBody
+	if req.Body != nil && req.Body != http.NoBody {
+		r = r.Clone(r.Context())
+		reqBody = &traceBody{ReadCloser: req.Body}
+		r.Body = reqBody
+	}
+
+	rsp, err := t.next.RoundTrip(r)
+
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	if reqBody != nil {
+		content := newHarContent(req.Header.Get("Content-Type"), reqBody.size,
+			reqBody.data.Bytes())
+		entry.Request.PostData = &content
+		entry.Request.BodySize = reqBody.size
+	}
+	if err != nil {
+		entry.Response.Error = err.Error()
+		entry.Timings = timer.timings(time.Now())
+		entry.Time = milliseconds(time.Since(timer.start))
+		addTraceEntry(entry)
+		return rsp, err
+	}
+
+	entry.Request.HTTPVersion = rsp.Proto
+	entry.Response.Status = rsp.StatusCode
+	entry.Response.StatusText = http.StatusText(rsp.StatusCode)
+	entry.Response.HTTPVersion = rsp.Proto
+	entry.Response.Headers = harHeaders(rsp.Header)
+	entry.Response.RedirectURL = rsp.Header.Get("Location")
+	contentType := rsp.Header.Get("Content-Type")
+	entry.Response.Content = harContent{MimeType: contentType}
+	// the entry is complete once the body is read
+	rspBody := &traceBody{ReadCloser: rsp.Body}
+	rspBody.done = func() {
+		entry.Response.BodySize = rspBody.size
+		entry.Response.Content = newHarContent(contentType, rspBody.size,
+			rspBody.data.Bytes())
+		now := time.Now()
+		entry.Timings = timer.timings(now)
+		entry.Time = milliseconds(now.Sub(timer.start))
+	}
+	rsp.Body = rspBody
+	entry.Timings = timer.timings(time.Now())
+	entry.Time = milliseconds(time.Since(timer.start))
+	addTraceEntry(entry)
+	return rsp, nil
+}
+
+// traceBody records a body while it is read, up to maxTraceBody
+type traceBody struct {
+	io.ReadCloser
+	size int64
+	data bytes.Buffer
+	// done is called with traceMutex held once the body is read or closed
+	done     func()
+	doneOnce sync.Once
+}
+
+func (b *traceBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	traceMutex.Lock()
+	b.size += int64(n)
+	if room := maxTraceBody - b.data.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.data.Write(p[:room])
+	}
+	if err != nil {
+		b.finish()
+	}
+	traceMutex.Unlock()
+	return n, err
+}
+
+func (b *traceBody) Close() error {
+	err := b.ReadCloser.Close()
+	traceMutex.Lock()
+	b.finish()
+	traceMutex.Unlock()
+	return err
+}
+
+// finish calls done once; the caller holds traceMutex
+func (b *traceBody) finish() {
+	b.doneOnce.Do(func() {
+		if b.done != nil {
+			b.done()
+		}
+	})
+}
+
+// WebsocketTrace records the messages of a websocket connection; its
+// methods do nothing on a nil WebsocketTrace
+type WebsocketTrace struct {
+	entry *harEntry
+	// the size of the data recorded so far
+	recorded int
+}
+
+// TraceWebsocket records the handshake of the websocket connection to the
+// URL, started at the time, if a trace is being recorded; the response is
+// nil if the handshake failed with the error
+func TraceWebsocket(
+	u *url.URL,
+	header http.Header,
+	started time.Time,
+	rsp *http.Response,
+	err error,
+) *WebsocketTrace {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	if trace == nil {
+		return nil
+	}
+	redactedURL := redactURL(u)
+	duration := milliseconds(time.Since(started))
+	entry := &harEntry{
+		StartedDateTime: started,
+		Time:            duration,
+		Request: harRequest{
+			Method:      http.MethodGet,
+			URL:         redactedURL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(header),
+			QueryString: harQuery(redactedURL),
+			HeadersSize: -1,
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings:      harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: duration},
+		ResourceType: "websocket",
+	}
+	if rsp != nil {
+		entry.Response.Status = rsp.StatusCode
+		entry.Response.StatusText = http.StatusText(rsp.StatusCode)
+		entry.Response.Headers = harHeaders(rsp.Header)
+		entry.Response.Content.MimeType = rsp.Header.Get("Content-Type")
+	}
+	if err != nil {
+		entry.Response.Error = err.Error()
+	}
+	addTraceEntry(entry)
+	if rsp == nil || rsp.StatusCode != http.StatusSwitchingProtocols {
+		return nil
+	}
+	return &WebsocketTrace{entry: entry}
+}
+
+// Sent records a message sent, of the websocket message type
+func (t *WebsocketTrace) Sent(messageType int, data []byte) {
+	t.add("send", messageType, data)
+}
+
+// Received records a message received, of the websocket message type
+func (t *WebsocketTrace) Received(messageType int, data []byte) {
+	t.add("receive", messageType, data)
+}
+
+func (t *WebsocketTrace) add(direction string, messageType int, data []byte) {
+	if t == nil {
+		return
+	}
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	m := harWebSocketMessage{
+		Type:   direction,
+		Time:   float64(time.Now().UnixMicro()) / 1e6,
+		Opcode: messageType,
+		Size:   len(data),
+	}
+	room := maxTraceWebsocket - t.recorded
+	if room > maxTraceMessage {
+		room = maxTraceMessage
+	}
+	if len(data) > 0 && room <= 0 {
+		data = nil
+		m.Comment = "not recorded"
+	} else if len(data) > room {
+		data = data[:room]
+		m.Comment = "truncated"
+	}
+	t.recorded += len(data)
+	m.Data = string(data)
+	if messageType == websocket.BinaryMessage {
+		m.Data = base64.StdEncoding.EncodeToString(data)
+	}
+	t.entry.WebSocketMessages = append(t.entry.WebSocketMessages, m)
+}
+
+// DumpRequest works like httputil.DumpRequest, with the credentials
+// hidden, for logging the request
+func DumpRequest(req *http.Request, body bool) ([]byte, error) {
+	header, u := req.Header, req.URL
+	req.Header, req.URL = redactHeader(header), redactURL(u)
+	defer func() {
+		req.Header, req.URL = header, u
+	}()
+	return httputil.DumpRequest(req, body)
+}
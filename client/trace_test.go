@@ -0,0 +1,57 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebsocketTraceLimits(t *testing.T) {
+	trace := &WebsocketTrace{entry: &harEntry{}}
+	trace.Sent(websocket.TextMessage, []byte("ls"))
+	trace.Received(websocket.BinaryMessage, bytes.Repeat([]byte{1}, maxTraceMessage+1))
+	for trace.recorded < maxTraceWebsocket {
+		trace.Received(websocket.TextMessage, bytes.Repeat([]byte{'a'}, maxTraceMessage))
+	}
+	trace.Received(websocket.TextMessage, []byte("more"))
+	trace.Received(websocket.TextMessage, nil)
+
+	messages := trace.entry.WebSocketMessages
+	tests := []struct {
+		index   int
+		size    int
+		data    int
+		comment string
+	}{
+		{index: 0, size: 2, data: 2},
+		// base64 encoded
+		{index: 1, size: maxTraceMessage + 1, data: maxTraceMessage / 3 * 4, comment: "truncated"},
+		{index: len(messages) - 2, size: 4, comment: "not recorded"},
+		{index: len(messages) - 1},
+	}
+	for _, tc := range tests {
+		m := messages[tc.index]
+		if m.Size != tc.size || len(m.Data) < tc.data || len(m.Data) > tc.data+4 ||
+			m.Comment != tc.comment {
+			t.Errorf("message %d: size %d, %d bytes of data, comment %q",
+				tc.index, m.Size, len(m.Data), m.Comment)
+		}
+	}
+	if trace.recorded != maxTraceWebsocket {
+		t.Errorf("recorded %d bytes, expected %d", trace.recorded, maxTraceWebsocket)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	DefaultConnectTimeout = 30 * time.Second
+	DefaultKeepAlive      = 30 * time.Second
+
+	// the websocket handshake timeout if there is no read timeout
+	defaultHandshakeTimeout = 45 * time.Second
+)
+
+// TransportOptions configures the connections of all the clients
+type TransportOptions struct {
+	// Proxy is the URL of the HTTP, HTTPS or SOCKS5 proxy; if empty, the
+	// proxy is taken from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// environment variables
+	Proxy string
+	// ConnectTimeout limits establishing the connection, including the
+	// TLS handshake
+	ConnectTimeout time.Duration
+	// ReadTimeout limits waiting for the response headers, or the
+	// websocket handshake; zero means no limit
+	ReadTimeout time.Duration
+	// KeepAlive is the keep-alive period of the connections; zero disables
+	// the keep-alives and the reuse of the connections
+	KeepAlive time.Duration
+}
+
+var (
+	transportOptions = TransportOptions{
+		ConnectTimeout: DefaultConnectTimeout,
+		KeepAlive:      DefaultKeepAlive,
+	}
+	proxyURL *url.URL
+	// envProxy is the proxy from the environment variables, as read by
+	// SetTransportOptions; nil until then
+	envProxy func(*url.URL) (*url.URL, error)
+
+	// the transports are shared by all the clients, one for each value
+	// of skipVerify
+	transportsMutex sync.Mutex
+	transports      = map[bool]*http.Transport{}
+)
+
+// SetTransportOptions configures the connections of the clients created
+// afterwards
+func SetTransportOptions(opts TransportOptions) error {
+	var proxy *url.URL
+	if opts.Proxy != "" {
+		var err error
+		proxy, err = url.Parse(opts.Proxy)
+		if err != nil {
+			return errors.Wrap(err, "invalid proxy URL")
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return errors.Errorf("invalid proxy URL %q, the scheme must be "+
+				"http, https or socks5", opts.Proxy)
+		}
+		if proxy.Host == "" {
+			return errors.Errorf("invalid proxy URL %q, the host is missing", opts.Proxy)
+		}
+	}
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+	transportOptions = opts
+	proxyURL = proxy
+	// unlike http.ProxyFromEnvironment, read the variables again on
+	// each call
+	envProxy = httpproxy.FromEnvironment().ProxyFunc()
+	resetTransports()
+	return nil
+}
+
+// resetTransports makes the clients created afterwards use the new
+// settings; the caller holds transportsMutex
+func resetTransports() {
+	transports = map[bool]*http.Transport{}
+}
+
+func proxyFunc(req *http.Request) (*url.URL, error) {
+	if proxyURL != nil {
+		return proxyURL, nil
+	}
+	if envProxy != nil {
+		return envProxy(req.URL)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+func newDialer() *net.Dialer {
+	keepAlive := transportOptions.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = -1
+	}
+	return &net.Dialer{
+		Timeout:   transportOptions.ConnectTimeout,
+		KeepAlive: keepAlive,
+	}
+}
+
+// NewTransport returns the transport shared by the clients
+func NewTransport(skipVerify bool) *http.Transport {
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+	if tr, ok := transports[skipVerify]; ok {
+		return tr
+	}
+	tr := &http.Transport{
+		Proxy:                 proxyFunc,
+		DialContext:           newDialer().DialContext,
+		TLSClientConfig:       NewTLSConfig(skipVerify),
+		TLSHandshakeTimeout:   transportOptions.ConnectTimeout,
+		ResponseHeaderTimeout: transportOptions.ReadTimeout,
+		DisableKeepAlives:     transportOptions.KeepAlive == 0,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	transports[skipVerify] = tr
+	return tr
+}
+
+// NewHttpClient returns a client sending the requests through the shared
+// transport, retrying them after transient failures and recording each
+// attempt in the trace, if any
+func NewHttpClient(skipVerify bool) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			next: &traceTransport{next: NewTransport(skipVerify)},
+		},
+	}
+}
+
+// NewWebsocketDialer returns a websocket dialer with the same proxy,
+// timeout and TLS settings as the HTTP clients
+func NewWebsocketDialer(skipVerify bool) *websocket.Dialer {
+	transportsMutex.Lock()
+	defer transportsMutex.Unlock()
+	handshakeTimeout := transportOptions.ReadTimeout
+	if handshakeTimeout == 0 {
+		handshakeTimeout = defaultHandshakeTimeout
+	}
+	return &websocket.Dialer{
+		Proxy:            proxyFunc,
+		NetDialContext:   newDialer().DialContext,
+		TLSClientConfig:  NewTLSConfig(skipVerify),
+		HandshakeTimeout: handshakeTimeout,
+	}
+}
@@ -16,7 +16,8 @@ package useradm
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
@@ -29,66 +30,93 @@ import (
 )
 
 const (
-	loginUrl = "/api/management/v1/useradm/auth/login"
-	timeout  = 10 * time.Second
+	loginUrl  = "/api/management/v1/useradm/auth/login"
+	logoutUrl = "/api/management/v1/useradm/auth/logout"
+	tokensUrl = "/api/management/v1/useradm/settings/tokens"
+	// the tenants of the user are managed by tenantadm
+	tenantsUrl = "/api/management/v1/tenantadm/user/tenants"
+	timeout    = 10 * time.Second
 )
 
 type Client struct {
-	url      string
-	loginUrl string
-	client   *http.Client
+	url        string
+	loginUrl   string
+	logoutUrl  string
+	tokensUrl  string
+	tenantsUrl string
+	client     *http.Client
 }
 
+// NewClient returns a client of the server at the URL
 func NewClient(url string, skipVerify bool) *Client {
 	return &Client{
-		url:      url,
-		loginUrl: client.JoinURL(url, loginUrl),
-		client:   client.NewHttpClient(skipVerify),
+		url:        url,
+		loginUrl:   client.JoinURL(url, loginUrl),
+		logoutUrl:  client.JoinURL(url, logoutUrl),
+		tokensUrl:  client.JoinURL(url, tokensUrl),
+		tenantsUrl: client.JoinURL(url, tenantsUrl),
+		client:     client.NewHttpClient(skipVerify),
 	}
 }
 
-func (c *Client) Login(user, pass string, token string) ([]byte, error) {
-	var reader *bytes.Reader
-	var req *http.Request
-	var err error
-
-	if len(token) > 1 {
-		tokenJson := "{\"token2fa\":\"" + token + "\"}"
-		reader = bytes.NewReader([]byte(tokenJson))
-		req, err = http.NewRequest(http.MethodPost, c.loginUrl, reader)
-	} else {
-		req, err = http.NewRequest(http.MethodPost, c.loginUrl, nil)
+// loginRequest holds the optional parameters of the login
+type loginRequest struct {
+	Token2FA string `json:"token2fa,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// Login returns a token for the user; tenantID selects the tenant if the
+// user belongs to several, otherwise the default tenant is used
+func (c *Client) Login(
+	ctx context.Context,
+	user, pass, token, tenantID string,
+) ([]byte, error) {
+	var reqBody io.Reader
+	if len(token) > 1 || tenantID != "" {
+		data, err := json.Marshal(loginRequest{Token2FA: token, TenantID: tenantID})
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.loginUrl, reqBody)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
 	req.SetBasicAuth(user, pass)
 	req.Header.Set("Content-Type", "application/json")
 
-	reqDump, _ := httputil.DumpRequest(req, true)
+	reqDump, _ := client.DumpRequest(req, true)
 	log.Verbf("sending request: \n%v", string(reqDump))
 
-	rsp, err := c.client.Do(req.WithContext(ctx))
+	rsp, err := c.client.Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "POST /auth/login request failed")
 	}
 	defer rsp.Body.Close()
 
-	rspDump, _ := httputil.DumpResponse(rsp, true)
+	// the body is the token, which must not end up in the logs
+	rspDump, _ := httputil.DumpResponse(rsp, false)
 	log.Verbf("response: \n%v\n", string(rspDump))
 
+	if rsp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(client.NewAPIError(rsp), "login failed")
+	}
+
 	body, err := ioutil.ReadAll(rsp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, "can't read request body")
 	}
 
-	if rsp.StatusCode != http.StatusOK {
-		return nil, errors.New(fmt.Sprintf("login failed with status %d", rsp.StatusCode))
-	}
-
 	return body, nil
 }
+
+// Logout invalidates the token on the server
+func (c *Client) Logout(ctx context.Context, token string) error {
+	_, err := client.DoPostRequest(ctx, token, c.logoutUrl, c.client, nil)
+	return err
+}
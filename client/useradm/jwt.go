@@ -0,0 +1,102 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package useradm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TokenClaims are the claims of a Mender user token
+type TokenClaims struct {
+	// Subject is the ID of the user
+	Subject   string     `json:"sub"`
+	Tenant    string     `json:"tenant,omitempty"`
+	Plan      string     `json:"plan,omitempty"`
+	Issuer    string     `json:"iss,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Tenant    string          `json:"mender.tenant"`
+	Plan      string          `json:"mender.plan"`
+	Issuer    string          `json:"iss"`
+	Scope     json.RawMessage `json:"scp"`
+	IssuedAt  int64           `json:"iat"`
+	ExpiresAt int64           `json:"exp"`
+}
+
+// ParseToken decodes the claims of the JWT; the signature is not verified,
+// this is left to the server
+func ParseToken(token string) (*TokenClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, errors.New("the token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode the token claims")
+	}
+	var raw jwtClaims
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, errors.Wrap(err, "cannot decode the token claims")
+	}
+
+	claims := &TokenClaims{
+		Subject: raw.Subject,
+		Tenant:  raw.Tenant,
+		Plan:    raw.Plan,
+		Issuer:  raw.Issuer,
+	}
+	// the scope is either a single string or a list of them
+	if len(raw.Scope) > 0 {
+		var scope string
+		if err := json.Unmarshal(raw.Scope, &scope); err == nil {
+			claims.Scopes = strings.Fields(scope)
+		} else if err := json.Unmarshal(raw.Scope, &claims.Scopes); err != nil {
+			return nil, errors.Wrap(err, "cannot decode the token scopes")
+		}
+	}
+	if raw.IssuedAt > 0 {
+		t := time.Unix(raw.IssuedAt, 0)
+		claims.IssuedAt = &t
+	}
+	if raw.ExpiresAt > 0 {
+		t := time.Unix(raw.ExpiresAt, 0)
+		claims.ExpiresAt = &t
+	}
+	return claims, nil
+}
+
+// ExpiresIn returns the time left until the token expires, negative once
+// expired; ok is false if the token doesn't expire
+func (c *TokenClaims) ExpiresIn() (left time.Duration, ok bool) {
+	if c.ExpiresAt == nil {
+		return 0, false
+	}
+	return time.Until(*c.ExpiresAt), true
+}
+
+// Expired tells whether the token has expired
+func (c *TokenClaims) Expired() bool {
+	left, ok := c.ExpiresIn()
+	return ok && left <= 0
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package useradm
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// makeToken returns a token with the claims, and a signature which isn't
+// verified
+func makeToken(claims string, encoding *base64.Encoding) string {
+	header := encoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	return header + "." + encoding.EncodeToString([]byte(claims)) + ".signature"
+}
+
+func TestParseToken(t *testing.T) {
+	issued := time.Unix(1700000000, 0)
+	expires := time.Unix(1700003600, 0)
+	tests := []struct {
+		name   string
+		token  string
+		claims *TokenClaims
+	}{
+		{
+			name: "all the claims",
+			token: makeToken(`{"sub":"u1","mender.tenant":"t1","mender.plan":"enterprise",`+
+				`"iss":"Mender Users","scp":"mender.* mender.users.read",`+
+				`"iat":1700000000,"exp":1700003600}`, base64.RawURLEncoding),
+			claims: &TokenClaims{
+				Subject:   "u1",
+				Tenant:    "t1",
+				Plan:      "enterprise",
+				Issuer:    "Mender Users",
+				Scopes:    []string{"mender.*", "mender.users.read"},
+				IssuedAt:  &issued,
+				ExpiresAt: &expires,
+			},
+		},
+		{
+			name:   "list of scopes",
+			token:  makeToken(`{"sub":"u1","scp":["mender.*"]}`, base64.RawURLEncoding),
+			claims: &TokenClaims{Subject: "u1", Scopes: []string{"mender.*"}},
+		},
+		{
+			name:   "padded, with a new line",
+			token:  makeToken(`{"sub":"u12"}`, base64.URLEncoding) + "\n",
+			claims: &TokenClaims{Subject: "u12"},
+		},
+		{name: "not a JWT", token: "abc.def"},
+		{name: "bad encoding", token: "a.!!!.c"},
+		{name: "bad claims", token: makeToken(`[]`, base64.RawURLEncoding)},
+		{name: "bad scopes", token: makeToken(`{"scp":1}`, base64.RawURLEncoding)},
+	}
+	for _, tc := range tests {
+		claims, err := ParseToken(tc.token)
+		if tc.claims == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+		} else if !reflect.DeepEqual(claims, tc.claims) {
+			t.Errorf("%s: got %+v", tc.name, claims)
+		}
+	}
+}
+
+func TestTokenClaimsExpired(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		expired   bool
+		expires   bool
+	}{
+		{name: "no expiry"},
+		{name: "expired", expiresAt: &past, expired: true, expires: true},
+		{name: "valid", expiresAt: &future, expires: true},
+	}
+	for _, tc := range tests {
+		claims := &TokenClaims{ExpiresAt: tc.expiresAt}
+		left, ok := claims.ExpiresIn()
+		if ok != tc.expires || claims.Expired() != tc.expired ||
+			(tc.expires && (left <= 0) != tc.expired) {
+			t.Errorf("%s: got %s, %v, expired %v", tc.name, left, ok, claims.Expired())
+		}
+	}
+}
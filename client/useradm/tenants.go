@@ -0,0 +1,65 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package useradm
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+// Tenant is an organization the user belongs to
+type Tenant struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+}
+
+// ListTenants returns the tenants the user can log in to
+func (c *Client) ListTenants(ctx context.Context, token string) ([]Tenant, error) {
+	body, err := client.DoGetRequest(ctx, token, c.tenantsUrl, c.client)
+	if err != nil {
+		return nil, err
+	}
+	var list []Tenant
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, errors.Wrap(err, "GET /user/tenants request failed")
+	}
+	return list, nil
+}
+
+// FindTenant returns the tenant with the given ID or name
+func FindTenant(list []Tenant, idOrName string) (*Tenant, error) {
+	for i, t := range list {
+		if t.ID == idOrName {
+			return &list[i], nil
+		}
+	}
+	var found *Tenant
+	for i, t := range list {
+		if t.Name == idOrName {
+			if found != nil {
+				return nil, errors.Errorf("several tenants are named %q, use the ID", idOrName)
+			}
+			found = &list[i]
+		}
+	}
+	if found == nil {
+		return nil, errors.Errorf("tenant %q not found", idOrName)
+	}
+	return found, nil
+}
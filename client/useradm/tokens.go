@@ -0,0 +1,87 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package useradm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+// PersonalAccessToken describes a personal access token; the token itself
+// is only returned when it is created
+type PersonalAccessToken struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	ExpirationDate *time.Time `json:"expiration_date,omitempty"`
+	LastUsed       *time.Time `json:"last_used,omitempty"`
+	CreatedTs      *time.Time `json:"created_ts,omitempty"`
+}
+
+type newPersonalAccessToken struct {
+	Name      string `json:"name"`
+	ExpiresIn int64  `json:"expires_in,omitempty"`
+}
+
+// CreateToken creates a personal access token valid for the given duration
+// and returns it
+func (c *Client) CreateToken(
+	ctx context.Context,
+	name string,
+	expiresIn time.Duration,
+	token string,
+) (string, error) {
+	data, err := json.Marshal(newPersonalAccessToken{
+		Name:      name,
+		ExpiresIn: int64(expiresIn / time.Second),
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := client.DoPostRequest(ctx, token, c.tokensUrl, c.client, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// ListTokens returns the personal access tokens of the user
+func (c *Client) ListTokens(ctx context.Context, token string) ([]PersonalAccessToken, error) {
+	body, err := client.DoGetRequest(ctx, token, c.tokensUrl, c.client)
+	if err != nil {
+		return nil, err
+	}
+	var list []PersonalAccessToken
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, errors.Wrap(err, "GET /settings/tokens request failed")
+	}
+	return list, nil
+}
+
+// RevokeToken revokes the personal access token with the given ID
+func (c *Client) RevokeToken(ctx context.Context, tokenID, token string) error {
+	return client.DoDeleteRequest(
+		ctx,
+		token,
+		client.JoinURL(c.tokensUrl, url.PathEscape(tokenID)),
+		c.client,
+	)
+}
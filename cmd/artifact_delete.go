@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 
 	"github.com/spf13/cobra"
@@ -24,9 +25,10 @@ import (
 )
 
 var artifactDeleteCmd = &cobra.Command{
-	Use:   "delete [flags] ARTIFACT_ID",
-	Short: "Delete mender artifact from the Mender server.",
-	Args:  cobra.ExactArgs(1),
+	Use:               "delete [flags] ARTIFACT_ID",
+	Short:             "Delete mender artifact from the Mender server.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeArtifact,
 	Run: func(c *cobra.Command, args []string) {
 		cmd, err := NewArtifactDeleteCmd(c, args)
 		CheckErr(err)
@@ -38,6 +40,7 @@ func init() {
 }
 
 type ArtifactDeleteCmd struct {
+	ctx        context.Context
 	server     string
 	skipVerify bool
 	artifactID string
@@ -66,6 +69,7 @@ func NewArtifactDeleteCmd(cmd *cobra.Command, args []string) (*ArtifactDeleteCmd
 	}
 
 	return &ArtifactDeleteCmd{
+		ctx:        cmd.Context(),
 		server:     server,
 		artifactID: artifactID,
 		token:      token,
@@ -76,7 +80,7 @@ func NewArtifactDeleteCmd(cmd *cobra.Command, args []string) (*ArtifactDeleteCmd
 func (c *ArtifactDeleteCmd) Run() error {
 
 	client := deployments.NewClient(c.server, c.skipVerify)
-	err := client.DeleteArtifact(c.artifactID, c.token)
+	err := client.DeleteArtifact(c.ctx, c.artifactID, c.token)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,70 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+// resetFlags restores the global flags and the login flags at the end of
+// the test, as they keep their values and changed state between executions
+func resetFlags(t *testing.T, names ...string) {
+	t.Cleanup(func() {
+		for _, name := range names {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			if flag == nil {
+				flag = loginCmd.Flags().Lookup(name)
+			}
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+			viper.Set(name, nil)
+		}
+	})
+}
+
+// runCommand runs the command line against the fake server, logged in as
+// a user of it; the commands exit the test binary on failure
+func runCommand(t *testing.T, srv *fakeserver.Server, args ...string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	resetFlags(t, argRootServer, argRootTokenValue)
+	args = append(args,
+		"--"+argRootServer, srv.URL,
+		"--"+argRootTokenValue, srv.Token("user@example.com"),
+	)
+	rootCmd.SetArgs(args)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArtifactDelete(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	id := srv.AddArtifact(deployments.Artifact{Name: "release-1"}, []byte("data"))
+	srv.AddArtifact(deployments.Artifact{Name: "release-2"}, []byte("data"))
+
+	runCommand(t, srv, "artifacts", "delete", id)
+
+	list := srv.Artifacts()
+	if len(list) != 1 || list[0].Name != "release-2" {
+		t.Errorf("expected only release-2 to be left, got %+v", list)
+	}
+}
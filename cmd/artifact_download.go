@@ -14,11 +14,14 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/mendersoftware/mender-cli/client"
 	"github.com/mendersoftware/mender-cli/client/deployments"
 	"github.com/mendersoftware/mender-cli/log"
 )
@@ -28,8 +31,9 @@ const (
 )
 
 var artifactDownloadCmd = &cobra.Command{
-	Use:   "download [flags] ARTIFACT",
-	Short: "Download mender artifact from the Mender server.",
+	Use:               "download [flags] ARTIFACT",
+	Short:             "Download mender artifact from the Mender server.",
+	ValidArgsFunction: completeArtifact,
 	Run: func(c *cobra.Command, args []string) {
 		cmd, err := NewArtifactDownloadCmd(c, args)
 		CheckErr(err)
@@ -45,6 +49,7 @@ func init() {
 }
 
 type ArtifactDownloadCmd struct {
+	ctx             context.Context
 	server          string
 	skipVerify      bool
 	destinationPath string
@@ -85,6 +90,7 @@ func NewArtifactDownloadCmd(cmd *cobra.Command, args []string) (*ArtifactDownloa
 	}
 
 	return &ArtifactDownloadCmd{
+		ctx:             cmd.Context(),
 		server:          server,
 		destinationPath: destinationPath,
 		artifactID:      artifactID,
@@ -95,8 +101,15 @@ func NewArtifactDownloadCmd(cmd *cobra.Command, args []string) (*ArtifactDownloa
 }
 
 func (c *ArtifactDownloadCmd) Run() error {
+	var progress client.ProgressFunc
+	if !c.withoutProgress {
+		bar := newBytesBar()
+		bar.Start()
+		defer bar.Finish()
+		progress = barProgress(bar)
+	}
 	client := deployments.NewClient(c.server, c.skipVerify)
-	err := client.DownloadArtifact(c.destinationPath, c.artifactID, c.token, c.withoutProgress)
+	err := client.DownloadArtifact(c.ctx, c.destinationPath, c.artifactID, c.token, progress)
 	if err != nil {
 		return err
 	}
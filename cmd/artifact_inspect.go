@@ -0,0 +1,201 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+)
+
+const (
+	argArtifactKey = "key"
+)
+
+var artifactInspectCmd = &cobra.Command{
+	Use:   "inspect [flags] ARTIFACT_FILE",
+	Short: "Print the header information and payloads of a local mender artifact.",
+	Long: "Print the header information and payloads of a local mender artifact.\n\n" +
+		"The whole file is read, so that the payload checksums are verified as well.\n" +
+		"The signature is verified if a public key is given.",
+	Args: cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewArtifactInspectCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	artifactInspectCmd.Flags().StringP(argArtifactKey, "", "",
+		"public key file (PEM) to verify the artifact signature with")
+}
+
+type ArtifactInspectCmd struct {
+	artifactPath string
+	publicKey    []byte
+	output       string
+}
+
+func NewArtifactInspectCmd(cmd *cobra.Command, args []string) (*ArtifactInspectCmd, error) {
+	publicKey, err := readPublicKeyFlag(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArtifactInspectCmd{
+		artifactPath: args[0],
+		publicKey:    publicKey,
+		output:       output,
+	}, nil
+}
+
+func (c *ArtifactInspectCmd) Run() error {
+	artifact, err := deployments.ReadLocalArtifact(c.artifactPath, c.publicKey)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, 0, localArtifact{artifact})
+}
+
+// readPublicKeyFlag returns the content of the key file, or nil if the
+// flag is not set
+func readPublicKeyFlag(cmd *cobra.Command) ([]byte, error) {
+	keyPath, err := cmd.Flags().GetString(argArtifactKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyPath == "" {
+		return nil, nil
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read the public key file")
+	}
+	return key, nil
+}
+
+type localArtifact struct {
+	*deployments.LocalArtifact
+}
+
+func (a localArtifact) printText(w io.Writer, detailLevel int) {
+	fmt.Fprintf(w, "Name: %s\n", a.Name)
+	fmt.Fprintf(w, "Artifact format: %s\n", a.Info.Format)
+	fmt.Fprintf(w, "Format version: %d\n", a.Info.Version)
+	fmt.Fprintf(w, "Signature: %s\n", a.Signature)
+	fmt.Fprintln(w, "Compatible device types:")
+	for _, v := range a.DeviceTypesCompatible {
+		fmt.Fprintf(w, "  %s\n", v)
+	}
+	printStringMap(w, "Artifact provides:", a.ArtifactProvides)
+	printValueMap(w, "Artifact depends:", a.ArtifactDepends)
+	if len(a.ClearsProvides) > 0 {
+		fmt.Fprintf(w, "Clears provides: %s\n", strings.Join(a.ClearsProvides, ", "))
+	}
+	if len(a.Scripts) > 0 {
+		fmt.Fprintln(w, "State scripts:")
+		for _, s := range a.Scripts {
+			fmt.Fprintf(w, "  %s\n", s)
+		}
+	}
+	fmt.Fprintln(w, "Updates:")
+	for _, p := range a.Payloads {
+		fmt.Fprintf(w, "  Type: %s\n", p.Type)
+		printStringMap(w, "  Provides:", p.Provides)
+		printValueMap(w, "  Depends:", p.Depends)
+		if len(p.ClearsProvides) > 0 {
+			fmt.Fprintf(w, "  Clears provides: %s\n", strings.Join(p.ClearsProvides, ", "))
+		}
+		printValueMap(w, "  Metadata:", p.MetaData)
+		fmt.Fprintln(w, "  Files:")
+		for _, f := range p.Files {
+			fmt.Fprintf(w, "\tName: %s\n", f.Name)
+			fmt.Fprintf(w, "\tChecksum: %s\n", f.Checksum)
+			fmt.Fprintf(w, "\tSize: %d\n", f.Size)
+			if f.Date != nil {
+				fmt.Fprintf(w, "\tDate: %s\n", f.Date)
+			}
+			if len(p.Files) > 1 {
+				fmt.Fprintln(w)
+			}
+		}
+	}
+}
+
+func (a localArtifact) header(wide bool) []string {
+	header := []string{"NAME", "DEVICE TYPES", "SIGNATURE", "UPDATES"}
+	if wide {
+		header = append(header, "FORMAT", "VERSION", "FILES")
+	}
+	return header
+}
+
+func (a localArtifact) rows(wide bool) [][]string {
+	types := make([]string, 0, len(a.Payloads))
+	files := 0
+	for _, p := range a.Payloads {
+		types = append(types, p.Type)
+		files += len(p.Files)
+	}
+	row := []string{
+		a.Name,
+		strings.Join(a.DeviceTypesCompatible, ","),
+		a.Signature,
+		strings.Join(types, ","),
+	}
+	if wide {
+		row = append(row, a.Info.Format, strconv.Itoa(a.Info.Version), strconv.Itoa(files))
+	}
+	return [][]string{row}
+}
+
+func printStringMap(w io.Writer, title string, m map[string]string) {
+	values := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		values[k] = v
+	}
+	printValueMap(w, title, values)
+}
+
+// printValueMap prints the map entries sorted by key, indented below the
+// title
+func printValueMap(w io.Writer, title string, m map[string]interface{}) {
+	if len(m) == 0 {
+		return
+	}
+	indent := title[:len(title)-len(strings.TrimLeft(title, " "))]
+	fmt.Fprintln(w, title)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s  %s: %s\n", indent, k, formatAttributeValue(m[k]))
+	}
+}
@@ -14,6 +14,18 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
 	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
@@ -26,12 +38,26 @@ import (
 const (
 	argArtifactDescription = "description"
 	argDirect              = "direct"
+	argUploadJobs          = "jobs"
+
+	uploadProgressTemplate = `{{counters . }} {{bar . }} {{percent . }} {{speed . }} ` +
+		`{{string . "status"}}`
+
+	uploadStatusUploaded = "uploaded"
+	uploadStatusSkipped  = "skipped"
+	uploadStatusFailed   = "failed"
 )
 
 var artifactUploadCmd = &cobra.Command{
-	Use:   "upload [flags] ARTIFACT",
-	Short: "Upload mender artifact to the Mender server.",
-	Args:  cobra.ExactArgs(1),
+	Use:   "upload [flags] ARTIFACT [ARTIFACT...]",
+	Short: "Upload mender artifacts to the Mender server.",
+	Long: "Upload mender artifacts to the Mender server.\n\n" +
+		"Each argument is an artifact file, a directory containing .mender files or a glob\n" +
+		"pattern. When several artifacts are given, they are uploaded concurrently, those\n" +
+		"whose name and device types already exist on the server are skipped, and a\n" +
+		"summary is printed at the end.",
+	Example: "  mender-cli artifacts upload --jobs 4 'build/*.mender'",
+	Args:    cobra.MinimumNArgs(1),
 	Run: func(c *cobra.Command, args []string) {
 		cmd, err := NewArtifactUploadCmd(c, args)
 		CheckErr(err)
@@ -43,16 +69,21 @@ func init() {
 	artifactUploadCmd.Flags().StringP(argArtifactDescription, "", "", "artifact description")
 	artifactUploadCmd.Flags().BoolP(argWithoutProgress, "", false, "disable progress bar")
 	artifactUploadCmd.Flags().BoolP(argDirect, "", false, "upload directly to storage")
+	artifactUploadCmd.Flags().IntP(argUploadJobs, "j", 4,
+		"maximum number of artifacts uploaded at the same time")
 }
 
 type ArtifactUploadCmd struct {
+	ctx             context.Context
 	server          string
 	skipVerify      bool
 	description     string
-	artifactPath    string
+	artifactPaths   []string
 	token           string
 	withoutProgress bool
 	direct          bool
+	jobs            int
+	output          string
 }
 
 func NewArtifactUploadCmd(cmd *cobra.Command, args []string) (*ArtifactUploadCmd, error) {
@@ -81,45 +112,277 @@ func NewArtifactUploadCmd(cmd *cobra.Command, args []string) (*ArtifactUploadCmd
 		return nil, err
 	}
 
+	jobs, err := cmd.Flags().GetInt(argUploadJobs)
+	if err != nil {
+		return nil, err
+	}
+	if jobs <= 0 {
+		return nil, errors.New("the number of jobs must be positive")
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	artifactPaths, err := expandArtifactPaths(args)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := getAuthToken(cmd)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ArtifactUploadCmd{
+		ctx:             cmd.Context(),
 		server:          server,
 		description:     artifactDescription,
 		token:           token,
-		artifactPath:    args[0],
+		artifactPaths:   artifactPaths,
 		skipVerify:      skipVerify,
 		withoutProgress: withoutProgress,
 		direct:          direct,
+		jobs:            jobs,
+		output:          output,
 	}, nil
 }
 
+// expandArtifactPaths resolves the directories and glob patterns to the
+// list of artifact files, without duplicates
+func expandArtifactPaths(args []string) ([]string, error) {
+	paths := []string{}
+	seen := map[string]bool{}
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	// addFiles adds the regular files among the matches of a pattern, and
+	// returns their number
+	addFiles := func(pattern string) (int, error) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return 0, err
+		}
+		sort.Strings(matches)
+		files := 0
+		for _, m := range matches {
+			if info, err := os.Stat(m); err != nil || info.IsDir() {
+				continue
+			}
+			files++
+			add(m)
+		}
+		return files, nil
+	}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err == nil && info.IsDir() {
+			files, err := addFiles(filepath.Join(arg, "*.mender"))
+			if err != nil {
+				return nil, err
+			}
+			if files == 0 {
+				return nil, errors.Errorf("no artifacts found in directory %s", arg)
+			}
+			continue
+		} else if err == nil {
+			add(arg)
+			continue
+		}
+
+		files, globErr := addFiles(arg)
+		if globErr != nil {
+			return nil, errors.Wrapf(globErr, "invalid pattern %s", arg)
+		}
+		if files == 0 {
+			return nil, errors.Wrap(err, "Cannot read artifact file")
+		}
+	}
+	return paths, nil
+}
+
+// artifactUpload is the result of uploading a single artifact
+type artifactUpload struct {
+	Path        string   `json:"path"`
+	Name        string   `json:"name,omitempty"`
+	DeviceTypes []string `json:"device_types,omitempty"`
+	Size        int64    `json:"size"`
+	Status      string   `json:"status"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func (u *artifactUpload) fail(err error) {
+	u.Status = uploadStatusFailed
+	u.Error = err.Error()
+}
+
 func (c *ArtifactUploadCmd) Run() error {
 	client := deployments.NewClient(c.server, c.skipVerify)
+
+	// a single artifact is uploaded as it is, for the server to validate;
+	// the existing artifacts are only skipped when uploading several
+	checkExisting := len(c.artifactPaths) > 1
+	var existing []deployments.Artifact
+	if checkExisting {
+		var err error
+		existing, err = client.ListArtifacts(c.ctx, c.token)
+		if err != nil {
+			log.Errf("WARNING: cannot list the artifacts on the server, "+
+				"uploading all of them: %s\n", err)
+			checkExisting = false
+		}
+	}
+
+	uploads := make([]*artifactUpload, len(c.artifactPaths))
+	pending := []*artifactUpload{}
+	var total int64
+	for i, path := range c.artifactPaths {
+		u := &artifactUpload{Path: path}
+		uploads[i] = u
+		if info, err := os.Stat(path); err != nil {
+			u.fail(errors.Wrap(err, "Cannot read artifact file stats"))
+			continue
+		} else {
+			u.Size = info.Size()
+		}
+		if checkExisting {
+			var err error
+			u.Name, u.DeviceTypes, err = deployments.ReadArtifactHeader(path)
+			if err != nil {
+				log.Errf("WARNING: cannot read the header of %s, uploading it anyway: %s\n",
+					path, err)
+			} else if artifactExists(existing, u.Name, u.DeviceTypes) {
+				u.Status = uploadStatusSkipped
+				continue
+			}
+		}
+		pending = append(pending, u)
+		total += u.Size
+	}
+
+	if len(uploads) == 1 {
+		return c.runSingle(client, uploads[0])
+	}
+
+	var bar *pb.ProgressBar
+	if !c.withoutProgress && len(pending) > 0 {
+		bar = pb.ProgressBarTemplate(uploadProgressTemplate).New(0).
+			SetTotal(total).
+			Set(pb.Bytes, true).
+			SetRefreshRate(time.Millisecond * 100)
+		bar.Set("status", fmt.Sprintf("0/%d artifacts", len(pending)))
+		bar.Start()
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+		jobs = make(chan struct{}, c.jobs)
+	)
+	for _, u := range pending {
+		wg.Add(1)
+		jobs <- struct{}{}
+		go func(u *artifactUpload) {
+			defer wg.Done()
+			defer func() { <-jobs }()
+
+			if err := c.upload(client, u.Path, bar); err != nil {
+				u.fail(err)
+			} else {
+				u.Status = uploadStatusUploaded
+			}
+
+			mu.Lock()
+			done++
+			if bar != nil {
+				bar.Set("status", fmt.Sprintf("%d/%d artifacts", done, len(pending)))
+			} else {
+				log.Verbf("%s: %s\n", u.Path, u.Status)
+			}
+			mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+	if bar != nil {
+		bar.Finish()
+	}
+
+	format := c.output
+	if format == outputText {
+		format = outputTable
+	}
+	if err := printOutput(os.Stdout, format, 0, artifactUploadList(uploads)); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, u := range uploads {
+		if u.Status == uploadStatusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d artifact uploads failed", failed, len(uploads))
+	}
+	return nil
+}
+
+// runSingle uploads a single artifact with its own progress bar and
+// messages, as the command always did
+func (c *ArtifactUploadCmd) runSingle(
+	client *deployments.Client,
+	u *artifactUpload,
+) error {
+	if u.Status == uploadStatusFailed {
+		return errors.New(u.Error)
+	}
+
+	var bar *pb.ProgressBar
+	if !c.withoutProgress {
+		bar = newBytesBar()
+		bar.Start()
+		defer bar.Finish()
+	}
+	progress := func(transferred, total int64) {
+		if bar == nil {
+			return
+		}
+		bar.SetTotal(total)
+		bar.SetCurrent(transferred)
+		if !c.direct && transferred == total && !bar.IsFinished() {
+			bar.Finish()
+			log.Info("Processing uploaded file. This may take around one minute.\n")
+		}
+	}
+
 	if c.direct {
 		log.Infof("getting direct link.\n")
-		link, err := client.DirectDownloadLink(c.token)
+		link, err := client.DirectUploadLink(c.ctx, c.token)
 		if err != nil {
 			return errors.Wrap(err, "failed to get the direct pre-signed URL")
 		}
 
 		log.Infof("uploading the artifact.\n")
 		err = client.DirectUpload(
+			c.ctx,
 			c.token,
-			c.artifactPath,
+			u.Path,
 			link.ArtifactID,
 			link.Uri,
 			link.Header,
-			c.withoutProgress,
+			progress,
 		)
 		if err != nil {
 			return errors.Wrap(err, "failed to upload the artifact")
 		}
 	} else {
-		err := client.UploadArtifact(c.description, c.artifactPath, c.token, c.withoutProgress)
+		err := client.UploadArtifact(c.ctx, c.description, u.Path, c.token, progress)
 		if err != nil {
 			return err
 		}
@@ -129,3 +392,93 @@ func (c *ArtifactUploadCmd) Run() error {
 
 	return nil
 }
+
+// upload uploads one of several artifacts, counting the bytes on the
+// shared progress bar, which may be nil
+func (c *ArtifactUploadCmd) upload(
+	client *deployments.Client,
+	path string,
+	bar *pb.ProgressBar,
+) (err error) {
+	var sent int64
+	progress := func(transferred, _ int64) {
+		if bar != nil {
+			bar.Add64(transferred - sent)
+		}
+		sent = transferred
+	}
+	defer func() {
+		if err != nil && bar != nil {
+			bar.Add64(-sent)
+		}
+	}()
+
+	if c.direct {
+		link, err := client.DirectUploadLink(c.ctx, c.token)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the direct pre-signed URL")
+		}
+		return client.DirectUpload(
+			c.ctx,
+			c.token,
+			path,
+			link.ArtifactID,
+			link.Uri,
+			link.Header,
+			progress,
+		)
+	}
+	return client.UploadArtifact(c.ctx, c.description, path, c.token, progress)
+}
+
+// artifactExists tells whether the server already has artifacts with the
+// given name covering all the given device types
+func artifactExists(list []deployments.Artifact, name string, deviceTypes []string) bool {
+	covered := map[string]bool{}
+	for _, a := range list {
+		if a.Name != name {
+			continue
+		}
+		for _, dt := range a.DeviceTypesCompatible {
+			covered[dt] = true
+		}
+	}
+	if len(covered) == 0 {
+		return false
+	}
+	for _, dt := range deviceTypes {
+		if !covered[dt] {
+			return false
+		}
+	}
+	return true
+}
+
+type artifactUploadList []*artifactUpload
+
+func (l artifactUploadList) printText(w io.Writer, detailLevel int) {
+	_ = printOutput(w, outputTable, detailLevel, l)
+}
+
+func (l artifactUploadList) header(wide bool) []string {
+	header := []string{"FILE", "NAME", "STATUS", "ERROR"}
+	if wide {
+		header = append(header, "DEVICE TYPES", "SIZE")
+	}
+	return header
+}
+
+func (l artifactUploadList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, u := range l {
+		row := []string{u.Path, u.Name, u.Status, u.Error}
+		if wide {
+			row = append(row,
+				strings.Join(u.DeviceTypes, ","),
+				strconv.FormatInt(u.Size, 10),
+			)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
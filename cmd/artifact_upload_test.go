@@ -0,0 +1,228 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+const artifactsPath = "/api/management/v1/deployments/artifacts"
+
+func writeArtifact(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := fakeserver.NewArtifact(name, "rpi4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name+".mender")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestArtifactUploadSingle(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	path := writeArtifact(t, t.TempDir(), "release-1")
+
+	runCommand(t, srv, "artifacts", "upload", "--"+argWithoutProgress, path)
+
+	// a single artifact is uploaded without looking up the existing ones
+	for _, r := range srv.Requests() {
+		if r == http.MethodGet+" "+artifactsPath {
+			t.Errorf("unexpected request %s", r)
+		}
+	}
+	if n := len(srv.Artifacts()); n != 1 {
+		t.Errorf("expected 1 artifact, got %d", n)
+	}
+}
+
+func TestArtifactUploadListFailure(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	dir := t.TempDir()
+	writeArtifact(t, dir, "release-1")
+	writeArtifact(t, dir, "release-2")
+	srv.Fail(http.MethodGet, artifactsPath, http.StatusForbidden, 1)
+
+	// the artifacts are uploaded even if the existing ones can't be listed
+	runCommand(t, srv, "artifacts", "upload", "--"+argWithoutProgress, dir)
+
+	if n := len(srv.Artifacts()); n != 2 {
+		t.Errorf("expected 2 artifacts, got %d", n)
+	}
+}
+
+// captureStdout returns what fn writes to the standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestExpandArtifactPaths(t *testing.T) {
+	dir := t.TempDir()
+	a := writeArtifact(t, dir, "a")
+	b := writeArtifact(t, dir, "b")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.mender"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.Mkdir(empty, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(empty, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		paths []string
+		err   bool
+	}{
+		{name: "files", args: []string{b, a}, paths: []string{b, a}},
+		{name: "directory", args: []string{dir}, paths: []string{a, b}},
+		{name: "glob", args: []string{filepath.Join(dir, "*.mender")}, paths: []string{a, b}},
+		{name: "duplicates",
+			args:  []string{a, dir, filepath.Join(dir, ".", "a.mender")},
+			paths: []string{a, b}},
+		{name: "no artifacts in directory", args: []string{empty}, err: true},
+		{name: "glob without matches", args: []string{filepath.Join(dir, "*.img")}, err: true},
+		{name: "glob of directories", args: []string{filepath.Join(dir, "sub*")}, err: true},
+		{name: "missing file", args: []string{filepath.Join(dir, "c.mender")}, err: true},
+		{name: "invalid pattern", args: []string{filepath.Join(dir, "[")}, err: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			paths, err := expandArtifactPaths(tc.args)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(paths, ",") != strings.Join(tc.paths, ",") {
+				t.Errorf("expected %v, got %v", tc.paths, paths)
+			}
+		})
+	}
+}
+
+func TestArtifactExists(t *testing.T) {
+	list := []deployments.Artifact{
+		{Name: "release-1", DeviceTypesCompatible: []string{"rpi3"}},
+		{Name: "release-1", DeviceTypesCompatible: []string{"rpi4", "bbb"}},
+		{Name: "release-2", DeviceTypesCompatible: []string{"rpi4"}},
+	}
+	tests := []struct {
+		name        string
+		deviceTypes []string
+		exists      bool
+	}{
+		{name: "release-1", deviceTypes: []string{"rpi4"}, exists: true},
+		{name: "release-1", deviceTypes: []string{"rpi3", "bbb"}, exists: true},
+		{name: "release-1", deviceTypes: []string{"rpi4", "x86"}},
+		{name: "release-2", deviceTypes: []string{"rpi3"}},
+		{name: "release-3", deviceTypes: []string{"rpi4"}},
+	}
+	for _, tc := range tests {
+		if exists := artifactExists(list, tc.name, tc.deviceTypes); exists != tc.exists {
+			t.Errorf("%s %v: expected %v, got %v", tc.name, tc.deviceTypes, tc.exists, exists)
+		}
+	}
+}
+
+func TestArtifactUploadPartialFailure(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	dir := t.TempDir()
+	writeArtifact(t, dir, "release-1")
+	writeArtifact(t, dir, "release-2")
+	writeArtifact(t, dir, "release-3")
+	srv.AddArtifact(deployments.Artifact{
+		Name:                  "release-3",
+		DeviceTypesCompatible: []string{"rpi4"},
+	}, []byte("data"))
+	// one of the concurrent uploads is rejected
+	srv.Fail(http.MethodPost, artifactsPath, http.StatusBadRequest, 1)
+
+	paths, err := expandArtifactPaths([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := &ArtifactUploadCmd{
+		ctx:             context.Background(),
+		server:          srv.URL,
+		token:           srv.Token("user@example.com"),
+		artifactPaths:   paths,
+		withoutProgress: true,
+		jobs:            2,
+		output:          outputJSON,
+	}
+	out := captureStdout(t, func() { err = cmd.Run() })
+	if err == nil || err.Error() != "1 of 3 artifact uploads failed" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var uploads []artifactUpload
+	if err := json.Unmarshal([]byte(out), &uploads); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	statuses := map[string]int{}
+	for _, u := range uploads {
+		statuses[u.Status]++
+		if (u.Status == uploadStatusFailed) != (u.Error != "") {
+			t.Errorf("unexpected error for %s: %q", u.Status, u.Error)
+		}
+	}
+	if len(uploads) != 3 || statuses[uploadStatusUploaded] != 1 ||
+		statuses[uploadStatusFailed] != 1 || uploads[2].Status != uploadStatusSkipped {
+		t.Errorf("unexpected summary: %+v", uploads)
+	}
+	if n := len(srv.Artifacts()); n != 2 {
+		t.Errorf("expected 2 artifacts on the server, got %d", n)
+	}
+}
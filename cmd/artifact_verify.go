@@ -0,0 +1,96 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var artifactVerifyCmd = &cobra.Command{
+	Use:   "verify [flags] ARTIFACT_FILE [ARTIFACT_FILE...]",
+	Short: "Verify the signature and payload checksums of local mender artifacts.",
+	Long: "Verify the signature and payload checksums of local mender artifacts.\n\n" +
+		"The payload checksums are always verified. When a public key is given, the\n" +
+		"artifacts must be signed with the matching private key.",
+	Example: "  mender-cli artifacts verify --key artifact-verify.pem release.mender",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewArtifactVerifyCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	artifactVerifyCmd.Flags().StringP(argArtifactKey, "", "",
+		"public key file (PEM) to verify the artifact signature with")
+}
+
+type ArtifactVerifyCmd struct {
+	artifactPaths []string
+	publicKey     []byte
+}
+
+func NewArtifactVerifyCmd(cmd *cobra.Command, args []string) (*ArtifactVerifyCmd, error) {
+	publicKey, err := readPublicKeyFlag(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArtifactVerifyCmd{
+		artifactPaths: args,
+		publicKey:     publicKey,
+	}, nil
+}
+
+func (c *ArtifactVerifyCmd) Run() error {
+	failed := 0
+	for _, path := range c.artifactPaths {
+		if err := c.verify(path); err != nil {
+			log.Errf("%s: %s\n", path, err.Error())
+			failed++
+			continue
+		}
+		log.Infof("Artifact file '%s' verified successfully\n", path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d artifacts failed verification",
+			failed, len(c.artifactPaths))
+	}
+	return nil
+}
+
+func (c *ArtifactVerifyCmd) verify(path string) error {
+	artifact, err := deployments.ReadLocalArtifact(path, c.publicKey)
+	if err != nil {
+		return err
+	}
+	switch artifact.Signature {
+	case deployments.SignatureInvalid:
+		return errors.Wrap(artifact.SignatureError(), "invalid signature")
+	case deployments.SignatureNone:
+		if c.publicKey != nil {
+			return errors.New("the artifact is not signed")
+		}
+	case deployments.SignatureNotVerified:
+		log.Infof("%s: the artifact is signed, but no key was given to verify it\n", path)
+	}
+	return nil
+}
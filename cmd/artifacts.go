@@ -14,7 +14,12 @@
 package cmd
 
 import (
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
 	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/client"
 )
 
 const (
@@ -24,7 +29,7 @@ const (
 var artifactsCmd = &cobra.Command{
 	Use:       "artifacts",
 	Short:     "Operations on mender artifacts.",
-	ValidArgs: []string{"upload", "list"},
+	ValidArgs: []string{"upload", "list", "delete", "download", "inspect", "verify"},
 }
 
 func init() {
@@ -32,4 +37,20 @@ func init() {
 	artifactsCmd.AddCommand(artifactsListCmd)
 	artifactsCmd.AddCommand(artifactDeleteCmd)
 	artifactsCmd.AddCommand(artifactDownloadCmd)
+	artifactsCmd.AddCommand(artifactInspectCmd)
+	artifactsCmd.AddCommand(artifactVerifyCmd)
+}
+
+func newBytesBar() *pb.ProgressBar {
+	return pb.New64(0).
+		Set(pb.Bytes, true).
+		SetRefreshRate(time.Millisecond * 100)
+}
+
+// barProgress shows the progress of a single transfer on the bar
+func barProgress(bar *pb.ProgressBar) client.ProgressFunc {
+	return func(transferred, total int64) {
+		bar.SetTotal(total)
+		bar.SetCurrent(transferred)
+	}
 }
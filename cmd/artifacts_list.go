@@ -14,7 +14,14 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -41,10 +48,12 @@ func init() {
 }
 
 type ArtifactsListCmd struct {
+	ctx         context.Context
 	server      string
 	skipVerify  bool
 	token       string
 	detailLevel int
+	output      string
 }
 
 func NewArtifactsListCmd(cmd *cobra.Command, args []string) (*ArtifactsListCmd, error) {
@@ -62,6 +71,14 @@ func NewArtifactsListCmd(cmd *cobra.Command, args []string) (*ArtifactsListCmd,
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDetailLevel(detailLevel, 3); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
 
 	token, err := getAuthToken(cmd)
 	if err != nil {
@@ -69,15 +86,109 @@ func NewArtifactsListCmd(cmd *cobra.Command, args []string) (*ArtifactsListCmd,
 	}
 
 	return &ArtifactsListCmd{
+		ctx:         cmd.Context(),
 		server:      server,
 		token:       token,
 		skipVerify:  skipVerify,
 		detailLevel: detailLevel,
+		output:      output,
 	}, nil
 }
 
 func (c *ArtifactsListCmd) Run() error {
 
 	client := deployments.NewClient(c.server, c.skipVerify)
-	return client.ListArtifacts(c.token, c.detailLevel)
+	list, err := client.ListArtifacts(c.ctx, c.token)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, artifactList(list))
+}
+
+type artifactList []deployments.Artifact
+
+func (l artifactList) printText(w io.Writer, detailLevel int) {
+	for _, a := range l {
+		printArtifact(w, a, detailLevel)
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l artifactList) header(wide bool) []string {
+	header := []string{"ID", "NAME", "DEVICE TYPES", "MODIFIED"}
+	if wide {
+		header = append(header, "SIGNED", "SIZE", "FORMAT", "DESCRIPTION")
+	}
+	return header
+}
+
+func (l artifactList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, a := range l {
+		row := []string{
+			a.ID,
+			a.Name,
+			strings.Join(a.DeviceTypesCompatible, ","),
+			a.Modified.Format(time.RFC3339),
+		}
+		if wide {
+			row = append(row,
+				strconv.FormatBool(a.Signed),
+				strconv.FormatInt(a.Size, 10),
+				fmt.Sprintf("%s/%d", a.Info.Format, a.Info.Version),
+				a.Description,
+			)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func printArtifact(w io.Writer, a deployments.Artifact, detailLevel int) {
+	fmt.Fprintf(w, "ID: %s\n", a.ID)
+	fmt.Fprintf(w, "Name: %s\n", a.Name)
+	if detailLevel >= 1 {
+		fmt.Fprintf(w, "Signed: %t\n", a.Signed)
+		fmt.Fprintf(w, "Modfied: %s\n", a.Modified)
+		fmt.Fprintf(w, "Size: %d\n", a.Size)
+		fmt.Fprintf(w, "Description: %s\n", a.Description)
+		fmt.Fprintln(w, "Compatible device types:")
+		for _, v := range a.DeviceTypesCompatible {
+			fmt.Fprintf(w, "  %s\n", v)
+		}
+		fmt.Fprintf(w, "Artifact format: %s\n", a.Info.Format)
+		fmt.Fprintf(w, "Format version: %d\n", a.Info.Version)
+	}
+	if detailLevel >= 2 {
+		fmt.Fprintf(w, "Artifact provides: %s\n", a.ArtifactProvides.ArtifactName)
+		fmt.Fprintln(w, "Artifact depends:")
+		for _, v := range a.ArtifactDepends.DeviceType {
+			fmt.Fprintf(w, "  %s\n", v)
+		}
+		fmt.Fprintln(w, "Updates:")
+		for _, v := range a.Updates {
+			updateType := ""
+			if v.TypeInfo.Type != nil {
+				updateType = *v.TypeInfo.Type
+			}
+			fmt.Fprintf(w, "  Type: %s\n", updateType)
+			fmt.Fprintln(w, "  Files:")
+			for _, f := range v.Files {
+				fmt.Fprintf(w, "\tName: %s\n", f.Name)
+				fmt.Fprintf(w, "\tChecksum: %s\n", f.Checksum)
+				fmt.Fprintf(w, "\tSize: %d\n", f.Size)
+				if f.Date != nil {
+					fmt.Fprintf(w, "\tDate: %s\n", f.Date)
+				} else {
+					fmt.Fprintf(w, "\tDate: %s\n", time.Time{})
+				}
+				if len(v.Files) > 1 {
+					fmt.Fprintln(w)
+				}
+			}
+			if detailLevel == 3 {
+				fmt.Fprintf(w, "  MetaData: %v\n", v.MetaData)
+			}
+		}
+	}
 }
@@ -0,0 +1,78 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Print the shell completion script.",
+	Long: "Print the completion script of the shell to the standard output.\n\n" +
+		"Besides the commands and flags, the script completes the device IDs, the\n" +
+		"DEVICE_ID:PATH specifications of cp, the artifact IDs and the group names\n" +
+		"by asking the server, whose answers are cached for a minute.",
+	Example: "  # Bash, for the current shell and for the new ones:\n" +
+		"  source <(mender-cli completion bash)\n" +
+		"  mender-cli completion bash > /etc/bash_completion.d/mender-cli\n\n" +
+		"  # Zsh, into a directory of $fpath:\n" +
+		"  mender-cli completion zsh > \"${fpath[1]}/_mender-cli\"\n\n" +
+		"  # Fish:\n" +
+		"  mender-cli completion fish > ~/.config/fish/completions/mender-cli.fish\n\n" +
+		"  # PowerShell, for the current shell:\n" +
+		"  mender-cli completion powershell | Out-String | Invoke-Expression",
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             completionShells,
+	DisableFlagsInUseLine: true,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewCompletionCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type CompletionCmd struct {
+	root  *cobra.Command
+	shell string
+	out   io.Writer
+}
+
+func NewCompletionCmd(cmd *cobra.Command, args []string) (*CompletionCmd, error) {
+	return &CompletionCmd{
+		root:  cmd.Root(),
+		shell: args[0],
+		out:   os.Stdout,
+	}, nil
+}
+
+func (c *CompletionCmd) Run() error {
+	switch c.shell {
+	case "bash":
+		return c.root.GenBashCompletionV2(c.out, true)
+	case "zsh":
+		return c.root.GenZshCompletion(c.out)
+	case "fish":
+		return c.root.GenFishCompletion(c.out, true)
+	case "powershell":
+		return c.root.GenPowerShellCompletionWithDesc(c.out)
+	}
+	return errors.Errorf("unsupported shell %q", c.shell)
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+const (
+	// the answers of the server are kept for the following completions of
+	// the same command line
+	completionCacheTTL = time.Minute
+	completionTimeout  = 5 * time.Second
+	// the devices are asked for in few large pages, and only so many of
+	// them, to answer within the timeout
+	completionDevicesPerPage = 500
+	completionMaxDevices     = 5000
+
+	completionKindDevices         = "devices"
+	completionKindAcceptedDevices = "accepted-devices"
+	completionKindArtifacts       = "artifacts"
+	completionKindGroups          = "groups"
+)
+
+type completionCache struct {
+	Time  time.Time `json:"time"`
+	Items []string  `json:"items"`
+}
+
+// completer asks the server for the completions of the arguments
+type completer struct {
+	ctx        context.Context
+	server     string
+	token      string
+	skipVerify bool
+}
+
+// newCompleter sets up the configuration, which the shell doesn't do when
+// completing the arguments of a command
+func newCompleter(cmd *cobra.Command) (*completer, context.CancelFunc, error) {
+	noPassphrasePrompt = true
+	if err := applyConfig(cmd); err != nil {
+		return nil, nil, err
+	}
+	validateConfiguration()
+	// the shell waits for the completions
+	client.SetRetryOptions(client.RetryOptions{})
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, _, err := readAuthToken(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	return &completer{
+		ctx:        ctx,
+		server:     viper.GetString(argRootServer),
+		token:      token,
+		skipVerify: skipVerify,
+	}, cancel, nil
+}
+
+// cachePath returns the cache file of the completions of the kind; each
+// server and user has their own
+func (c *completer) cachePath(kind string) (string, error) {
+	_, cachedir, err := userDirs()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(c.server + "\n" + c.token))
+	name := kind + "-" + hex.EncodeToString(sum[:8]) + ".json"
+	return filepath.Join(cachedir, "mender", "completion", name), nil
+}
+
+// cached returns the completions of the kind from the cache, or fetches
+// them from the server and caches them; each is "VALUE\tDESCRIPTION". The
+// items fetch returns with an error, e.g. on timeout, are cached too.
+func (c *completer) cached(kind string, fetch func() ([]string, error)) []string {
+	path, err := c.cachePath(kind)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		return nil
+	}
+	var cache completionCache
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cache) == nil &&
+		time.Since(cache.Time) < completionCacheTTL {
+		return cache.Items
+	}
+
+	items, err := fetch()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+		if len(items) == 0 {
+			return nil
+		}
+	}
+	data, err := json.Marshal(completionCache{Time: time.Now(), Items: items})
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0600)
+	}
+	if err != nil {
+		cobra.CompDebugln(err.Error(), false)
+	}
+	return items
+}
+
+// devices returns the device IDs, of all the devices or of those with the
+// status, described by their identity; on failure, those received before
+func (c *completer) devices(kind, status string) []string {
+	return c.cached(kind, func() ([]string, error) {
+		list, err := devices.NewClient(c.server, c.skipVerify).
+			ListDevices(c.ctx, c.token, devices.ListDevicesOptions{
+				Status:  status,
+				PerPage: completionDevicesPerPage,
+				Limit:   completionMaxDevices,
+			})
+		items := make([]string, 0, len(list))
+		for _, d := range list {
+			description := []string{}
+			for name, value := range identityAttributes(d.IdentityData) {
+				description = append(description, name+"="+value)
+			}
+			sort.Strings(description)
+			if status == "" {
+				description = append(description, "("+d.Status+")")
+			}
+			items = append(items, d.ID+"\t"+strings.Join(description, " "))
+		}
+		return items, err
+	})
+}
+
+func identityAttributes(identity devices.IdentityData) map[string]string {
+	attributes := map[string]string{}
+	for name := range identity {
+		if value := identity.Get(name); value != "" {
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
+
+// artifacts returns the artifact IDs described by the artifact names
+func (c *completer) artifacts() []string {
+	return c.cached(completionKindArtifacts, func() ([]string, error) {
+		list, err := deployments.NewClient(c.server, c.skipVerify).
+			ListArtifacts(c.ctx, c.token)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]string, 0, len(list))
+		for _, a := range list {
+			items = append(items, fmt.Sprintf("%s\t%s (%s)",
+				a.ID, a.Name, strings.Join(a.DeviceTypesCompatible, ", ")))
+		}
+		return items, nil
+	})
+}
+
+// groups returns the group names described by the group types
+func (c *completer) groups() []string {
+	return c.cached(completionKindGroups, func() ([]string, error) {
+		list, err := devices.NewClient(c.server, c.skipVerify).ListGroups(c.ctx, c.token)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]string, 0, len(list))
+		for _, g := range list {
+			items = append(items, g.Name+"\t"+g.Type)
+		}
+		return items, nil
+	})
+}
+
+// withPrefix returns the completions whose value starts with the prefix
+func withPrefix(items []string, prefix string) []string {
+	found := []string{}
+	for _, item := range items {
+		if strings.HasPrefix(item, prefix) {
+			found = append(found, item)
+		}
+	}
+	return found
+}
+
+// completeArgs returns a completion function completing the first
+// argument with the items returned by first, and the following ones with
+// those returned by rest, if not nil
+func completeArgs(
+	first func(c *completer) []string,
+	rest func(c *completer) []string,
+) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(
+		cmd *cobra.Command,
+		args []string,
+		toComplete string,
+	) ([]string, cobra.ShellCompDirective) {
+		items := first
+		if len(args) > 0 {
+			items = rest
+		}
+		if items == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		c, cancel, err := newCompleter(cmd)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer cancel()
+		return withPrefix(items(c), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func allDevices(c *completer) []string {
+	return c.devices(completionKindDevices, "")
+}
+
+func acceptedDevices(c *completer) []string {
+	return c.devices(completionKindAcceptedDevices, devices.AuthSetStatusAccepted)
+}
+
+func artifactIDs(c *completer) []string {
+	return c.artifacts()
+}
+
+func groupNames(c *completer) []string {
+	return c.groups()
+}
+
+var (
+	completeAcceptedDevice   = completeArgs(acceptedDevices, nil)
+	completeDevice           = completeArgs(allDevices, nil)
+	completeArtifact         = completeArgs(artifactIDs, nil)
+	completeGroup            = completeArgs(groupNames, nil)
+	completeGroupThenDevices = completeArgs(groupNames, acceptedDevices)
+)
+
+// completeDeviceSpec completes the arguments of cp: the DEVICE_ID:PATH
+// specifications of the accepted devices, and the local files
+func completeDeviceSpec(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 1 && strings.Contains(args[0], deviceDelimiter) {
+		// copying from the device to a local file
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	if strings.Contains(toComplete, deviceDelimiter) {
+		// the files on the device can't be listed
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	specs := []string{}
+	if c, cancel, err := newCompleter(cmd); err == nil {
+		defer cancel()
+		for _, item := range withPrefix(acceptedDevices(c), toComplete) {
+			id, description, _ := strings.Cut(item, "\t")
+			specs = append(specs, id+deviceDelimiter+"\t"+description)
+		}
+	} else {
+		cobra.CompDebugln(err.Error(), false)
+	}
+	if len(args) == 0 && len(specs) == 0 {
+		// copying a local file to the device
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return specs, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+// complete returns the completions of the last argument of the command
+// line, as the shell asks for them
+func complete(t *testing.T, srv *fakeserver.Server, token string, args ...string) []string {
+	t.Helper()
+	resetFlags(t, argRootServer, argRootTokenValue)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs(append([]string{
+		"__complete",
+		"--" + argRootServer, srv.URL,
+		"--" + argRootTokenValue, token,
+	}, args...))
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	// the last line is the directive
+	return lines[:len(lines)-1]
+}
+
+func TestCompletion(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	srv := fakeserver.New()
+	defer srv.Close()
+	accepted := srv.AddDevice(devices.Device{
+		Status:       devices.AuthSetStatusAccepted,
+		IdentityData: devices.IdentityData{devices.IdentityMac: "00:01"},
+	})
+	srv.AddDevice(devices.Device{Status: devices.AuthSetStatusPending})
+	artifact := srv.AddArtifact(deployments.Artifact{
+		Name:                  "release-1",
+		DeviceTypesCompatible: []string{"rpi4"},
+	}, []byte("data"))
+	token := srv.Token("user@example.com")
+
+	if got := complete(t, srv, token, "terminal", ""); len(got) != 1 ||
+		got[0] != accepted+"\tmac=00:01" {
+		t.Errorf("unexpected device completions %q", got)
+	}
+	if got := complete(t, srv, token, "cp", accepted[:4]); len(got) != 1 ||
+		got[0] != accepted+":\tmac=00:01" {
+		t.Errorf("unexpected cp completions %q", got)
+	}
+	if got := complete(t, srv, token, "cp", "./"); len(got) != 0 {
+		t.Errorf("expected the local files to be completed, got %q", got)
+	}
+	if got := complete(t, srv, token, "artifacts", "delete", ""); len(got) != 1 ||
+		got[0] != artifact+"\trelease-1 (rpi4)" {
+		t.Errorf("unexpected artifact completions %q", got)
+	}
+
+	// the devices are cached
+	before := len(srv.Requests())
+	complete(t, srv, token, "port-forward", "")
+	if after := len(srv.Requests()); after != before {
+		t.Errorf("expected the cached devices, got %d requests", after-before)
+	}
+}
+
+func TestCompletionManyDevices(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	srv := fakeserver.New()
+	defer srv.Close()
+	for i := 0; i < 30; i++ {
+		srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+	}
+
+	// more devices than the default page size are listed at once
+	if got := complete(t, srv, srv.Token("user@example.com"), "terminal", ""); len(got) != 30 {
+		t.Errorf("expected 30 devices, got %d", len(got))
+	}
+	requests := 0
+	for _, r := range srv.Requests() {
+		if r == http.MethodGet+" /api/management/v2/devauth/devices" {
+			requests++
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request for the devices, got %d", requests)
+	}
+}
+
+func TestCompletionCachePartial(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	c := &completer{server: "https://mender.example.com", token: "token"}
+
+	// nothing is cached without any items
+	got := c.cached("test", func() ([]string, error) {
+		return nil, errors.New("timeout")
+	})
+	if len(got) != 0 {
+		t.Errorf("expected no items, got %q", got)
+	}
+
+	// the items received before the failure are kept
+	items := []string{"a\tfirst", "b\tsecond"}
+	got = c.cached("test", func() ([]string, error) {
+		return items, errors.New("timeout")
+	})
+	if strings.Join(got, ",") != strings.Join(items, ",") {
+		t.Errorf("expected %q, got %q", items, got)
+	}
+	got = c.cached("test", func() ([]string, error) {
+		t.Error("expected the cached items")
+		return nil, nil
+	})
+	if strings.Join(got, ",") != strings.Join(items, ",") {
+		t.Errorf("expected %q from the cache, got %q", items, got)
+	}
+}
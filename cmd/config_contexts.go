@@ -0,0 +1,280 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var configGetContextsCmd = &cobra.Command{
+	Use:   "get-contexts",
+	Short: "List the contexts (server profiles) in the configuration file.",
+	Args:  cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigGetContextsCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+var configUseContextCmd = &cobra.Command{
+	Use:   "use-context [flags] NAME",
+	Short: "Set the current context in the configuration file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigContextCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Use())
+	},
+}
+
+var configSetContextCmd = &cobra.Command{
+	Use:   "set-context [flags] NAME",
+	Short: "Create or update a context (server profile) in the configuration file.",
+	Long: "Create or update a context (server profile) in the configuration file.\n\n" +
+		"The context takes the values of the --server, --token, --skip-verify,\n" +
+		"--ca-cert, --client-cert, --client-key and --proxy flags given on the command\n" +
+		"line; the other settings of an existing context are kept.\n" +
+		"Without --token, each context keeps its own token in the cache directory.",
+	Example: "  mender-cli config set-context eu --server https://eu.hosted.mender.io\n" +
+		"  mender-cli config set-context lab --server https://mender.lab --ca-cert lab-ca.pem",
+	Args: cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigContextCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Set(c))
+	},
+}
+
+var configDeleteContextCmd = &cobra.Command{
+	Use:   "delete-context [flags] NAME",
+	Short: "Delete a context (server profile) from the configuration file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigContextCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Delete())
+	},
+}
+
+func init() {
+	configSetContextCmd.Flags().StringP(argLoginUsername, "", "",
+		"username to log in with")
+}
+
+// ConfigContextCmd handles the commands modifying a single context
+type ConfigContextCmd struct {
+	name       string
+	configPath string
+}
+
+func NewConfigContextCmd(cmd *cobra.Command, args []string) (*ConfigContextCmd, error) {
+	name := args[0]
+	if name == "" || strings.ContainsAny(name, ". ") {
+		return nil, errors.Errorf("invalid context name %q", name)
+	}
+
+	configPath, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigContextCmd{
+		// the configuration keys are case insensitive
+		name:       strings.ToLower(name),
+		configPath: configPath,
+	}, nil
+}
+
+func (c *ConfigContextCmd) Use() error {
+	config, err := readConfigFile(c.configPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := configFileContexts(config)[c.name]; !ok {
+		return errors.Errorf("context %q not found in %s", c.name, c.configPath)
+	}
+	config[configCurrentContext] = c.name
+	if err := writeConfigFile(c.configPath, config); err != nil {
+		return err
+	}
+	log.Infof("Switched to context %q.\n", c.name)
+	return nil
+}
+
+func (c *ConfigContextCmd) Set(cmd *cobra.Command) error {
+	config, err := readConfigFile(c.configPath)
+	if err != nil {
+		return err
+	}
+	contexts := configFileContexts(config)
+	ctx, ok := contexts[c.name].(map[string]interface{})
+	if !ok {
+		ctx = map[string]interface{}{}
+	}
+
+	flags := cmd.Flags()
+	for _, name := range []string{
+		argRootServer, argRootToken, argRootCACert, argRootClientCert, argRootClientKey,
+		argRootProxy, argLoginUsername,
+	} {
+		if flags.Changed(name) {
+			value, err := flags.GetString(name)
+			if err != nil {
+				return err
+			}
+			setOrDelete(ctx, name, value)
+		}
+	}
+	if flags.Changed(argRootSkipVerify) {
+		skipVerify, err := flags.GetBool(argRootSkipVerify)
+		if err != nil {
+			return err
+		}
+		setOrDelete(ctx, argRootSkipVerify, skipVerify)
+	}
+
+	contexts[c.name] = ctx
+	config[configContexts] = contexts
+	if err := writeConfigFile(c.configPath, config); err != nil {
+		return err
+	}
+	if ok {
+		log.Infof("Context %q modified.\n", c.name)
+	} else {
+		log.Infof("Context %q created.\n", c.name)
+	}
+	return nil
+}
+
+func (c *ConfigContextCmd) Delete() error {
+	config, err := readConfigFile(c.configPath)
+	if err != nil {
+		return err
+	}
+	contexts := configFileContexts(config)
+	if _, ok := contexts[c.name]; !ok {
+		return errors.Errorf("context %q not found in %s", c.name, c.configPath)
+	}
+	delete(contexts, c.name)
+	config[configContexts] = contexts
+	if current, _ := config[configCurrentContext].(string); current == c.name {
+		delete(config, configCurrentContext)
+	}
+	if err := writeConfigFile(c.configPath, config); err != nil {
+		return err
+	}
+	log.Infof("Context %q deleted.\n", c.name)
+	return nil
+}
+
+// configFileContexts returns the contexts from the configuration file
+// content, with the names in lower case like viper sees them
+func configFileContexts(config map[string]interface{}) map[string]interface{} {
+	contexts := map[string]interface{}{}
+	if m, ok := config[configContexts].(map[string]interface{}); ok {
+		for name, ctx := range m {
+			contexts[strings.ToLower(name)] = ctx
+		}
+	}
+	return contexts
+}
+
+// setOrDelete sets the value, or deletes the key if the value is empty
+func setOrDelete(m map[string]interface{}, key string, value interface{}) {
+	if value == "" || value == false {
+		delete(m, key)
+		return
+	}
+	m[key] = value
+}
+
+type ConfigGetContextsCmd struct {
+	output string
+}
+
+func NewConfigGetContextsCmd(cmd *cobra.Command, args []string) (*ConfigGetContextsCmd, error) {
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigGetContextsCmd{output: output}, nil
+}
+
+func (c *ConfigGetContextsCmd) Run() error {
+	current := currentContext()
+	list := contextList{}
+	for _, name := range contextNames() {
+		ctx, err := getContext(name)
+		if err != nil {
+			return err
+		}
+		list = append(list, contextItem{
+			Name:          name,
+			Current:       name == current,
+			contextConfig: *ctx,
+		})
+	}
+	return printOutput(os.Stdout, c.output, 0, list)
+}
+
+type contextItem struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	contextConfig
+}
+
+type contextList []contextItem
+
+func (l contextList) printText(w io.Writer, detailLevel int) {
+	if len(l) == 0 {
+		fmt.Fprintln(w, "No contexts configured.")
+		return
+	}
+	_ = printOutput(w, outputTable, detailLevel, l)
+}
+
+func (l contextList) header(wide bool) []string {
+	header := []string{"CURRENT", "NAME", "SERVER"}
+	if wide {
+		header = append(header, "TOKEN", "SKIP-VERIFY", "CA-CERT", "CLIENT-CERT", "USERNAME")
+	}
+	return header
+}
+
+func (l contextList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, ctx := range l {
+		current := ""
+		if ctx.Current {
+			current = "*"
+		}
+		row := []string{current, ctx.Name, ctx.Server}
+		if wide {
+			row = append(row, ctx.Token, strconv.FormatBool(ctx.SkipVerify), ctx.CACert,
+				ctx.ClientCert, ctx.Username)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigSetContextKeepsOtherSources(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// the value from the environment must not be taken for a flag
+	t.Setenv(configEnvPrefix+"SKIP_VERIFY", "true")
+	resetFlags(t, argRootServer, argRootSkipVerify)
+
+	rootCmd.SetArgs([]string{
+		"config", "set-context", "prod", "--" + argRootServer, "https://prod.example.com",
+	})
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := readConfigFile(filepath.Join(home, configFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, _ := configFileContexts(config)["prod"].(map[string]interface{})
+	if ctx[argRootServer] != "https://prod.example.com" {
+		t.Errorf("unexpected context %+v", ctx)
+	}
+	if _, ok := ctx[argRootSkipVerify]; ok {
+		t.Errorf("%s was copied to the context: %+v", argRootSkipVerify, ctx)
+	}
+}
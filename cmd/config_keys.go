@@ -0,0 +1,449 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+const (
+	configEnvPrefix = "MENDER_CLI_"
+
+	configSourceFlag    = "flag"
+	configSourceEnv     = "env"
+	configSourceFile    = "file"
+	configSourceDefault = "default"
+
+	configKeyTokenExpiryWarning = "token-expiry-warning"
+	configKeyEncryptToken       = "encrypt-token"
+)
+
+// configKey describes a setting of the configuration file
+type configKey struct {
+	name        string
+	description string
+	// flag overriding the setting on the command line, if any
+	flag string
+	// default value of a setting without a flag
+	def string
+	// the setting is a boolean
+	boolean bool
+	// the setting is a duration
+	duration bool
+	// the setting is a non-negative integer
+	integer bool
+	// the value must not be printed
+	secret bool
+	// the setting can be part of a context
+	inContext bool
+}
+
+func init() {
+	for _, k := range configKeys {
+		if k.def != "" {
+			viper.SetDefault(k.name, k.def)
+		}
+	}
+}
+
+var configKeys = []configKey{
+	{
+		name:        configCurrentContext,
+		description: "name of the context in use",
+		flag:        argRootContext,
+	},
+	{
+		name:        argRootServer,
+		description: "root server URL",
+		flag:        argRootServer,
+		inContext:   true,
+	},
+	{
+		name:        argRootSkipVerify,
+		description: "skip SSL certificate verification",
+		flag:        argRootSkipVerify,
+		boolean:     true,
+		inContext:   true,
+	},
+	{
+		name:        argRootCACert,
+		description: "CA certificate file (PEM) to trust",
+		flag:        argRootCACert,
+		inContext:   true,
+	},
+	{
+		name:        argRootClientCert,
+		description: "client certificate file (PEM) for mutual TLS",
+		flag:        argRootClientCert,
+		inContext:   true,
+	},
+	{
+		name:        argRootClientKey,
+		description: "private key file (PEM) of the client certificate",
+		flag:        argRootClientKey,
+		inContext:   true,
+	},
+	{
+		name:        argRootProxy,
+		description: "HTTP, HTTPS or SOCKS5 proxy URL",
+		flag:        argRootProxy,
+		inContext:   true,
+	},
+	{
+		name:        argRootConnectTimeout,
+		description: "timeout for connecting to the server",
+		flag:        argRootConnectTimeout,
+		duration:    true,
+		inContext:   true,
+	},
+	{
+		name:        argRootReadTimeout,
+		description: "timeout for waiting for the server response",
+		flag:        argRootReadTimeout,
+		duration:    true,
+		inContext:   true,
+	},
+	{
+		name:        argRootKeepAlive,
+		description: "keep-alive period of the connections",
+		flag:        argRootKeepAlive,
+		duration:    true,
+		inContext:   true,
+	},
+	{
+		name:        argRootRetries,
+		description: "number of retries after a transient failure",
+		flag:        argRootRetries,
+		integer:     true,
+		inContext:   true,
+	},
+	{
+		name:        argRootRetryMaxDelay,
+		description: "maximum delay between the retries",
+		flag:        argRootRetryMaxDelay,
+		duration:    true,
+		inContext:   true,
+	},
+	{
+		name:        argRootRetryUnsafe,
+		description: "retry also the requests which are not idempotent",
+		flag:        argRootRetryUnsafe,
+		boolean:     true,
+		inContext:   true,
+	},
+	{
+		name:        argRootToken,
+		description: "JWT token file path",
+		flag:        argRootToken,
+		inContext:   true,
+	},
+	{
+		name:        configKeyTokenExpiryWarning,
+		description: "warn when the token expires within this time, e.g. 24h or 7d; 0 disables",
+		def:         "24h",
+		inContext:   true,
+	},
+	{
+		name:        configKeyEncryptToken,
+		description: "encrypt the saved token with a passphrase",
+		flag:        configKeyEncryptToken,
+		boolean:     true,
+		inContext:   true,
+	},
+	{
+		name:        argLoginUsername,
+		description: "username to log in with",
+		flag:        argLoginUsername,
+		inContext:   true,
+	},
+	{
+		name:        argLoginTenant,
+		description: "ID or name of the tenant to log in to",
+		flag:        argLoginTenant,
+		inContext:   true,
+	},
+	{
+		name:        argLoginPassword,
+		description: "password to log in with",
+		flag:        argLoginPassword,
+		secret:      true,
+	},
+}
+
+func findConfigKey(name string) (*configKey, error) {
+	for i, k := range configKeys {
+		if k.name == strings.ToLower(name) {
+			return &configKeys[i], nil
+		}
+	}
+	names := make([]string, len(configKeys))
+	for i, k := range configKeys {
+		names[i] = k.name
+	}
+	return nil, errors.Errorf("unknown configuration key %q, must be one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// envName returns the environment variable setting the key
+func (k configKey) envName() string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(k.name, "-", "_"))
+}
+
+// validate checks the value given for the key
+func (k configKey) validate(value string) error {
+	if k.boolean {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.Errorf("invalid value %q for %s, must be true or false",
+				value, k.name)
+		}
+	}
+	if k.duration {
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return errors.Errorf("invalid value %q for %s, must be a duration, e.g. 30s",
+				value, k.name)
+		}
+	}
+	if k.integer {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return errors.Errorf("invalid value %q for %s, must be a number, e.g. 3",
+				value, k.name)
+		}
+	}
+	switch k.name {
+	case argRootServer:
+		if _, err := url.Parse(value); err != nil || value == "" {
+			return errors.Errorf("invalid value %q for %s, must be a URL", value, k.name)
+		}
+	case configKeyTokenExpiryWarning:
+		if value != "0" {
+			if _, err := parseExpiry(value); err != nil {
+				return errors.Errorf("invalid value %q for %s, must be a duration",
+					value, k.name)
+			}
+		}
+	case configCurrentContext:
+		if value != "" {
+			if _, err := getContext(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configResolver finds the effective value of the settings and where it
+// comes from: a flag, the environment, a context in the configuration file,
+// the top level of the configuration file or the default
+type configResolver struct {
+	cmd     *cobra.Command
+	path    string
+	file    map[string]interface{}
+	context string
+}
+
+func newConfigResolver(cmd *cobra.Command) (*configResolver, error) {
+	r := &configResolver{
+		cmd:  cmd,
+		path: viper.ConfigFileUsed(),
+		file: map[string]interface{}{},
+	}
+	if r.path != "" {
+		file, err := readConfigFile(r.path)
+		if err != nil {
+			return nil, err
+		}
+		r.file = file
+	}
+	current, _ := findConfigKey(configCurrentContext)
+	r.context, _ = r.resolve(*current)
+	r.context = strings.ToLower(r.context)
+	return r, nil
+}
+
+// resolve returns the effective value of the key and its source
+func (r *configResolver) resolve(k configKey) (string, string) {
+	flag := r.lookupFlag(k)
+	if flag != nil && flag.Changed {
+		return flag.Value.String(), configSourceFlag
+	}
+	if value, ok := os.LookupEnv(k.envName()); ok {
+		return value, configSourceEnv
+	}
+	if k.inContext && r.context != "" {
+		ctx, _ := configFileContexts(r.file)[r.context].(map[string]interface{})
+		if value, ok := lookupIgnoreCase(ctx, k.name); ok {
+			return value, fmt.Sprintf("%s (context %s)", configSourceFile, r.context)
+		}
+	}
+	if value, ok := lookupIgnoreCase(r.file, k.name); ok {
+		return value, configSourceFile
+	}
+	if flag != nil {
+		return flag.DefValue, configSourceDefault
+	}
+	if k.def != "" {
+		return k.def, configSourceDefault
+	}
+	if k.boolean {
+		return "false", configSourceDefault
+	}
+	return "", configSourceDefault
+}
+
+func (r *configResolver) lookupFlag(k configKey) *pflag.Flag {
+	if k.flag == "" || r.cmd == nil {
+		return nil
+	}
+	if f := r.cmd.Flags().Lookup(k.flag); f != nil {
+		return f
+	}
+	// the login flags are only defined on the login command
+	return loginCmd.Flags().Lookup(k.flag)
+}
+
+// lookupIgnoreCase finds the key like viper does, ignoring the case
+func lookupIgnoreCase(m map[string]interface{}, key string) (string, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) && v != nil {
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+// applyConfig makes the settings from the environment and the active
+// context the values of the corresponding flags; flags given on the
+// command line still win
+func applyConfig(cmd *cobra.Command) error {
+	r, err := newConfigResolver(cmd)
+	if err != nil {
+		return err
+	}
+	if r.context != "" {
+		if _, err := getContext(r.context); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range configKeys {
+		value, source := r.resolve(k)
+		if source == configSourceFlag || source == configSourceDefault {
+			continue
+		}
+		if err := k.validate(value); err != nil {
+			return errors.Wrapf(err, "configuration from %s", source)
+		}
+		viper.Set(k.name, value)
+		if flag := cmd.Flags().Lookup(k.flag); k.flag != "" && flag != nil {
+			// keep the flag unchanged, so that the source stays known
+			if err := flag.Value.Set(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := applyTLSConfig(r); err != nil {
+		return err
+	}
+	if err := applyTransportConfig(r); err != nil {
+		return err
+	}
+	return applyRetryConfig(r)
+}
+
+// applyTLSConfig loads the CA certificate and the client certificate, so
+// that all the clients use them, in place of those applied before
+func applyTLSConfig(r *configResolver) error {
+	client.ResetTLSConfig()
+	caCert, _ := findConfigKey(argRootCACert)
+	if value, source := r.resolve(*caCert); value != "" {
+		if err := client.AddCACertificate(value); err != nil {
+			return errors.Wrapf(err, "configuration from %s", source)
+		}
+	}
+
+	certKey, _ := findConfigKey(argRootClientCert)
+	keyKey, _ := findConfigKey(argRootClientKey)
+	cert, certSource := r.resolve(*certKey)
+	key, keySource := r.resolve(*keyKey)
+	switch {
+	case cert == "" && key == "":
+		return nil
+	case cert == "":
+		return errors.Errorf("configuration from %s: %s requires %s",
+			keySource, argRootClientKey, argRootClientCert)
+	case key == "":
+		return errors.Errorf("configuration from %s: %s requires %s",
+			certSource, argRootClientCert, argRootClientKey)
+	}
+	if err := client.SetClientCertificate(cert, key); err != nil {
+		return errors.Wrapf(err, "configuration from %s", certSource)
+	}
+	return nil
+}
+
+// applyTransportConfig sets the proxy and the timeouts of all the clients
+func applyTransportConfig(r *configResolver) error {
+	opts := client.TransportOptions{}
+	proxy, _ := findConfigKey(argRootProxy)
+	var source string
+	opts.Proxy, source = r.resolve(*proxy)
+	for name, d := range map[string]*time.Duration{
+		argRootConnectTimeout: &opts.ConnectTimeout,
+		argRootReadTimeout:    &opts.ReadTimeout,
+		argRootKeepAlive:      &opts.KeepAlive,
+	} {
+		k, _ := findConfigKey(name)
+		value, source := r.resolve(*k)
+		if err := k.validate(value); err != nil {
+			return errors.Wrapf(err, "configuration from %s", source)
+		}
+		*d, _ = time.ParseDuration(value)
+	}
+	if err := client.SetTransportOptions(opts); err != nil {
+		return errors.Wrapf(err, "configuration from %s", source)
+	}
+	return nil
+}
+
+// applyRetryConfig sets the retry policy of all the clients
+func applyRetryConfig(r *configResolver) error {
+	values := map[string]string{}
+	for _, name := range []string{argRootRetries, argRootRetryMaxDelay, argRootRetryUnsafe} {
+		k, _ := findConfigKey(name)
+		value, source := r.resolve(*k)
+		if err := k.validate(value); err != nil {
+			return errors.Wrapf(err, "configuration from %s", source)
+		}
+		values[name] = value
+	}
+	opts := client.RetryOptions{}
+	opts.Retries, _ = strconv.Atoi(values[argRootRetries])
+	opts.MaxDelay, _ = time.ParseDuration(values[argRootRetryMaxDelay])
+	opts.NonIdempotent, _ = strconv.ParseBool(values[argRootRetryUnsafe])
+	client.SetRetryOptions(opts)
+	return nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+// testCertificate is a certificate and its private key, also written to
+// PEM files
+type testCertificate struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCertificate returns a CA certificate if parent is nil, or a
+// client certificate signed by parent
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testCertificate{
+		key:      key,
+		certFile: filepath.Join(t.TempDir(), name+".crt"),
+		keyFile:  filepath.Join(t.TempDir(), name+".key"),
+	}
+	if c.cert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, c.certFile, "CERTIFICATE", der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, c.keyFile, "EC PRIVATE KEY", keyDER)
+	return c
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// get sends a request through the shared transport of the clients
+func get(url string, skipVerify bool) error {
+	rsp, err := client.NewHttpClient(skipVerify).Get(url)
+	if err == nil {
+		rsp.Body.Close()
+	}
+	return err
+}
+
+func TestApplyTLSConfig(t *testing.T) {
+	client.SetRetryOptions(client.RetryOptions{})
+	t.Cleanup(func() {
+		client.SetRetryOptions(client.RetryOptions{
+			Retries:  client.DefaultRetries,
+			MaxDelay: client.DefaultRetryMaxDelay,
+		})
+	})
+	srv := fakeserver.NewTLS()
+	defer srv.Close()
+	dir := t.TempDir()
+	serverCA := filepath.Join(dir, "server.crt")
+	writePEM(t, serverCA, "CERTIFICATE", srv.Certificate().Raw)
+	wrongCA := newTestCertificate(t, "wrong-ca", nil)
+	noCerts := filepath.Join(dir, "empty.crt")
+	if err := os.WriteFile(noCerts, []byte("no certificates"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	apply := func(config map[string]interface{}) error {
+		return applyTLSConfig(&configResolver{file: config})
+	}
+
+	if err := apply(map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, false); err == nil {
+		t.Error("expected the self-signed certificate to be rejected")
+	}
+	if err := get(srv.URL, true); err != nil {
+		t.Errorf("expected the verification to be skipped, got: %v", err)
+	}
+
+	for name, config := range map[string]map[string]interface{}{
+		"missing CA":       {argRootCACert: filepath.Join(dir, "missing.crt")},
+		"CA without certs": {argRootCACert: noCerts},
+	} {
+		if err := apply(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := apply(map[string]interface{}{argRootCACert: wrongCA.certFile}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, false); err == nil {
+		t.Error("expected the server certificate to be rejected with the wrong CA")
+	}
+
+	if err := apply(map[string]interface{}{argRootCACert: serverCA}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, false); err != nil {
+		t.Errorf("expected the server certificate to be trusted, got: %v", err)
+	}
+
+	// the CA is no longer trusted once removed from the configuration
+	if err := apply(map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, false); err == nil {
+		t.Error("expected the self-signed certificate to be rejected again")
+	}
+}
+
+func TestApplyTLSConfigClientCertificate(t *testing.T) {
+	client.SetRetryOptions(client.RetryOptions{})
+	t.Cleanup(func() {
+		client.SetRetryOptions(client.RetryOptions{
+			Retries:  client.DefaultRetries,
+			MaxDelay: client.DefaultRetryMaxDelay,
+		})
+	})
+	ca := newTestCertificate(t, "ca", nil)
+	cert := newTestCertificate(t, "client", ca)
+	other := newTestCertificate(t, "other", newTestCertificate(t, "other-ca", nil))
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  x509.NewCertPool(),
+	}
+	srv.TLS.ClientCAs.AddCert(ca.cert)
+	srv.StartTLS()
+	defer srv.Close()
+
+	apply := func(certFile, keyFile string) error {
+		return applyTLSConfig(&configResolver{file: map[string]interface{}{
+			argRootClientCert: certFile,
+			argRootClientKey:  keyFile,
+		}})
+	}
+
+	for name, files := range map[string][2]string{
+		"certificate without key":  {cert.certFile, ""},
+		"key without certificate":  {"", cert.keyFile},
+		"mismatched key":           {cert.certFile, other.keyFile},
+		"missing certificate file": {filepath.Join(t.TempDir(), "missing.crt"), cert.keyFile},
+	} {
+		if err := apply(files[0], files[1]); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := apply(other.certFile, other.keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, true); err == nil {
+		t.Error("expected the certificate from another CA to be rejected")
+	}
+
+	if err := apply(cert.certFile, cert.keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, true); err != nil {
+		t.Errorf("expected the client certificate to be accepted, got: %v", err)
+	}
+
+	if err := apply("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(srv.URL, true); err == nil {
+		t.Error("expected the request without a client certificate to be rejected")
+	}
+}
+
+func TestApplyTransportConfig(t *testing.T) {
+	t.Cleanup(func() {
+		_ = client.SetTransportOptions(client.TransportOptions{
+			ConnectTimeout: client.DefaultConnectTimeout,
+			KeepAlive:      client.DefaultKeepAlive,
+		})
+	})
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		valid  bool
+	}{
+		{name: "defaults", config: map[string]interface{}{}, valid: true},
+		{name: "valid", valid: true, config: map[string]interface{}{
+			argRootProxy:          "socks5://proxy:1080",
+			argRootConnectTimeout: "5s",
+			argRootReadTimeout:    "1m",
+			argRootKeepAlive:      "0",
+		}},
+		{name: "invalid proxy", config: map[string]interface{}{argRootProxy: "proxy:3128"}},
+		{name: "invalid timeout", config: map[string]interface{}{argRootReadTimeout: "30"}},
+		{name: "negative timeout",
+			config: map[string]interface{}{argRootConnectTimeout: "-1s"}},
+		{name: "invalid keep-alive", config: map[string]interface{}{argRootKeepAlive: "on"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().AddFlagSet(rootCmd.PersistentFlags())
+			err := applyTransportConfig(&configResolver{cmd: cmd, file: tc.config})
+			if valid := err == nil; valid != tc.valid {
+				t.Errorf("expected valid: %v, got: %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	secretMask = "********"
+)
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the effective configuration and where each value comes from.",
+	Long: "Show the effective configuration and where each value comes from.\n\n" +
+		"Each value comes from, in order of precedence: a command line flag, an\n" +
+		"environment variable (MENDER_CLI_<KEY>), the current context in the\n" +
+		"configuration file, the top level of the configuration file or the default.",
+	Args: cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigSettingsCmd(c)
+		CheckErr(err)
+		CheckErr(cmd.View())
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [flags] KEY",
+	Short: "Print the effective value of a configuration key.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigSettingsCmd(c)
+		CheckErr(err)
+		CheckErr(cmd.Get(args[0]))
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [flags] KEY VALUE",
+	Short: "Set a configuration key in the configuration file.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigSettingsCmd(c)
+		CheckErr(err)
+		CheckErr(cmd.Set(args[0], args[1]))
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset [flags] KEY",
+	Short: "Remove a configuration key from the configuration file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigSettingsCmd(c)
+		CheckErr(err)
+		CheckErr(cmd.Unset(args[0]))
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Show the configuration files searched and the one in use.",
+	Args:  cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewConfigSettingsCmd(c)
+		CheckErr(err)
+		CheckErr(cmd.Path())
+	},
+}
+
+// ConfigSettingsCmd handles the commands on the configuration keys
+type ConfigSettingsCmd struct {
+	resolver   *configResolver
+	configPath string
+	output     string
+}
+
+func NewConfigSettingsCmd(cmd *cobra.Command) (*ConfigSettingsCmd, error) {
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver, err := newConfigResolver(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	configPath, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigSettingsCmd{
+		resolver:   resolver,
+		configPath: configPath,
+		output:     output,
+	}, nil
+}
+
+func (c *ConfigSettingsCmd) setting(k configKey) configSetting {
+	value, source := c.resolver.resolve(k)
+	if k.secret && value != "" {
+		value = secretMask
+	}
+	return configSetting{
+		Key:    k.name,
+		Value:  value,
+		Source: source,
+		Env:    k.envName(),
+	}
+}
+
+func (c *ConfigSettingsCmd) View() error {
+	list := make(configSettingList, 0, len(configKeys))
+	for _, k := range configKeys {
+		list = append(list, c.setting(k))
+	}
+	return printOutput(os.Stdout, c.output, 0, list)
+}
+
+func (c *ConfigSettingsCmd) Get(name string) error {
+	k, err := findConfigKey(name)
+	if err != nil {
+		return err
+	}
+	setting := c.setting(*k)
+	if c.output == outputText {
+		fmt.Println(setting.Value)
+		return nil
+	}
+	return printOutput(os.Stdout, c.output, 0, configSettingList{setting})
+}
+
+func (c *ConfigSettingsCmd) Set(name, value string) error {
+	k, err := findConfigKey(name)
+	if err != nil {
+		return err
+	}
+	if err := k.validate(value); err != nil {
+		return err
+	}
+
+	config, err := readConfigFile(c.configPath)
+	if err != nil {
+		return err
+	}
+	deleteIgnoreCase(config, k.name)
+	if k.boolean {
+		config[k.name], _ = strconv.ParseBool(value)
+	} else {
+		config[k.name] = value
+	}
+	if err := writeConfigFile(c.configPath, config); err != nil {
+		return err
+	}
+	log.Infof("%s set in %s\n", k.name, c.configPath)
+	c.warnOverridden(*k)
+	return nil
+}
+
+func (c *ConfigSettingsCmd) Unset(name string) error {
+	k, err := findConfigKey(name)
+	if err != nil {
+		return err
+	}
+
+	config, err := readConfigFile(c.configPath)
+	if err != nil {
+		return err
+	}
+	if !deleteIgnoreCase(config, k.name) {
+		log.Infof("%s is not set in %s\n", k.name, c.configPath)
+		return nil
+	}
+	if err := writeConfigFile(c.configPath, config); err != nil {
+		return err
+	}
+	log.Infof("%s removed from %s\n", k.name, c.configPath)
+	c.warnOverridden(*k)
+	return nil
+}
+
+// warnOverridden tells if the value written to the file is not the
+// effective one
+func (c *ConfigSettingsCmd) warnOverridden(k configKey) {
+	_, source := c.resolver.resolve(k)
+	switch source {
+	case configSourceFile, configSourceDefault, configSourceFlag:
+	default:
+		log.Infof("note: %s is overridden by the %s\n", k.name, source)
+	}
+}
+
+func (c *ConfigSettingsCmd) Path() error {
+	used := viper.ConfigFileUsed()
+	list := configPathList{}
+	seen := map[string]bool{}
+	for _, dir := range configSearchPaths() {
+		path := filepath.Join(dir, configFileName)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		status := "not found"
+		if path == used {
+			status = "in use"
+		} else if _, err := os.Stat(path); err == nil {
+			status = "ignored"
+		}
+		list = append(list, configPath{Path: path, Status: status})
+	}
+	if used == "" {
+		log.Infof("No configuration file in use; it will be created as %s\n", c.configPath)
+	}
+	return printOutput(os.Stdout, c.output, 0, list)
+}
+
+// configSearchPaths returns the directories searched for the configuration
+// file, in the order viper searches them
+func configSearchPaths() []string {
+	dirs := []string{"/etc/mender-cli/"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	return dirs
+}
+
+// deleteIgnoreCase deletes the key in any case and tells if it was found
+func deleteIgnoreCase(m map[string]interface{}, key string) bool {
+	found := false
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			delete(m, k)
+			found = true
+		}
+	}
+	return found
+}
+
+type configSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Env    string `json:"env"`
+}
+
+type configSettingList []configSetting
+
+func (l configSettingList) printText(w io.Writer, detailLevel int) {
+	_ = printOutput(w, outputTable, detailLevel, l)
+}
+
+func (l configSettingList) header(wide bool) []string {
+	header := []string{"KEY", "VALUE", "SOURCE"}
+	if wide {
+		header = append(header, "ENV")
+	}
+	return header
+}
+
+func (l configSettingList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, s := range l {
+		row := []string{s.Key, s.Value, s.Source}
+		if wide {
+			row = append(row, s.Env)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+type configPath struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+type configPathList []configPath
+
+func (l configPathList) printText(w io.Writer, detailLevel int) {
+	_ = printOutput(w, outputTable, detailLevel, l)
+}
+
+func (l configPathList) header(wide bool) []string {
+	return []string{"PATH", "STATUS"}
+}
+
+func (l configPathList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, p := range l {
+		rows = append(rows, []string{p.Path, p.Status})
+	}
+	return rows
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/client"
+)
+
+func TestConfigResolve(t *testing.T) {
+	file := map[string]interface{}{
+		argRootServer: "https://file.example.com",
+		configContexts: map[string]interface{}{
+			"prod":  map[string]interface{}{"SERVER": "https://prod.example.com"},
+			"empty": map[string]interface{}{},
+		},
+	}
+	tests := []struct {
+		name    string
+		key     string
+		flag    string
+		env     string
+		file    map[string]interface{}
+		context string
+		value   string
+		source  string
+	}{
+		{name: "default", key: argRootServer,
+			value: "https://default.example.com", source: configSourceDefault},
+		{name: "file", key: argRootServer, file: file,
+			value: "https://file.example.com", source: configSourceFile},
+		{name: "context", key: argRootServer, file: file, context: "prod",
+			value: "https://prod.example.com", source: "file (context prod)"},
+		{name: "context without the key", key: argRootServer, file: file, context: "empty",
+			value: "https://file.example.com", source: configSourceFile},
+		{name: "env", key: argRootServer, file: file, context: "prod",
+			env: "https://env.example.com", value: "https://env.example.com",
+			source: configSourceEnv},
+		{name: "flag", key: argRootServer, file: file, context: "prod",
+			env: "https://env.example.com", flag: "https://flag.example.com",
+			value: "https://flag.example.com", source: configSourceFlag},
+		{name: "default without a flag", key: configKeyTokenExpiryWarning,
+			value: "24h", source: configSourceDefault},
+		{name: "file without a flag", key: configKeyTokenExpiryWarning,
+			file:  map[string]interface{}{configKeyTokenExpiryWarning: "7d"},
+			value: "7d", source: configSourceFile},
+		{name: "not in a context", key: argLoginPassword, context: "prod",
+			file: map[string]interface{}{
+				argLoginPassword: "secret",
+				configContexts: map[string]interface{}{
+					"prod": map[string]interface{}{argLoginPassword: "other"},
+				},
+			},
+			value: "secret", source: configSourceFile},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := findConfigKey(tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv(k.envName(), tc.env)
+			if tc.env == "" {
+				os.Unsetenv(k.envName())
+			}
+			cmd := &cobra.Command{}
+			cmd.Flags().String(argRootServer, "https://default.example.com", "")
+			if tc.flag != "" {
+				if err := cmd.Flags().Set(argRootServer, tc.flag); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.file == nil {
+				tc.file = map[string]interface{}{}
+			}
+
+			r := &configResolver{cmd: cmd, file: tc.file, context: tc.context}
+			value, source := r.resolve(*k)
+			if value != tc.value || source != tc.source {
+				t.Errorf("expected %q from %s, got %q from %s",
+					tc.value, tc.source, value, source)
+			}
+		})
+	}
+}
+
+func TestConfigSetUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	c := &ConfigSettingsCmd{
+		resolver:   &configResolver{file: map[string]interface{}{}},
+		configPath: path,
+		output:     outputText,
+	}
+	readConfig := func() map[string]interface{} {
+		t.Helper()
+		config, err := readConfigFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config
+	}
+
+	if err := c.Set(argRootServer, "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(strings.ToUpper(argRootSkipVerify), "true"); err != nil {
+		t.Fatal(err)
+	}
+	config := readConfig()
+	if config[argRootServer] != "https://example.com" || config[argRootSkipVerify] != true {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+
+	for _, tc := range []struct{ key, value string }{
+		{"unknown", "value"},
+		{argRootSkipVerify, "yes"},
+		{argRootRetries, "-1"},
+		{argRootReadTimeout, "30"},
+		{configKeyTokenExpiryWarning, "soon"},
+		{argRootServer, ""},
+		{configCurrentContext, "missing"},
+	} {
+		if err := c.Set(tc.key, tc.value); err == nil {
+			t.Errorf("expected an error setting %s to %q", tc.key, tc.value)
+		}
+	}
+	if len(readConfig()) != 2 {
+		t.Errorf("the invalid values were written: %+v", readConfig())
+	}
+	if err := c.Unset("unknown"); err == nil {
+		t.Error("expected an error unsetting an unknown key")
+	}
+
+	if err := c.Unset(argRootServer); err != nil {
+		t.Fatal(err)
+	}
+	// unsetting a key which is not set is not an error
+	if err := c.Unset(argRootServer); err != nil {
+		t.Fatal(err)
+	}
+	config = readConfig()
+	if _, ok := config[argRootServer]; ok || config[argRootSkipVerify] != true {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(configEnvPrefix+"SERVER", "https://env.example.com")
+	t.Setenv(configEnvPrefix+"MAX_RETRIES", "5")
+	resetFlags(t, argRootServer, argRootRetries)
+	t.Cleanup(func() {
+		client.SetRetryOptions(client.RetryOptions{
+			Retries:  client.DefaultRetries,
+			MaxDelay: client.DefaultRetryMaxDelay,
+		})
+	})
+
+	rootCmd.SetArgs([]string{"config", "get", argRootServer})
+	out := captureStdout(t, func() {
+		if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "https://env.example.com\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	// the flags get the values from the environment, but are not marked as
+	// given on the command line
+	for name, value := range map[string]string{
+		argRootServer:  "https://env.example.com",
+		argRootRetries: "5",
+	} {
+		flag := configGetCmd.Flags().Lookup(name)
+		if flag.Value.String() != value || flag.Changed {
+			t.Errorf("unexpected flag %s=%s, changed: %v", name, flag.Value, flag.Changed)
+		}
+		k, _ := findConfigKey(name)
+		r, err := newConfigResolver(configGetCmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, source := r.resolve(*k); source != configSourceEnv {
+			t.Errorf("expected %s to come from %s, got %s", name, configSourceEnv, source)
+		}
+	}
+
+	t.Setenv(configEnvPrefix+"MAX_RETRIES", "many")
+	err := applyConfig(configGetCmd)
+	if err == nil || !strings.Contains(err.Error(), "configuration from env") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	argRootContext = "context"
+
+	configCurrentContext = "current-context"
+	configContexts       = "contexts"
+
+	configFileName = ".mender-clirc"
+)
+
+// contextConfig holds the settings of a named server profile
+type contextConfig struct {
+	Server     string `json:"server,omitempty" mapstructure:"server"`
+	Token      string `json:"token,omitempty" mapstructure:"token"`
+	SkipVerify bool   `json:"skip-verify,omitempty" mapstructure:"skip-verify"`
+	CACert     string `json:"ca-cert,omitempty" mapstructure:"ca-cert"`
+	ClientCert string `json:"client-cert,omitempty" mapstructure:"client-cert"`
+	ClientKey  string `json:"client-key,omitempty" mapstructure:"client-key"`
+	Proxy      string `json:"proxy,omitempty" mapstructure:"proxy"`
+	Username   string `json:"username,omitempty" mapstructure:"username"`
+}
+
+// currentContext returns the name of the active context, from the
+// --context flag or the configuration file; it is empty if no context is
+// in use
+func currentContext() string {
+	return viper.GetString(configCurrentContext)
+}
+
+func getContext(name string) (*contextConfig, error) {
+	key := configContexts + "." + name
+	if name == "" || strings.Contains(name, ".") || !viper.IsSet(key) {
+		return nil, errors.Errorf("context %q not found", name)
+	}
+	var ctx contextConfig
+	if err := viper.UnmarshalKey(key, &ctx); err != nil {
+		return nil, errors.Wrapf(err, "invalid context %q", name)
+	}
+	return &ctx, nil
+}
+
+// contextNames returns the names of the configured contexts, sorted
+func contextNames() []string {
+	names := []string{}
+	for name := range viper.GetStringMap(configContexts) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getContextAuthTokenPath returns the token path of the active context, or
+// an empty string if no context is in use
+func getContextAuthTokenPath(cachedir string) (string, error) {
+	name := currentContext()
+	if name == "" {
+		return "", nil
+	}
+	ctx, err := getContext(name)
+	if err != nil {
+		return "", err
+	}
+	if ctx.Token != "" {
+		return ctx.Token, nil
+	}
+	return filepath.Join(cachedir, "mender", "contexts", name, "authtoken"), nil
+}
+
+// configFilePath returns the configuration file to modify: the one in use,
+// or the one in the home directory if there is none
+func configFilePath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "Not able to determine the home directory")
+	}
+	return filepath.Join(home, configFileName), nil
+}
+
+// readConfigFile returns the content of the configuration file as written,
+// without the values from the flags and the environment
+func readConfigFile(path string) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Failed to read config")
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return config, nil
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse config %s", path)
+	}
+	return config, nil
+}
+
+func writeConfigFile(path string, config map[string]interface{}) error {
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+	// the file may hold a password
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return errors.Wrap(err, "Failed to write config")
+	}
+	return nil
+}
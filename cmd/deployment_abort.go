@@ -0,0 +1,81 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var deploymentAbortCmd = &cobra.Command{
+	Use:   "abort [flags] DEPLOYMENT_ID",
+	Short: "Abort a deployment on the Mender server.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDeploymentAbortCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type DeploymentAbortCmd struct {
+	ctx          context.Context
+	server       string
+	skipVerify   bool
+	token        string
+	deploymentID string
+}
+
+func NewDeploymentAbortCmd(cmd *cobra.Command, args []string) (*DeploymentAbortCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentAbortCmd{
+		ctx:          cmd.Context(),
+		server:       server,
+		token:        token,
+		skipVerify:   skipVerify,
+		deploymentID: args[0],
+	}, nil
+}
+
+func (c *DeploymentAbortCmd) Run() error {
+	client := deployments.NewClient(c.server, c.skipVerify)
+	err := client.AbortDeployment(c.ctx, c.deploymentID, c.token)
+	if err != nil {
+		return err
+	}
+
+	log.Info("abort successful")
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	argDeploymentName         = "name"
+	argDeploymentArtifactName = "artifact-name"
+	argDeploymentDevices      = "devices"
+	argDeploymentGroup        = "group"
+	argDeploymentAllDevices   = "all-devices"
+	argDeploymentRetries      = "retries"
+)
+
+var deploymentCreateCmd = &cobra.Command{
+	Use:   "create [flags]",
+	Short: "Create a deployment on the Mender server.",
+	Long: "Create a deployment of the given artifact to a list of devices, " +
+		"to a device group, or to all the devices.",
+	Example: "  mender-cli deployments create --name release-1 " +
+		"--artifact-name release-1 --devices ID1,ID2\n" +
+		"  mender-cli deployments create --name release-1 " +
+		"--artifact-name release-1 --group production\n" +
+		"  mender-cli deployments create --name release-1 " +
+		"--artifact-name release-1 --all-devices",
+	Args: cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDeploymentCreateCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	deploymentCreateCmd.Flags().StringP(argDeploymentName, "", "", "deployment name")
+	deploymentCreateCmd.Flags().StringP(argDeploymentArtifactName, "", "",
+		"name of the artifact to deploy")
+	deploymentCreateCmd.Flags().StringSliceP(argDeploymentDevices, "", nil,
+		"comma-separated list of device IDs to deploy to")
+	deploymentCreateCmd.Flags().StringP(argDeploymentGroup, "", "",
+		"device group to deploy to")
+	deploymentCreateCmd.Flags().BoolP(argDeploymentAllDevices, "", false,
+		"deploy to all the devices")
+	deploymentCreateCmd.Flags().UintP(argDeploymentRetries, "", 0,
+		"number of times a device retries the deployment on failure")
+	_ = deploymentCreateCmd.MarkFlagRequired(argDeploymentName)
+	_ = deploymentCreateCmd.MarkFlagRequired(argDeploymentArtifactName)
+}
+
+type DeploymentCreateCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	deployment *deployments.NewDeployment
+}
+
+func NewDeploymentCreateCmd(cmd *cobra.Command, args []string) (*DeploymentCreateCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cmd.Flags().GetString(argDeploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	artifactName, err := cmd.Flags().GetString(argDeploymentArtifactName)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := cmd.Flags().GetStringSlice(argDeploymentDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := cmd.Flags().GetString(argDeploymentGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	allDevices, err := cmd.Flags().GetBool(argDeploymentAllDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	retries, err := cmd.Flags().GetUint(argDeploymentRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := &deployments.NewDeployment{
+		Name:         name,
+		ArtifactName: artifactName,
+		Devices:      devices,
+		Group:        group,
+		AllDevices:   allDevices,
+		Retries:      retries,
+	}
+	if err := deployment.Validate(); err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentCreateCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		deployment: deployment,
+	}, nil
+}
+
+func (c *DeploymentCreateCmd) Run() error {
+	client := deployments.NewClient(c.server, c.skipVerify)
+	id, err := client.CreateDeployment(c.ctx, c.deployment, c.token)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("deployment created: %s\n", id)
+
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+)
+
+var deploymentShowCmd = &cobra.Command{
+	Use:   "show [flags] DEPLOYMENT_ID",
+	Short: "Show a deployment and its per-device status counts.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDeploymentShowCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type DeploymentShowCmd struct {
+	ctx          context.Context
+	server       string
+	skipVerify   bool
+	token        string
+	deploymentID string
+	output       string
+}
+
+func NewDeploymentShowCmd(cmd *cobra.Command, args []string) (*DeploymentShowCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentShowCmd{
+		ctx:          cmd.Context(),
+		server:       server,
+		token:        token,
+		skipVerify:   skipVerify,
+		deploymentID: args[0],
+		output:       output,
+	}, nil
+}
+
+func (c *DeploymentShowCmd) Run() error {
+	client := deployments.NewClient(c.server, c.skipVerify)
+	deployment, err := client.GetDeployment(c.ctx, c.deploymentID, c.token)
+	if err != nil {
+		return err
+	}
+	stats, err := client.GetDeploymentStatistics(c.ctx, c.deploymentID, c.token)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, 2, &deploymentDetails{
+		Deployment: *deployment,
+		Statistics: stats,
+	})
+}
+
+type deploymentDetails struct {
+	deployments.Deployment
+	Statistics deployments.DeploymentStatistics `json:"statistics"`
+}
+
+func (d *deploymentDetails) printText(w io.Writer, detailLevel int) {
+	printDeployment(w, d.Deployment, detailLevel)
+	fmt.Fprintln(w, "Device status:")
+	for _, k := range d.Statistics.Keys() {
+		fmt.Fprintf(w, "  %s: %d\n", k, d.Statistics[k])
+	}
+}
+
+func (d *deploymentDetails) header(wide bool) []string {
+	header := deploymentList{}.header(wide)
+	for _, k := range d.Statistics.Keys() {
+		header = append(header, strings.ToUpper(k))
+	}
+	return header
+}
+
+func (d *deploymentDetails) rows(wide bool) [][]string {
+	row := deploymentRow(d.Deployment, wide)
+	for _, k := range d.Statistics.Keys() {
+		row = append(row, strconv.Itoa(d.Statistics[k]))
+	}
+	return [][]string{row}
+}
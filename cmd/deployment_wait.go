@@ -0,0 +1,274 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	argWaitTimeout  = "timeout"
+	argWaitInterval = "interval"
+	argWaitLogLines = "log-lines"
+
+	// exit codes of the wait command, besides 0 (success) and 1 (error)
+	exitCodeDeploymentFailed  = 2
+	exitCodeDeploymentTimeout = 3
+
+	progressBarTemplate = `{{counters . }} {{bar . }} {{percent . }} {{string . "status"}}`
+)
+
+var deploymentWaitCmd = &cobra.Command{
+	Use:   "wait [flags] DEPLOYMENT_ID",
+	Short: "Wait for a deployment to finish.",
+	Long: "Wait for a deployment to finish, showing the per-device progress.\n\n" +
+		"When the deployment finishes, the IDs of the failed devices are printed\n" +
+		"together with an excerpt of their deployment logs.\n\n" +
+		"Exit codes:\n" +
+		"  0  all the devices were updated successfully\n" +
+		"  1  error while waiting for the deployment\n" +
+		"  2  the deployment finished, but one or more devices failed\n" +
+		"  3  the deployment did not finish within the timeout",
+	Args: cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDeploymentWaitCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	deploymentWaitCmd.Flags().DurationP(argWaitTimeout, "", 0,
+		"maximum time to wait for the deployment to finish (0 waits forever)")
+	deploymentWaitCmd.Flags().DurationP(argWaitInterval, "", 5*time.Second,
+		"polling interval")
+	deploymentWaitCmd.Flags().IntP(argWaitLogLines, "", 10,
+		"number of deployment log lines to show for each failed device")
+	deploymentWaitCmd.Flags().BoolP(argWithoutProgress, "", false, "disable progress bar")
+}
+
+type DeploymentWaitCmd struct {
+	ctx             context.Context
+	server          string
+	skipVerify      bool
+	token           string
+	deploymentID    string
+	timeout         time.Duration
+	interval        time.Duration
+	logLines        int
+	withoutProgress bool
+}
+
+func NewDeploymentWaitCmd(cmd *cobra.Command, args []string) (*DeploymentWaitCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := cmd.Flags().GetDuration(argWaitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := cmd.Flags().GetDuration(argWaitInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("the polling interval must be positive")
+	}
+
+	logLines, err := cmd.Flags().GetInt(argWaitLogLines)
+	if err != nil {
+		return nil, err
+	}
+
+	withoutProgress, err := cmd.Flags().GetBool(argWithoutProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentWaitCmd{
+		ctx:             cmd.Context(),
+		server:          server,
+		token:           token,
+		skipVerify:      skipVerify,
+		deploymentID:    args[0],
+		timeout:         timeout,
+		interval:        interval,
+		logLines:        logLines,
+		withoutProgress: withoutProgress,
+	}, nil
+}
+
+func (c *DeploymentWaitCmd) Run() error {
+	// the timeout applies to the requests too, which may be retried
+	ctx := c.ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(c.ctx, c.timeout)
+		defer cancel()
+	}
+	err := c.wait(ctx)
+	if errors.Is(err, context.DeadlineExceeded) && c.ctx.Err() == nil {
+		return &exitError{
+			code: exitCodeDeploymentTimeout,
+			err: errors.Errorf(
+				"timed out after %s waiting for deployment %s to finish",
+				c.timeout, c.deploymentID,
+			),
+		}
+	}
+	return err
+}
+
+// wait polls the deployment until it finishes, or the context is done
+func (c *DeploymentWaitCmd) wait(ctx context.Context) error {
+	client := deployments.NewClient(c.server, c.skipVerify)
+
+	var bar *pb.ProgressBar
+	if !c.withoutProgress {
+		bar = pb.ProgressBarTemplate(progressBarTemplate).New(0).
+			SetRefreshRate(time.Millisecond * 100)
+		bar.Start()
+	}
+
+	lastSummary := ""
+	for {
+		deployment, err := client.GetDeployment(ctx, c.deploymentID, c.token)
+		if err != nil {
+			c.finishBar(bar)
+			return err
+		}
+		stats, err := client.GetDeploymentStatistics(ctx, c.deploymentID, c.token)
+		if err != nil {
+			c.finishBar(bar)
+			return err
+		}
+
+		summary := statisticsSummary(stats)
+		if bar != nil {
+			bar.SetTotal(int64(stats.Total()))
+			bar.SetCurrent(int64(stats.Finished()))
+			bar.Set("status", summary)
+		} else if summary != lastSummary {
+			log.Infof("%d/%d %s\n", stats.Finished(), stats.Total(), summary)
+		}
+		lastSummary = summary
+
+		if deployment.Status == deployments.DeploymentStatusFinished {
+			c.finishBar(bar)
+			return c.report(ctx, client, stats)
+		}
+
+		select {
+		case <-time.After(c.interval):
+		case <-ctx.Done():
+			c.finishBar(bar)
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *DeploymentWaitCmd) finishBar(bar *pb.ProgressBar) {
+	if bar != nil {
+		bar.Finish()
+	}
+}
+
+// report prints the failed devices with their logs and returns an error if
+// any device failed
+func (c *DeploymentWaitCmd) report(
+	ctx context.Context,
+	client *deployments.Client,
+	stats deployments.DeploymentStatistics,
+) error {
+	failed := stats.Failed()
+	if failed == 0 {
+		log.Infof("deployment %s finished successfully\n", c.deploymentID)
+		return nil
+	}
+
+	devices, err := client.ListDeploymentDevices(ctx, c.deploymentID, c.token)
+	if err != nil {
+		return err
+	}
+	log.Info("Failed devices:")
+	for _, d := range devices {
+		if !deployments.IsFailed(d.Status) {
+			continue
+		}
+		log.Infof("ID: %s\n", d.ID)
+		log.Infof("Status: %s\n", d.Status)
+		if d.Log && c.logLines > 0 {
+			deviceLog, err := client.GetDeploymentDeviceLog(ctx, c.deploymentID, d.ID, c.token)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: unable to get the log of device %s: %v\n",
+					d.ID, err)
+			} else {
+				log.Info("Log:")
+				for _, line := range lastLines(deviceLog, c.logLines) {
+					log.Infof("  %s\n", line)
+				}
+			}
+		}
+		log.Info("--------------------------------------------------------------------------------")
+	}
+
+	return &exitError{
+		code: exitCodeDeploymentFailed,
+		err: errors.Errorf("deployment %s finished with %d of %d devices failed",
+			c.deploymentID, failed, stats.Total()),
+	}
+}
+
+func statisticsSummary(stats deployments.DeploymentStatistics) string {
+	parts := []string{}
+	for _, k := range stats.Keys() {
+		if stats[k] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", k, stats[k]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func lastLines(s string, n int) []string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+func TestDeploymentWait(t *testing.T) {
+	client.SetRetryOptions(client.RetryOptions{Retries: 3, MaxDelay: time.Minute})
+	t.Cleanup(func() {
+		client.SetRetryOptions(client.RetryOptions{
+			Retries:  client.DefaultRetries,
+			MaxDelay: client.DefaultRetryMaxDelay,
+		})
+	})
+
+	tests := []struct {
+		name string
+		// the status the device reports, if any
+		status string
+		// the server fails the requests for the deployment
+		fail bool
+		code int
+	}{
+		{name: "success", status: "success"},
+		{name: "failure", status: "failure", code: exitCodeDeploymentFailed},
+		{name: "not finished", code: exitCodeDeploymentTimeout},
+		{name: "requests retried", fail: true, code: exitCodeDeploymentTimeout},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := fakeserver.New()
+			defer srv.Close()
+			token := srv.Token("user@example.com")
+			ctx := context.Background()
+			srv.AddArtifact(deployments.Artifact{Name: "release-1"}, []byte("data"))
+			dev := srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+			id, err := deployments.NewClient(srv.URL, false).CreateDeployment(ctx,
+				&deployments.NewDeployment{
+					Name:         "update",
+					ArtifactName: "release-1",
+					AllDevices:   true,
+				}, token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.status != "" {
+				srv.SetDeploymentDeviceStatus(id, dev, tc.status, "log")
+			}
+			if tc.fail {
+				srv.Fail(http.MethodGet, "/api/management/v1/deployments/deployments/"+id,
+					http.StatusServiceUnavailable, 100)
+			}
+
+			cmd := &DeploymentWaitCmd{
+				ctx:             ctx,
+				server:          srv.URL,
+				token:           token,
+				deploymentID:    id,
+				timeout:         200 * time.Millisecond,
+				interval:        10 * time.Millisecond,
+				withoutProgress: true,
+			}
+			start := time.Now()
+			err = cmd.Run()
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("the command took %s", elapsed)
+			}
+			var exitErr *exitError
+			switch {
+			case tc.code == 0 && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case tc.code != 0 && (!errors.As(err, &exitErr) || exitErr.code != tc.code):
+				t.Errorf("expected the exit code %d, got %v", tc.code, err)
+			}
+		})
+	}
+}
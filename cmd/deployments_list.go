@@ -0,0 +1,211 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+)
+
+const (
+	argDeploymentStatus   = "status"
+	argDeploymentPageSize = "page-size"
+	argDeploymentLimit    = "limit"
+)
+
+var deploymentsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Get a list of deployments from the Mender server.",
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDeploymentsListCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	deploymentsListCmd.Flags().IntP(argDetailLevel, "d", 0, "deployments list detail level [0..2]")
+	deploymentsListCmd.Flags().StringP(argDeploymentStatus, "", "",
+		"only list deployments with the given status "+
+			"(pending, inprogress, finished or scheduled)")
+	deploymentsListCmd.Flags().IntP(argDeploymentPageSize, "", 100,
+		"number of deployments requested from the server at once")
+	deploymentsListCmd.Flags().IntP(argDeploymentLimit, "", 0,
+		"maximum number of deployments to list (0 lists all the deployments)")
+}
+
+type DeploymentsListCmd struct {
+	ctx         context.Context
+	server      string
+	skipVerify  bool
+	token       string
+	options     deployments.ListDeploymentsOptions
+	detailLevel int
+	output      string
+}
+
+func NewDeploymentsListCmd(cmd *cobra.Command, args []string) (*DeploymentsListCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	detailLevel, err := cmd.Flags().GetInt(argDetailLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDetailLevel(detailLevel, 2); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := getListDeploymentsOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentsListCmd{
+		ctx:         cmd.Context(),
+		server:      server,
+		token:       token,
+		skipVerify:  skipVerify,
+		options:     options,
+		detailLevel: detailLevel,
+		output:      output,
+	}, nil
+}
+
+func getListDeploymentsOptions(cmd *cobra.Command) (deployments.ListDeploymentsOptions, error) {
+	options := deployments.ListDeploymentsOptions{}
+	var err error
+
+	if options.Status, err = cmd.Flags().GetString(argDeploymentStatus); err != nil {
+		return options, err
+	}
+	if options.PerPage, err = cmd.Flags().GetInt(argDeploymentPageSize); err != nil {
+		return options, err
+	}
+	if options.PerPage <= 0 {
+		return options, errors.New("the page size must be positive")
+	}
+	if options.Limit, err = cmd.Flags().GetInt(argDeploymentLimit); err != nil {
+		return options, err
+	}
+	if options.Limit < 0 {
+		return options, errors.New("the limit must not be negative")
+	}
+	return options, nil
+}
+
+func (c *DeploymentsListCmd) Run() error {
+
+	client := deployments.NewClient(c.server, c.skipVerify)
+	list, err := client.ListDeployments(c.ctx, c.token, c.options)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, deploymentList(list))
+}
+
+type deploymentList []deployments.Deployment
+
+func (l deploymentList) printText(w io.Writer, detailLevel int) {
+	for _, d := range l {
+		printDeployment(w, d, detailLevel)
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l deploymentList) header(wide bool) []string {
+	header := []string{"ID", "NAME", "ARTIFACT", "STATUS", "DEVICES"}
+	if wide {
+		header = append(header, "CREATED", "FINISHED", "TYPE")
+	}
+	return header
+}
+
+func (l deploymentList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, d := range l {
+		rows = append(rows, deploymentRow(d, wide))
+	}
+	return rows
+}
+
+func deploymentRow(d deployments.Deployment, wide bool) []string {
+	row := []string{d.ID, d.Name, d.ArtifactName, d.Status, strconv.Itoa(d.DeviceCount)}
+	if wide {
+		finished := ""
+		if d.Finished != nil {
+			finished = d.Finished.Format(time.RFC3339)
+		}
+		row = append(row, d.Created.Format(time.RFC3339), finished, d.Type)
+	}
+	return row
+}
+
+func printDeployment(w io.Writer, d deployments.Deployment, detailLevel int) {
+	fmt.Fprintf(w, "ID: %s\n", d.ID)
+	fmt.Fprintf(w, "Name: %s\n", d.Name)
+	fmt.Fprintf(w, "Artifact name: %s\n", d.ArtifactName)
+	fmt.Fprintf(w, "Status: %s\n", d.Status)
+	if detailLevel >= 1 {
+		fmt.Fprintf(w, "Created: %s\n", d.Created)
+		if d.Finished != nil {
+			fmt.Fprintf(w, "Finished: %s\n", d.Finished)
+		}
+		fmt.Fprintf(w, "Device count: %d\n", d.DeviceCount)
+	}
+	if detailLevel >= 2 {
+		if d.Type != "" {
+			fmt.Fprintf(w, "Type: %s\n", d.Type)
+		}
+		if len(d.Groups) > 0 {
+			fmt.Fprintln(w, "Groups:")
+			for _, v := range d.Groups {
+				fmt.Fprintf(w, "  %s\n", v)
+			}
+		}
+		if len(d.Artifacts) > 0 {
+			fmt.Fprintln(w, "Artifacts:")
+			for _, v := range d.Artifacts {
+				fmt.Fprintf(w, "  %s\n", v)
+			}
+		}
+	}
+}
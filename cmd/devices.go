@@ -18,11 +18,27 @@ import (
 )
 
 var devicesCmd = &cobra.Command{
-	Use:       "devices",
-	Short:     "Operations on mender devices.",
-	ValidArgs: []string{"list"},
+	Use:   "devices",
+	Short: "Operations on mender devices.",
+	ValidArgs: []string{
+		"list",
+		"inventory",
+		"search",
+		"accept",
+		"reject",
+		"dismiss",
+		"decommission",
+		"preauthorize",
+	},
 }
 
 func init() {
 	devicesCmd.AddCommand(devicesListCmd)
+	devicesCmd.AddCommand(devicesInventoryCmd)
+	devicesCmd.AddCommand(devicesSearchCmd)
+	devicesCmd.AddCommand(devicesAcceptCmd)
+	devicesCmd.AddCommand(devicesRejectCmd)
+	devicesCmd.AddCommand(devicesDismissCmd)
+	devicesCmd.AddCommand(devicesDecommissionCmd)
+	devicesCmd.AddCommand(devicesPreauthorizeCmd)
 }
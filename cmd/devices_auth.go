@@ -0,0 +1,182 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	authSetActionAccept  = "accept"
+	authSetActionReject  = "reject"
+	authSetActionDismiss = "dismiss"
+)
+
+const authSetLong = "When AUTH_SET_ID is not given, the device must have either a single " +
+	"authentication set or a single pending one, which is then used."
+
+var devicesAcceptCmd = &cobra.Command{
+	Use:               "accept [flags] DEVICE_ID [AUTH_SET_ID]",
+	Short:             "Accept the authentication set of a device.",
+	Long:              "Accept the authentication set of a device.\n\n" + authSetLong,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeDevice,
+	Run:               runAuthSetCmd(authSetActionAccept),
+}
+
+var devicesRejectCmd = &cobra.Command{
+	Use:               "reject [flags] DEVICE_ID [AUTH_SET_ID]",
+	Short:             "Reject the authentication set of a device.",
+	Long:              "Reject the authentication set of a device.\n\n" + authSetLong,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeDevice,
+	Run:               runAuthSetCmd(authSetActionReject),
+}
+
+var devicesDismissCmd = &cobra.Command{
+	Use:               "dismiss [flags] DEVICE_ID [AUTH_SET_ID]",
+	Short:             "Dismiss the authentication set of a device.",
+	Long:              "Dismiss (remove) the authentication set of a device.\n\n" + authSetLong,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeDevice,
+	Run:               runAuthSetCmd(authSetActionDismiss),
+}
+
+func runAuthSetCmd(action string) func(c *cobra.Command, args []string) {
+	return func(c *cobra.Command, args []string) {
+		cmd, err := NewDevicesAuthSetCmd(c, args, action)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	}
+}
+
+// DevicesAuthSetCmd handles the accept, reject and dismiss commands
+type DevicesAuthSetCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	action     string
+	deviceID   string
+	authSetID  string
+}
+
+func NewDevicesAuthSetCmd(
+	cmd *cobra.Command,
+	args []string,
+	action string,
+) (*DevicesAuthSetCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	authSetID := ""
+	if len(args) == 2 {
+		authSetID = args[1]
+	}
+
+	return &DevicesAuthSetCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		action:     action,
+		deviceID:   args[0],
+		authSetID:  authSetID,
+	}, nil
+}
+
+func (c *DevicesAuthSetCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+
+	authSetID := c.authSetID
+	if authSetID == "" {
+		device, err := client.GetDevice(c.ctx, c.deviceID, c.token)
+		if err != nil {
+			return errors.Wrap(err, "unable to get the device")
+		}
+		authSetID, err = selectAuthSet(device)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch c.action {
+	case authSetActionAccept:
+		err = client.SetAuthSetStatus(
+			c.ctx,
+			c.deviceID, authSetID, devices.AuthSetStatusAccepted, c.token)
+	case authSetActionReject:
+		err = client.SetAuthSetStatus(
+			c.ctx,
+			c.deviceID, authSetID, devices.AuthSetStatusRejected, c.token)
+	case authSetActionDismiss:
+		err = client.DismissAuthSet(c.ctx, c.deviceID, authSetID, c.token)
+	default:
+		err = errors.New("unknown action: " + c.action)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%s successful for device %s, authentication set %s\n",
+		c.action, c.deviceID, authSetID)
+	return nil
+}
+
+// selectAuthSet returns the only authentication set of the device, or the
+// only pending one if the device has more
+func selectAuthSet(device *devices.Device) (string, error) {
+	if len(device.AuthSets) == 0 {
+		return "", fmt.Errorf("the device %s has no authentication sets", device.ID)
+	}
+	if len(device.AuthSets) == 1 {
+		return device.AuthSets[0].ID, nil
+	}
+	pending := []string{}
+	all := make([]string, 0, len(device.AuthSets))
+	for _, a := range device.AuthSets {
+		all = append(all, fmt.Sprintf("%s (%s)", a.ID, a.Status))
+		if a.Status == devices.AuthSetStatusPending {
+			pending = append(pending, a.ID)
+		}
+	}
+	if len(pending) == 1 {
+		return pending[0], nil
+	}
+	return "", fmt.Errorf(
+		"the device %s has %d authentication sets, please specify one of: %s",
+		device.ID, len(device.AuthSets), strings.Join(all, ", "))
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+func TestDevicesAuthSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		// the statuses of the authentication sets "a0", "a1"...
+		authSets []string
+		// the authentication set given on the command line, if any
+		authSetID string
+		// the expected statuses after the command, nil if it fails
+		expected []string
+	}{
+		{
+			name:     "single auth set",
+			action:   authSetActionAccept,
+			authSets: []string{devices.AuthSetStatusPending},
+			expected: []string{devices.AuthSetStatusAccepted},
+		},
+		{
+			name:   "single pending auth set",
+			action: authSetActionAccept,
+			authSets: []string{devices.AuthSetStatusAccepted, devices.AuthSetStatusPending,
+				devices.AuthSetStatusRejected},
+			expected: []string{devices.AuthSetStatusRejected, devices.AuthSetStatusAccepted,
+				devices.AuthSetStatusRejected},
+		},
+		{
+			name:      "explicit auth set",
+			action:    authSetActionReject,
+			authSets:  []string{devices.AuthSetStatusAccepted, devices.AuthSetStatusPending},
+			authSetID: "a0",
+			expected:  []string{devices.AuthSetStatusRejected, devices.AuthSetStatusPending},
+		},
+		{
+			name:      "explicit dismiss",
+			action:    authSetActionDismiss,
+			authSets:  []string{devices.AuthSetStatusAccepted, devices.AuthSetStatusRejected},
+			authSetID: "a1",
+			expected:  []string{devices.AuthSetStatusAccepted},
+		},
+		{
+			name:     "several pending auth sets",
+			action:   authSetActionAccept,
+			authSets: []string{devices.AuthSetStatusPending, devices.AuthSetStatusPending},
+		},
+		{
+			name:     "no pending auth set",
+			action:   authSetActionAccept,
+			authSets: []string{devices.AuthSetStatusAccepted, devices.AuthSetStatusRejected},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := fakeserver.New()
+			defer srv.Close()
+			authSets := make([]devices.AuthSet, len(tc.authSets))
+			for i, status := range tc.authSets {
+				authSets[i] = devices.AuthSet{ID: "a" + strconv.Itoa(i), Status: status}
+			}
+			id := srv.AddDevice(devices.Device{AuthSets: authSets})
+
+			cmd := &DevicesAuthSetCmd{
+				ctx:       context.Background(),
+				server:    srv.URL,
+				token:     srv.Token("user@example.com"),
+				action:    tc.action,
+				deviceID:  id,
+				authSetID: tc.authSetID,
+			}
+			err := cmd.Run()
+
+			device, _ := srv.Device(id)
+			statuses := make([]string, len(device.AuthSets))
+			for i, a := range device.AuthSets {
+				statuses[i] = a.Status
+			}
+			expected := tc.expected
+			if expected == nil {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				// the error lists the authentication sets to choose from
+				for _, a := range authSets {
+					if !strings.Contains(err.Error(), a.ID+" ("+a.Status+")") {
+						t.Errorf("expected %s in the error, got: %v", a.ID, err)
+					}
+				}
+				// and nothing changes
+				expected = tc.authSets
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(statuses, ",") != strings.Join(expected, ",") {
+				t.Errorf("expected the statuses %v, got %v", expected, statuses)
+			}
+		})
+	}
+}
+
+func TestDevicesAcceptCommand(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	id := srv.AddDevice(devices.Device{AuthSets: []devices.AuthSet{
+		{ID: "a0", Status: devices.AuthSetStatusPending},
+		{ID: "a1", Status: devices.AuthSetStatusPending},
+	}})
+
+	runCommand(t, srv, "devices", "accept", id, "a1")
+
+	device, _ := srv.Device(id)
+	if device.AuthSets[0].Status != devices.AuthSetStatusPending ||
+		device.AuthSets[1].Status != devices.AuthSetStatusAccepted {
+		t.Errorf("expected only a1 to be accepted, got %+v", device.AuthSets)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var devicesDecommissionCmd = &cobra.Command{
+	Use:               "decommission [flags] DEVICE_ID",
+	Short:             "Decommission a device, removing it and all its data from the Mender server.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDevice,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDevicesDecommissionCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type DevicesDecommissionCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	deviceID   string
+}
+
+func NewDevicesDecommissionCmd(
+	cmd *cobra.Command,
+	args []string,
+) (*DevicesDecommissionCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DevicesDecommissionCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		deviceID:   args[0],
+	}, nil
+}
+
+func (c *DevicesDecommissionCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	err := client.DecommissionDevice(c.ctx, c.deviceID, c.token)
+	if err != nil {
+		return err
+	}
+
+	log.Info("decommission successful")
+
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+var devicesInventoryCmd = &cobra.Command{
+	Use:               "inventory [flags] DEVICE_ID",
+	Short:             "Get the inventory attributes of a device from the Mender server.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDevice,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDevicesInventoryCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	devicesInventoryCmd.Flags().IntP(argDetailLevel, "d", 0, "device inventory detail level [0..3]")
+}
+
+type DevicesInventoryCmd struct {
+	ctx         context.Context
+	server      string
+	skipVerify  bool
+	token       string
+	deviceID    string
+	detailLevel int
+	output      string
+}
+
+func NewDevicesInventoryCmd(cmd *cobra.Command, args []string) (*DevicesInventoryCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	detailLevel, err := cmd.Flags().GetInt(argDetailLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDetailLevel(detailLevel, 3); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DevicesInventoryCmd{
+		ctx:         cmd.Context(),
+		server:      server,
+		token:       token,
+		skipVerify:  skipVerify,
+		deviceID:    args[0],
+		detailLevel: detailLevel,
+		output:      output,
+	}, nil
+}
+
+func (c *DevicesInventoryCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	device, err := client.GetInventoryDevice(c.ctx, c.deviceID, c.token)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, &inventoryDevice{device})
+}
+
+// inventoryDevice prints a single device, as returned by the show commands
+type inventoryDevice struct {
+	*devices.InventoryDevice
+}
+
+func (d *inventoryDevice) printText(w io.Writer, detailLevel int) {
+	printInventoryDevice(w, *d.InventoryDevice, detailLevel)
+}
+
+func (d *inventoryDevice) header(wide bool) []string {
+	return inventoryList{}.header(wide)
+}
+
+func (d *inventoryDevice) rows(wide bool) [][]string {
+	return inventoryList{*d.InventoryDevice}.rows(wide)
+}
+
+type inventoryList []devices.InventoryDevice
+
+func (l inventoryList) printText(w io.Writer, detailLevel int) {
+	for _, d := range l {
+		printInventoryDevice(w, d, detailLevel)
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l inventoryList) header(wide bool) []string {
+	header := []string{"ID", "DEVICE TYPE", "ARTIFACT", "UPDATED"}
+	if wide {
+		header = append(header, "STATUS", "GROUP", "KERNEL")
+	}
+	return header
+}
+
+func (l inventoryList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, d := range l {
+		row := []string{
+			d.ID,
+			inventoryValue(d, devices.ScopeInventory, "device_type"),
+			inventoryValue(d, devices.ScopeInventory, "artifact_name"),
+			d.UpdatedTs,
+		}
+		if wide {
+			row = append(row,
+				inventoryValue(d, devices.ScopeIdentity, "status"),
+				inventoryValue(d, devices.ScopeSystem, "group"),
+				inventoryValue(d, devices.ScopeInventory, "kernel"),
+			)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func inventoryValue(d devices.InventoryDevice, scope, name string) string {
+	v, _ := d.Attribute(scope, name)
+	return formatAttributeValue(v)
+}
+
+func formatAttributeValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = formatAttributeValue(e)
+		}
+		return strings.Join(values, ", ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func printInventoryDevice(w io.Writer, d devices.InventoryDevice, detailLevel int) {
+	fmt.Fprintf(w, "ID: %s\n", d.ID)
+	fmt.Fprintf(w, "Device type: %s\n", inventoryValue(d, devices.ScopeInventory, "device_type"))
+	fmt.Fprintf(w, "Artifact name: %s\n",
+		inventoryValue(d, devices.ScopeInventory, "artifact_name"))
+	if detailLevel >= 1 {
+		fmt.Fprintf(w, "UpdatedTs: %s\n", d.UpdatedTs)
+		fmt.Fprintln(w, "Attributes:")
+	}
+	for _, a := range d.Attributes {
+		if detailLevel < 1 || (detailLevel < 2 && a.Scope != devices.ScopeInventory) {
+			continue
+		}
+		name := a.Name
+		if detailLevel >= 2 {
+			name = a.Scope + "/" + a.Name
+		}
+		fmt.Fprintf(w, "  %s: %s\n", name, formatAttributeValue(a.Value))
+		if detailLevel == 3 && a.Description != "" {
+			fmt.Fprintf(w, "    Description: %s\n", a.Description)
+		}
+	}
+}
@@ -14,7 +14,13 @@
 package cmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -32,7 +38,15 @@ var devicesListCmd = &cobra.Command{
 	},
 }
 
-const argRawMode = "raw"
+const (
+	argRawMode        = "raw"
+	argDeviceStatus   = "status"
+	argDevicePageSize = "page-size"
+	argDeviceLimit    = "limit"
+	argDeviceMac      = "mac"
+	argDeviceSku      = "sku"
+	argDeviceSn       = "sn"
+)
 
 func init() {
 	devicesListCmd.Flags().IntP(argDetailLevel, "d", 0, "devices list detail level [0..3]")
@@ -41,14 +55,30 @@ func init() {
 		"r",
 		false,
 		"devices list raw mode (json from mender server)")
+	devicesListCmd.Flags().StringP(argDeviceStatus, "", "",
+		"only list devices with the given status "+
+			"(pending, accepted, rejected, preauthorized or noauth)")
+	devicesListCmd.Flags().IntP(argDevicePageSize, "", 100,
+		"number of devices requested from the server at once")
+	devicesListCmd.Flags().IntP(argDeviceLimit, "", 0,
+		"maximum number of devices to list (0 lists all the devices)")
+	devicesListCmd.Flags().StringP(argDeviceMac, "", "",
+		"only list devices with the given MAC address")
+	devicesListCmd.Flags().StringP(argDeviceSku, "", "",
+		"only list devices with the given stock keeping unit")
+	devicesListCmd.Flags().StringP(argDeviceSn, "", "",
+		"only list devices with the given serial number")
 }
 
 type DevicesListCmd struct {
+	ctx         context.Context
 	server      string
 	skipVerify  bool
 	token       string
 	detailLevel int
 	rawMode     bool
+	output      string
+	options     devices.ListDevicesOptions
 }
 
 func NewDevicesListCmd(cmd *cobra.Command, args []string) (*DevicesListCmd, error) {
@@ -66,28 +96,174 @@ func NewDevicesListCmd(cmd *cobra.Command, args []string) (*DevicesListCmd, erro
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDetailLevel(detailLevel, 3); err != nil {
+		return nil, err
+	}
 
 	rawMode, err := cmd.Flags().GetBool(argRawMode)
 	if err != nil {
 		return nil, err
 	}
 
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := getListDevicesOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := getAuthToken(cmd)
 	if err != nil {
 		return nil, err
 	}
 
 	return &DevicesListCmd{
+		ctx:         cmd.Context(),
 		server:      server,
 		token:       token,
 		skipVerify:  skipVerify,
 		detailLevel: detailLevel,
 		rawMode:     rawMode,
+		output:      output,
+		options:     options,
 	}, nil
 }
 
+func getListDevicesOptions(cmd *cobra.Command) (devices.ListDevicesOptions, error) {
+	options := devices.ListDevicesOptions{}
+	var err error
+
+	if options.Status, err = cmd.Flags().GetString(argDeviceStatus); err != nil {
+		return options, err
+	}
+	if options.PerPage, err = cmd.Flags().GetInt(argDevicePageSize); err != nil {
+		return options, err
+	}
+	if options.PerPage <= 0 {
+		return options, errors.New("the page size must be positive")
+	}
+	if options.Limit, err = cmd.Flags().GetInt(argDeviceLimit); err != nil {
+		return options, err
+	}
+	if options.Limit < 0 {
+		return options, errors.New("the limit must not be negative")
+	}
+	options.Identity = map[string]string{}
+	for name, flag := range map[string]string{
+		devices.IdentityMac: argDeviceMac,
+		devices.IdentitySku: argDeviceSku,
+		devices.IdentitySn:  argDeviceSn,
+	} {
+		if options.Identity[name], err = cmd.Flags().GetString(flag); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
 func (c *DevicesListCmd) Run() error {
 
 	client := devices.NewClient(c.server, c.skipVerify)
-	return client.ListDevices(c.token, c.detailLevel, c.rawMode)
+	if c.rawMode {
+		body, err := client.ListDevicesRaw(c.ctx, c.token, c.options)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(body))
+		return nil
+	}
+	list, err := client.ListDevices(c.ctx, c.token, c.options)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, deviceList(list))
+}
+
+type deviceList []devices.Device
+
+func (l deviceList) printText(w io.Writer, detailLevel int) {
+	for _, d := range l {
+		printDevice(w, d, detailLevel)
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l deviceList) header(wide bool) []string {
+	header := []string{"ID", "STATUS", "CREATED"}
+	if wide {
+		header = append(header, "MAC", "SKU", "SN", "UPDATED", "AUTH SETS", "DECOMMISSIONING")
+	}
+	return header
+}
+
+func (l deviceList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, d := range l {
+		row := []string{d.ID, d.Status, d.CreatedTs}
+		if wide {
+			row = append(row,
+				d.IdentityData.Get(devices.IdentityMac),
+				d.IdentityData.Get(devices.IdentitySku),
+				d.IdentityData.Get(devices.IdentitySn),
+				d.UpdatedTs,
+				strconv.Itoa(len(d.AuthSets)),
+				strconv.FormatBool(d.Decommissioning),
+			)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// the labels of the common identity attributes, printed first
+var identityLabels = []struct{ name, label string }{
+	{devices.IdentityMac, "MAC address"},
+	{devices.IdentitySku, "Stock keeping unit"},
+	{devices.IdentitySn, "Serial number"},
+}
+
+func printIdentityData(w io.Writer, indent string, identity devices.IdentityData) {
+	others := []string{}
+	for name := range identity {
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	for _, l := range identityLabels {
+		if value := identity.Get(l.name); value != "" {
+			fmt.Fprintf(w, "%s%s: %s\n", indent, l.label, value)
+		}
+	}
+	for _, name := range others {
+		switch name {
+		case devices.IdentityMac, devices.IdentitySku, devices.IdentitySn:
+			continue
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", indent, name, identity.Get(name))
+	}
+}
+
+func printDevice(w io.Writer, d devices.Device, detailLevel int) {
+	fmt.Fprintf(w, "ID: %s\n", d.ID)
+	fmt.Fprintf(w, "Status: %s\n", d.Status)
+	if detailLevel >= 1 {
+		fmt.Fprintln(w, "IdentityData:")
+		printIdentityData(w, "  ", d.IdentityData)
+		fmt.Fprintf(w, "CreatedTs: %s\n", d.CreatedTs)
+		fmt.Fprintf(w, "UpdatedTs: %s\n", d.UpdatedTs)
+		fmt.Fprintf(w, "Decommissioning: %t\n", d.Decommissioning)
+	}
+	if detailLevel >= 2 {
+		for i, v := range d.AuthSets {
+			fmt.Fprintf(w, "AuthSet[%d]:\n", i)
+			fmt.Fprintf(w, "  ID: %s\n", v.ID)
+			fmt.Fprintf(w, "  PubKey:\n%s", v.PubKey)
+			fmt.Fprintln(w, "  IdentityData:")
+			printIdentityData(w, "    ", v.IdentityData)
+			fmt.Fprintf(w, "  Status: %s\n", v.Status)
+			fmt.Fprintf(w, "  Ts: %s\n", v.Ts)
+		}
+	}
 }
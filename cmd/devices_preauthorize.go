@@ -0,0 +1,135 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	argPreauthIdentity = "identity"
+	argPreauthPubKey   = "pubkey"
+)
+
+var devicesPreauthorizeCmd = &cobra.Command{
+	Use:   "preauthorize [flags]",
+	Short: "Preauthorize a device with the given identity and public key.",
+	Example: "  mender-cli devices preauthorize --identity mac=00:11:22:33:44:55 " +
+		"--identity sku=foo --pubkey device.pub.pem",
+	Args: cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDevicesPreauthorizeCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	devicesPreauthorizeCmd.Flags().StringArrayP(argPreauthIdentity, "", nil,
+		"device identity attribute KEY=VALUE, can be repeated")
+	devicesPreauthorizeCmd.Flags().StringP(argPreauthPubKey, "", "",
+		"path to the device public key in PEM format")
+	_ = devicesPreauthorizeCmd.MarkFlagRequired(argPreauthIdentity)
+	_ = devicesPreauthorizeCmd.MarkFlagRequired(argPreauthPubKey)
+}
+
+type DevicesPreauthorizeCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	identity   map[string]string
+	pubKey     string
+}
+
+func NewDevicesPreauthorizeCmd(
+	cmd *cobra.Command,
+	args []string,
+) (*DevicesPreauthorizeCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	identityArgs, err := cmd.Flags().GetStringArray(argPreauthIdentity)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := parseKeyValues(identityArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid identity")
+	}
+
+	pubKeyPath, err := cmd.Flags().GetString(argPreauthPubKey)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := ioutil.ReadFile(pubKeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read the public key file")
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DevicesPreauthorizeCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		identity:   identity,
+		pubKey:     string(pubKey),
+	}, nil
+}
+
+func (c *DevicesPreauthorizeCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	err := client.PreauthorizeDevice(c.ctx, c.identity, c.pubKey, c.token)
+	if err != nil {
+		return err
+	}
+
+	log.Info("preauthorization successful")
+
+	return nil
+}
+
+// parseKeyValues parses a list of KEY=VALUE strings into a map
+func parseKeyValues(args []string) (map[string]string, error) {
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("expected KEY=VALUE, got %q", arg)
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values, nil
+}
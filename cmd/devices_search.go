@@ -0,0 +1,157 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+const (
+	argInventoryFilter = "filter"
+)
+
+var devicesSearchCmd = &cobra.Command{
+	Use:   "search [flags]",
+	Short: "Search the device inventory on the Mender server.",
+	Long: "Search the devices by inventory attributes.\n\n" +
+		"Filters have the form [SCOPE:]ATTRIBUTE=VALUE or [SCOPE:]ATTRIBUTE!=VALUE,\n" +
+		"where SCOPE is one of inventory (default), identity, system or tags.\n" +
+		"Devices must match all the given filters.",
+	Example: "  mender-cli devices search --filter device_type=raspberrypi4\n" +
+		"  mender-cli devices search --filter artifact_name!=release-2 " +
+		"--filter system:group=production",
+	Args: cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewDevicesSearchCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	devicesSearchCmd.Flags().IntP(argDetailLevel, "d", 0, "devices search detail level [0..3]")
+	devicesSearchCmd.Flags().StringArrayP(argInventoryFilter, "f", nil,
+		"inventory filter [SCOPE:]ATTRIBUTE=VALUE, can be repeated")
+	devicesSearchCmd.Flags().IntP(argDevicePageSize, "", 100,
+		"number of devices requested from the server at once")
+	devicesSearchCmd.Flags().IntP(argDeviceLimit, "", 0,
+		"maximum number of devices to list (0 lists all the devices)")
+}
+
+type DevicesSearchCmd struct {
+	ctx         context.Context
+	server      string
+	skipVerify  bool
+	token       string
+	filters     []devices.InventoryFilter
+	perPage     int
+	limit       int
+	detailLevel int
+	output      string
+}
+
+func NewDevicesSearchCmd(cmd *cobra.Command, args []string) (*DevicesSearchCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	detailLevel, err := cmd.Flags().GetInt(argDetailLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDetailLevel(detailLevel, 3); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	filterExprs, err := cmd.Flags().GetStringArray(argInventoryFilter)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := parseInventoryFilters(filterExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage, err := cmd.Flags().GetInt(argDevicePageSize)
+	if err != nil {
+		return nil, err
+	}
+	if perPage <= 0 {
+		return nil, errors.New("the page size must be positive")
+	}
+
+	limit, err := cmd.Flags().GetInt(argDeviceLimit)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, errors.New("the limit must not be negative")
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DevicesSearchCmd{
+		ctx:         cmd.Context(),
+		server:      server,
+		token:       token,
+		skipVerify:  skipVerify,
+		filters:     filters,
+		perPage:     perPage,
+		limit:       limit,
+		detailLevel: detailLevel,
+		output:      output,
+	}, nil
+}
+
+func (c *DevicesSearchCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	list, err := client.SearchInventory(c.ctx, c.token, c.filters, c.perPage, c.limit)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, inventoryList(list))
+}
+
+func parseInventoryFilters(exprs []string) ([]devices.InventoryFilter, error) {
+	filters := make([]devices.InventoryFilter, 0, len(exprs))
+	for _, expr := range exprs {
+		filter, err := devices.ParseInventoryFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, *filter)
+	}
+	return filters, nil
+}
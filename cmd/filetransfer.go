@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -29,10 +30,11 @@ const (
 )
 
 var fileTransferCmd = &cobra.Command{
-	Use:   "cp device_id:file_path file_path",
-	Short: "Transfer files from/to a device",
-	Long:  "A CLI interface for copying files from/to devices in your setup",
-	Args:  cobra.MinimumNArgs(2),
+	Use:               "cp device_id:file_path file_path",
+	Short:             "Transfer files from/to a device",
+	Long:              "A CLI interface for copying files from/to devices in your setup",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeDeviceSpec,
 	Run: func(c *cobra.Command, args []string) {
 		cmd, err := NewFileTransfer(c, args)
 		CheckErr(err)
@@ -41,6 +43,7 @@ var fileTransferCmd = &cobra.Command{
 }
 
 type FileTransferCmd struct {
+	ctx         context.Context
 	server      string
 	skipVerify  bool
 	source      string
@@ -65,6 +68,7 @@ func NewFileTransfer(cmd *cobra.Command, args []string) (*FileTransferCmd, error
 	}
 
 	return &FileTransferCmd{
+		ctx:         cmd.Context(),
 		server:      server,
 		skipVerify:  skipVerify,
 		token:       token,
@@ -83,7 +87,7 @@ func (c *FileTransferCmd) Run() error {
 func (c *FileTransferCmd) checkDevice(deviceID string) error {
 	// check if the device is connected
 	client := deviceconnect.NewClient(c.server, c.token, c.skipVerify)
-	device, err := client.GetDevice(deviceID)
+	device, err := client.GetDevice(c.ctx, deviceID)
 	if err != nil {
 		return errors.Wrap(err, "unable to get the device")
 	} else if device.Status != deviceconnect.CONNECTED {
@@ -102,7 +106,7 @@ func (c *FileTransferCmd) upload() error {
 		return err
 	}
 	client := deviceconnect.NewFileTransferClient(c.server, c.token, c.skipVerify)
-	if err = client.Upload(c.source, d); err != nil {
+	if err = client.Upload(c.ctx, c.source, d); err != nil {
 		return err
 	}
 	log.Infof("Successfully uploaded the file %q to device %q at location %q\n",
@@ -130,7 +134,7 @@ func (c *FileTransferCmd) download() error {
 		return err
 	}
 	client := deviceconnect.NewFileTransferClient(c.server, c.token, c.skipVerify)
-	if err = client.Download(d, c.destination); err != nil {
+	if err = client.Download(c.ctx, d, c.destination); err != nil {
 		return err
 	}
 	log.Infof("Successfully downloaded the file: %q from device %q to %q\n",
@@ -0,0 +1,104 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var groupCreateCmd = &cobra.Command{
+	Use:   "create [flags] GROUP",
+	Short: "Create a dynamic group from inventory filters.",
+	Long: "Create a dynamic group from inventory filters.\n\n" +
+		"Filters have the form [SCOPE:]ATTRIBUTE=VALUE or [SCOPE:]ATTRIBUTE!=VALUE,\n" +
+		"where SCOPE is one of inventory (default), identity, system or tags.\n" +
+		"The group contains the devices matching all the filters.\n\n" +
+		"Static groups are created by adding devices to them with \"groups add\".",
+	Example: "  mender-cli groups create rpi4-beta --filter device_type=raspberrypi4 " +
+		"--filter tags:channel=beta",
+	Args: cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewGroupCreateCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	groupCreateCmd.Flags().StringArrayP(argInventoryFilter, "f", nil,
+		"inventory filter [SCOPE:]ATTRIBUTE=VALUE, can be repeated")
+	_ = groupCreateCmd.MarkFlagRequired(argInventoryFilter)
+}
+
+type GroupCreateCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	group      string
+	filters    []devices.InventoryFilter
+}
+
+func NewGroupCreateCmd(cmd *cobra.Command, args []string) (*GroupCreateCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	filterExprs, err := cmd.Flags().GetStringArray(argInventoryFilter)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := parseInventoryFilters(filterExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupCreateCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		group:      args[0],
+		filters:    filters,
+	}, nil
+}
+
+func (c *GroupCreateCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	if err := client.CreateDynamicGroup(c.ctx, c.group, c.filters, c.token); err != nil {
+		return err
+	}
+
+	log.Infof("dynamic group %s created\n", c.group)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var groupAddCmd = &cobra.Command{
+	Use:               "add [flags] GROUP DEVICE_ID [DEVICE_ID...]",
+	Short:             "Add devices to a static group, creating the group if needed.",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeGroupThenDevices,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewGroupDevicesCmd(c, args, false)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+var groupRemoveCmd = &cobra.Command{
+	Use:               "remove [flags] GROUP DEVICE_ID [DEVICE_ID...]",
+	Short:             "Remove devices from a static group.",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeGroupThenDevices,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewGroupDevicesCmd(c, args, true)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+// GroupDevicesCmd handles the add and remove commands
+type GroupDevicesCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	group      string
+	deviceIDs  []string
+	remove     bool
+}
+
+func NewGroupDevicesCmd(
+	cmd *cobra.Command,
+	args []string,
+	remove bool,
+) (*GroupDevicesCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupDevicesCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		group:      args[0],
+		deviceIDs:  args[1:],
+		remove:     remove,
+	}, nil
+}
+
+func (c *GroupDevicesCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	if c.remove {
+		if err := client.RemoveFromGroup(c.ctx, c.group, c.deviceIDs, c.token); err != nil {
+			return err
+		}
+		log.Infof("removed %d device(s) from group %s\n", len(c.deviceIDs), c.group)
+		return nil
+	}
+	if err := client.AddToGroup(c.ctx, c.group, c.deviceIDs, c.token); err != nil {
+		return err
+	}
+	log.Infof("added %d device(s) to group %s\n", len(c.deviceIDs), c.group)
+	return nil
+}
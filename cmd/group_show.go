@@ -0,0 +1,128 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+var groupShowCmd = &cobra.Command{
+	Use:               "show [flags] GROUP",
+	Short:             "Show the devices in a static or dynamic group.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeGroup,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewGroupShowCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	groupShowCmd.Flags().IntP(argDetailLevel, "d", 0, "group devices detail level [0..3]")
+	groupShowCmd.Flags().IntP(argDevicePageSize, "", 100,
+		"number of devices requested from the server at once")
+	groupShowCmd.Flags().IntP(argDeviceLimit, "", 0,
+		"maximum number of devices to list (0 lists all the devices)")
+}
+
+type GroupShowCmd struct {
+	ctx         context.Context
+	server      string
+	skipVerify  bool
+	token       string
+	group       string
+	perPage     int
+	limit       int
+	detailLevel int
+	output      string
+}
+
+func NewGroupShowCmd(cmd *cobra.Command, args []string) (*GroupShowCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	detailLevel, err := cmd.Flags().GetInt(argDetailLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDetailLevel(detailLevel, 3); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage, err := cmd.Flags().GetInt(argDevicePageSize)
+	if err != nil {
+		return nil, err
+	}
+	if perPage <= 0 {
+		return nil, errors.New("the page size must be positive")
+	}
+
+	limit, err := cmd.Flags().GetInt(argDeviceLimit)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, errors.New("the limit must not be negative")
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupShowCmd{
+		ctx:         cmd.Context(),
+		server:      server,
+		token:       token,
+		skipVerify:  skipVerify,
+		group:       args[0],
+		perPage:     perPage,
+		limit:       limit,
+		detailLevel: detailLevel,
+		output:      output,
+	}, nil
+}
+
+func (c *GroupShowCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	group, err := client.GetGroup(c.ctx, c.group, c.token)
+	if err != nil {
+		return err
+	}
+	list, err := client.ListGroupDevices(c.ctx, group, c.token, c.perPage, c.limit)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, inventoryList(list))
+}
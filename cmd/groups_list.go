@@ -0,0 +1,152 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+var groupsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Get a list of static and dynamic device groups from the Mender server.",
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewGroupsListCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	groupsListCmd.Flags().IntP(argDetailLevel, "d", 0, "groups list detail level [0..1]")
+}
+
+type GroupsListCmd struct {
+	ctx         context.Context
+	server      string
+	skipVerify  bool
+	token       string
+	detailLevel int
+	output      string
+}
+
+func NewGroupsListCmd(cmd *cobra.Command, args []string) (*GroupsListCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	detailLevel, err := cmd.Flags().GetInt(argDetailLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDetailLevel(detailLevel, 1); err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupsListCmd{
+		ctx:         cmd.Context(),
+		server:      server,
+		token:       token,
+		skipVerify:  skipVerify,
+		detailLevel: detailLevel,
+		output:      output,
+	}, nil
+}
+
+func (c *GroupsListCmd) Run() error {
+	client := devices.NewClient(c.server, c.skipVerify)
+	groups, err := client.ListGroups(c.ctx, c.token)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, c.detailLevel, groupList(groups))
+}
+
+type groupList []devices.Group
+
+func (l groupList) printText(w io.Writer, detailLevel int) {
+	for _, g := range l {
+		fmt.Fprintf(w, "Name: %s\n", g.Name)
+		fmt.Fprintf(w, "Type: %s\n", g.Type)
+		if detailLevel >= 1 && g.Type == devices.GroupTypeDynamic {
+			fmt.Fprintf(w, "ID: %s\n", g.ID)
+			fmt.Fprintln(w, "Filters:")
+			for _, t := range g.Terms {
+				fmt.Fprintf(w, "  %s\n", formatInventoryFilter(t))
+			}
+		}
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l groupList) header(wide bool) []string {
+	header := []string{"NAME", "TYPE"}
+	if wide {
+		header = append(header, "ID", "FILTERS")
+	}
+	return header
+}
+
+func (l groupList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, g := range l {
+		row := []string{g.Name, g.Type}
+		if wide {
+			terms := make([]string, 0, len(g.Terms))
+			for _, t := range g.Terms {
+				terms = append(terms, formatInventoryFilter(t))
+			}
+			row = append(row, g.ID, strings.Join(terms, " "))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// formatInventoryFilter formats the filter in the same syntax as accepted
+// by the --filter flags
+func formatInventoryFilter(f devices.InventoryFilter) string {
+	op := "="
+	if f.Type == devices.FilterNotEqual {
+		op = "!="
+	} else if f.Type != devices.FilterEqual {
+		op = " " + f.Type + " "
+	}
+	return fmt.Sprintf("%s:%s%s%s", f.Scope, f.Attribute, op, formatAttributeValue(f.Value))
+}
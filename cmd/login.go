@@ -15,10 +15,10 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/howeyc/gopass"
@@ -34,8 +34,12 @@ const (
 	argLoginUsername = "username"
 	argLoginPassword = "password"
 	argLoginToken    = "2fa-code"
+	argLoginTenant   = "tenant"
 )
 
+// the tenant IDs are MongoDB object IDs
+var tenantIDPattern = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Log in to the Mender server (required before other operations).",
@@ -46,22 +50,39 @@ var loginCmd = &cobra.Command{
 	},
 }
 
-func init() {
-	loginCmd.Flags().
+// addCredentialFlags adds the flags of the commands which log in
+func addCredentialFlags(cmd *cobra.Command) {
+	cmd.Flags().
 		StringP(argLoginUsername, "", "", "username, format: email (will prompt if not provided)")
-	loginCmd.Flags().StringP(argLoginPassword, "", "", "password (will prompt if not provided)")
-	loginCmd.Flags().StringP(argLoginToken, "", "", "two-factor authentication token")
+	cmd.Flags().StringP(argLoginPassword, "", "", "password (will prompt if not provided)")
+	cmd.Flags().StringP(argLoginToken, "", "", "two-factor authentication token")
+}
+
+func init() {
+	addCredentialFlags(loginCmd)
+	loginCmd.Flags().StringP(argLoginTenant, "", "",
+		"ID or name of the tenant to log in to, if the user belongs to several")
+	loginCmd.Flags().BoolP(configKeyEncryptToken, "", false,
+		"encrypt the saved token with a passphrase, read from "+tokenPassphraseEnv+
+			" or prompted for")
 	_ = viper.BindPFlag(argLoginUsername, loginCmd.Flags().Lookup(argLoginUsername))
 	_ = viper.BindPFlag(argLoginPassword, loginCmd.Flags().Lookup(argLoginPassword))
+	_ = viper.BindPFlag(argLoginTenant, loginCmd.Flags().Lookup(argLoginTenant))
+	_ = viper.BindPFlag(configKeyEncryptToken, loginCmd.Flags().Lookup(configKeyEncryptToken))
+	loginCmd.AddCommand(loginStatusCmd)
 }
 
 type LoginCmd struct {
+	ctx        context.Context
 	server     string
 	skipVerify bool
 	username   string
 	password   string
 	token      string
 	tokenPath  string
+	tenant     string
+	// the token in use, to look up the tenant by name
+	currentToken string
 }
 
 func NewLoginCmd(cmd *cobra.Command, args []string) (*LoginCmd, error) {
@@ -77,6 +98,13 @@ func NewLoginCmd(cmd *cobra.Command, args []string) (*LoginCmd, error) {
 
 	username := viper.GetString(argLoginUsername)
 	password := viper.GetString(argLoginPassword)
+	// the flags of the other commands logging in aren't bound to viper
+	if cmd.Flags().Changed(argLoginUsername) {
+		username, _ = cmd.Flags().GetString(argLoginUsername)
+	}
+	if cmd.Flags().Changed(argLoginPassword) {
+		password, _ = cmd.Flags().GetString(argLoginPassword)
+	}
 
 	tfaToken, err := cmd.Flags().GetString(argLoginToken)
 	if err != nil {
@@ -95,13 +123,26 @@ func NewLoginCmd(cmd *cobra.Command, args []string) (*LoginCmd, error) {
 		}
 	}
 
+	tenant := viper.GetString(argLoginTenant)
+	currentToken := ""
+	if tenant != "" {
+		// a valid token isn't needed to log in
+		currentToken, _, err = readAuthToken(cmd)
+		if err != nil {
+			log.Verbf("no current token: %s\n", err)
+		}
+	}
+
 	return &LoginCmd{
-		server:     server,
-		username:   username,
-		password:   password,
-		token:      tfaToken,
-		tokenPath:  token,
-		skipVerify: skipVerify,
+		ctx:          cmd.Context(),
+		server:       server,
+		username:     username,
+		password:     password,
+		token:        tfaToken,
+		tokenPath:    token,
+		tenant:       tenant,
+		currentToken: currentToken,
+		skipVerify:   skipVerify,
 	}, nil
 }
 
@@ -116,7 +157,11 @@ func (c *LoginCmd) Run() error {
 		return err
 	}
 	client := useradm.NewClient(c.server, c.skipVerify)
-	res, err := client.Login(c.username, c.password, c.token)
+	tenantID, err := c.tenantID(client)
+	if err != nil {
+		return err
+	}
+	res, err := client.Login(c.ctx, c.username, c.password, c.token, tenantID)
 	if err != nil {
 		return err
 	}
@@ -129,6 +174,50 @@ func (c *LoginCmd) Run() error {
 	return nil
 }
 
+// tenantID returns the ID of the tenant to log in to; a tenant name is
+// looked up with the current token, or else with the token of a first login
+// to the default tenant
+func (c *LoginCmd) tenantID(client *useradm.Client) (string, error) {
+	if c.tenant == "" {
+		return "", nil
+	}
+	if c.currentToken != "" {
+		tenants, err := client.ListTenants(c.ctx, c.currentToken)
+		if err == nil {
+			return findTenantID(tenants, c.tenant)
+		}
+		log.Verbf("cannot list the tenants: %s\n", err)
+	}
+	if isTenantID(c.tenant) {
+		return c.tenant, nil
+	}
+
+	token, err := client.Login(c.ctx, c.username, c.password, c.token, "")
+	if err == nil {
+		var tenants []useradm.Tenant
+		tenants, err = client.ListTenants(c.ctx, string(token))
+		if err == nil {
+			return findTenantID(tenants, c.tenant)
+		}
+	}
+	return "", errors.Wrapf(err,
+		"cannot look up the tenant %q by name, which needs a token; use the tenant ID",
+		c.tenant)
+}
+
+// isTenantID tells whether the value has the format of a tenant ID
+func isTenantID(value string) bool {
+	return tenantIDPattern.MatchString(value)
+}
+
+func findTenantID(tenants []useradm.Tenant, idOrName string) (string, error) {
+	tenant, err := useradm.FindTenant(tenants, idOrName)
+	if err != nil {
+		return "", err
+	}
+	return tenant.ID, nil
+}
+
 func (c *LoginCmd) maybeGetUsername() error {
 	if c.username == "" {
 		fmt.Printf("Username: ")
@@ -159,21 +248,10 @@ func (c *LoginCmd) maybeGetPassword() error {
 }
 
 func (c *LoginCmd) saveToken(t []byte) error {
-	dir := filepath.Dir(c.tokenPath)
-	log.Verbf("creating directory: %v\n", dir)
-
-	err := os.MkdirAll(dir, os.ModeDir|0700)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create directory %s", dir)
-
-	}
-
-	err = ioutil.WriteFile(c.tokenPath, t, 0600)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create file %s", c.tokenPath)
+	if err := writeTokenFile(c.tokenPath, t); err != nil {
+		return err
 	}
 
-	log.Verb("saved token to: " + c.tokenPath)
 	log.Info("login successful")
 
 	return nil
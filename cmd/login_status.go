@@ -0,0 +1,144 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+)
+
+var loginStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the user, tenant, expiry and scopes of the current token.",
+	Long: "Show the user, tenant, expiry and scopes of the current token.\n\n" +
+		"The claims are decoded locally, without contacting the server. The\n" +
+		"command fails if the token has expired.",
+	Args: cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewLoginStatusCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type LoginStatusCmd struct {
+	server    string
+	token     string
+	tokenPath string
+	output    string
+}
+
+func NewLoginStatusCmd(cmd *cobra.Command, args []string) (*LoginStatusCmd, error) {
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenPath, err := readAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginStatusCmd{
+		server:    viper.GetString(argRootServer),
+		token:     token,
+		tokenPath: tokenPath,
+		output:    output,
+	}, nil
+}
+
+func (c *LoginStatusCmd) Run() error {
+	claims, err := useradm.ParseToken(c.token)
+	if err != nil {
+		return err
+	}
+	status := loginStatus{
+		Server:      c.server,
+		TokenFile:   c.tokenPath,
+		TokenClaims: *claims,
+	}
+	if left, ok := claims.ExpiresIn(); ok {
+		status.Expired = left <= 0
+	}
+	if err := printOutput(os.Stdout, c.output, 0, status); err != nil {
+		return err
+	}
+	if status.Expired {
+		return &exitError{code: 2, err: errTokenExpired}
+	}
+	return nil
+}
+
+var errTokenExpired = errors.New("token expired, run mender-cli login")
+
+type loginStatus struct {
+	Server    string `json:"server"`
+	TokenFile string `json:"token_file,omitempty"`
+	useradm.TokenClaims
+	Expired bool `json:"expired"`
+}
+
+func formatExpiry(claims useradm.TokenClaims) string {
+	left, ok := claims.ExpiresIn()
+	if !ok {
+		return "never"
+	}
+	expires := claims.ExpiresAt.Format(time.RFC3339)
+	if left <= 0 {
+		return expires + " (expired)"
+	}
+	return fmt.Sprintf("%s (in %s)", expires, left.Round(time.Minute))
+}
+
+func (s loginStatus) printText(w io.Writer, detailLevel int) {
+	fmt.Fprintf(w, "Server: %s\n", s.Server)
+	if s.TokenFile != "" {
+		fmt.Fprintf(w, "Token file: %s\n", s.TokenFile)
+	}
+	fmt.Fprintf(w, "User: %s\n", s.Subject)
+	if s.Tenant != "" {
+		fmt.Fprintf(w, "Tenant: %s\n", s.Tenant)
+	}
+	if s.Plan != "" {
+		fmt.Fprintf(w, "Plan: %s\n", s.Plan)
+	}
+	fmt.Fprintf(w, "Issued: %s\n", formatOptionalTime(s.IssuedAt))
+	fmt.Fprintf(w, "Expires: %s\n", formatExpiry(s.TokenClaims))
+	fmt.Fprintf(w, "Scopes: %s\n", strings.Join(s.Scopes, ", "))
+}
+
+func (s loginStatus) header(wide bool) []string {
+	header := []string{"USER", "TENANT", "EXPIRES"}
+	if wide {
+		header = append(header, "SERVER", "SCOPES")
+	}
+	return header
+}
+
+func (s loginStatus) rows(wide bool) [][]string {
+	row := []string{s.Subject, s.Tenant, formatExpiry(s.TokenClaims)}
+	if wide {
+		row = append(row, s.Server, strings.Join(s.Scopes, ","))
+	}
+	return [][]string{row}
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+func TestLoginTenantName(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	srv.AddUser("user@example.com", "secret")
+	srv.AddTenant("t1", "acme")
+	srv.AddTenant("t2", "acme-lab")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	resetFlags(t, argRootServer, argLoginUsername, argLoginPassword, argLoginTenant)
+	// no token to look up the tenant with
+	_ = rootCmd.PersistentFlags().Lookup(argRootTokenValue).Value.Set("")
+
+	rootCmd.SetArgs([]string{
+		"login", "--" + argRootServer, srv.URL,
+		"--" + argLoginUsername, "user@example.com", "--" + argLoginPassword, "secret",
+		"--" + argLoginTenant, "acme-lab",
+	})
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenPath, err := getDefaultAuthTokenPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := useradm.ParseToken(string(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Tenant != "t2" {
+		t.Errorf("expected to log in to the tenant t2, got %q", claims.Tenant)
+	}
+}
+
+func TestLoginVerboseHidesToken(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	srv.AddUser("user@example.com", "secret")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	resetFlags(t, argRootServer, argRootVerbose, argLoginUsername, argLoginPassword)
+	_ = rootCmd.PersistentFlags().Lookup(argRootTokenValue).Value.Set("")
+
+	rootCmd.SetArgs([]string{
+		"login", "--" + argRootServer, srv.URL, "--" + argRootVerbose,
+		"--" + argLoginUsername, "user@example.com", "--" + argLoginPassword, "secret",
+	})
+	out := captureStdout(t, func() {
+		if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tokenPath, err := getDefaultAuthTokenPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "VERBOSE response:") {
+		t.Errorf("expected the response in the verbose output, got:\n%s", out)
+	}
+	for _, secret := range []string{string(token), "secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%q is in the verbose output:\n%s", secret, out)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	argLogoutLocal = "local"
+)
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Log out from the Mender server and remove the saved token.",
+	Long: "Log out from the Mender server and remove the saved token.\n\n" +
+		"The token is invalidated on the server first; the saved token is\n" +
+		"removed even if this fails, e.g. because the token already expired.",
+	Args: cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewLogoutCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	logoutCmd.Flags().BoolP(argLogoutLocal, "", false,
+		"only remove the saved token, without contacting the server")
+}
+
+type LogoutCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	local      bool
+	token      string
+	tokenPath  string
+}
+
+func NewLogoutCmd(cmd *cobra.Command, args []string) (*LogoutCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := cmd.Flags().GetBool(argLogoutLocal)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenValue, err := cmd.Flags().GetString(argRootTokenValue)
+	if err != nil {
+		return nil, err
+	}
+	tokenPath := ""
+	if tokenValue == "" {
+		tokenPath, err = cmd.Flags().GetString(argRootToken)
+		if err != nil {
+			return nil, err
+		}
+		if tokenPath == "" {
+			tokenPath, err = getDefaultAuthTokenPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, err := os.Stat(tokenPath); os.IsNotExist(err) {
+			return nil, errors.New("not logged in")
+		}
+	}
+
+	token := ""
+	if !local {
+		// the token may be unreadable, e.g. without the passphrase, but
+		// can still be removed
+		token, _, err = readAuthToken(cmd)
+		if err != nil {
+			log.Errf("WARNING: not logging out on the server: %s\n", err)
+		}
+	}
+
+	return &LogoutCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		skipVerify: skipVerify,
+		local:      local,
+		token:      token,
+		tokenPath:  tokenPath,
+	}, nil
+}
+
+func (c *LogoutCmd) Run() error {
+	if c.token != "" {
+		// an expired token needs no logout
+		err := useradm.NewClient(c.server, c.skipVerify).Logout(c.ctx, c.token)
+		if err != nil && !errors.Is(err, client.ErrUnauthorized) {
+			log.Errf("WARNING: logging out on the server failed: %s\n", err)
+		}
+	}
+
+	if c.tokenPath != "" {
+		if err := os.Remove(c.tokenPath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "cannot remove the token")
+		}
+		log.Verb("removed token: " + c.tokenPath)
+	}
+
+	log.Info("logout successful")
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputWide  = "wide"
+
+	textSeparator = "--------------------------------------------------------------------------------"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputYAML, outputTable, outputWide}
+
+// printable is implemented by the results of the list and show commands
+type printable interface {
+	// printText prints the human readable output at the given detail level
+	printText(w io.Writer, detailLevel int)
+	// header returns the column names for the table and wide outputs
+	header(wide bool) []string
+	// rows returns the table rows for the table and wide outputs
+	rows(wide bool) [][]string
+}
+
+func getOutputFormat(cmd *cobra.Command) (string, error) {
+	format, err := cmd.Flags().GetString(argRootOutput)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range outputFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("invalid output format %q, must be one of: %s",
+		format, strings.Join(outputFormats, ", "))
+}
+
+func printOutput(w io.Writer, format string, detailLevel int, p printable) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case outputYAML:
+		// go through JSON so that the keys match the JSON output
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputTable, outputWide:
+		wide := format == outputWide
+		tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(p.header(wide), "\t"))
+		for _, row := range p.rows(wide) {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		p.printText(w, detailLevel)
+		return nil
+	}
+}
+
+func checkDetailLevel(detailLevel, max int) error {
+	if detailLevel > max || detailLevel < 0 {
+		return fmt.Errorf("invalid detail level %d, must be between 0 and %d", detailLevel, max)
+	}
+	return nil
+}
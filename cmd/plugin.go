@@ -0,0 +1,225 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/sys/unix"
+
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+// the plugins are the executables named mender-cli-NAME on the PATH, run as
+// mender-cli NAME
+const pluginPrefix = "mender-cli-"
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Operations on the mender-cli plugins.",
+	Long: "The plugins are the executables named " + pluginPrefix + "NAME on the PATH,\n" +
+		"run as 'mender-cli NAME'. A plugin named " + pluginPrefix + "fleet-report is\n" +
+		"run by 'mender-cli fleet-report' or 'mender-cli fleet report'.\n\n" +
+		"The plugins get the server, the token and the TLS settings in the\n" +
+		"MENDER_CLI_<KEY> environment variables, e.g. MENDER_CLI_SERVER and\n" +
+		tokenValueEnv + ", which the mender-cli commands they run use too.",
+	ValidArgs: []string{"list"},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+}
+
+type plugin struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// findPlugins returns the plugins on the PATH, in the order of the PATH
+// and then by name; those which can't be run warn why
+func findPlugins() []plugin {
+	plugins := []plugin{}
+	found := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		names := []string{}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), pluginPrefix) && !e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := plugin{
+				Name: strings.TrimPrefix(name, pluginPrefix),
+				Path: filepath.Join(dir, name),
+			}
+			if _, err := exec.LookPath(p.Path); err != nil {
+				p.Warnings = append(p.Warnings, "not executable")
+			} else if first, ok := found[p.Name]; ok {
+				p.Warnings = append(p.Warnings, "shadowed by "+first)
+			} else {
+				found[p.Name] = p.Path
+				if c, _, err := rootCmd.Find([]string{p.Name}); err == nil && c != rootCmd {
+					p.Warnings = append(p.Warnings,
+						"shadowed by the built-in command "+c.CommandPath())
+				}
+			}
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// isBuiltinCommand tells whether the arguments run a command of mender-cli,
+// including those cobra adds when executing the root command
+func isBuiltinCommand(args []string) bool {
+	switch args[0] {
+	case "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	c, _, err := rootCmd.Find(args)
+	return err == nil && c != rootCmd
+}
+
+// findPluginCommand returns the plugin run by the command line, if any, the
+// global flags preceding its name and its arguments. The longest matching
+// name wins: "fleet report daily" runs mender-cli-fleet-report-daily if it
+// exists, and mender-cli-fleet-report with the argument daily otherwise.
+func findPluginCommand(args []string) (string, []string, []string, bool) {
+	// the global flags are parsed only to find where the name starts, into
+	// copies which leave the flags of the root command unchanged
+	flags := pflag.NewFlagSet(rootCmd.Name(), pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.SetInterspersed(false)
+	copyFlag := func(f *pflag.Flag) {
+		if flags.Lookup(f.Name) == nil {
+			flags.AddFlag(&pflag.Flag{
+				Name:        f.Name,
+				Shorthand:   f.Shorthand,
+				NoOptDefVal: f.NoOptDefVal,
+				Value:       ignoredValue(f.Value.Type()),
+			})
+		}
+	}
+	rootCmd.PersistentFlags().VisitAll(copyFlag)
+	rootCmd.Flags().VisitAll(copyFlag)
+	if err := flags.Parse(args); err != nil {
+		return "", nil, nil, false
+	}
+	rest := flags.Args()
+	if len(rest) == 0 || isBuiltinCommand(rest) {
+		return "", nil, nil, false
+	}
+	globalFlags := args[:len(args)-len(rest)]
+
+	words := 0
+	for words < len(rest) && !strings.HasPrefix(rest[words], "-") {
+		words++
+	}
+	for n := words; n > 0; n-- {
+		name := pluginPrefix + strings.Join(rest[:n], "-")
+		if path, err := exec.LookPath(name); err == nil {
+			return path, globalFlags, rest[n:], true
+		}
+	}
+	return "", nil, nil, false
+}
+
+// ignoredValue is a flag value of the given type, which accepts and
+// discards any value
+type ignoredValue string
+
+func (v ignoredValue) String() string   { return "" }
+func (v ignoredValue) Set(string) error { return nil }
+func (v ignoredValue) Type() string     { return string(v) }
+
+// runPlugin replaces mender-cli with the plugin, passing it the effective
+// configuration in the environment
+func runPlugin(path string, globalFlags, args []string) error {
+	if err := rootCmd.ParseFlags(globalFlags); err != nil {
+		return err
+	}
+	verbose, err := rootCmd.Flags().GetBool(argRootVerbose)
+	if err != nil {
+		return err
+	}
+	log.Setup(verbose)
+	if err := applyConfig(rootCmd); err != nil {
+		return err
+	}
+	validateConfiguration()
+
+	env := map[string]string{}
+	r, err := newConfigResolver(rootCmd)
+	if err != nil {
+		return err
+	}
+	for _, k := range configKeys {
+		if k.secret {
+			continue
+		}
+		if value, _ := r.resolve(k); value != "" {
+			env[k.envName()] = value
+		}
+	}
+	server, _ := findConfigKey(argRootServer)
+	env[server.envName()] = viper.GetString(argRootServer)
+	if token, err := getAuthToken(rootCmd); err == nil {
+		env[tokenValueEnv] = token
+	} else {
+		log.Verbf("no token for the plugin: %s\n", err)
+	}
+
+	log.Verbf("running the plugin %s\n", path)
+	err = unix.Exec(path, append([]string{path}, args...), pluginEnviron(env))
+	return errors.Wrapf(err, "failed to run the plugin %s", path)
+}
+
+// pluginEnviron returns the environment of mender-cli with the variables
+// replaced by the given ones
+func pluginEnviron(vars map[string]string) []string {
+	environ := []string{}
+	for _, v := range os.Environ() {
+		name, _, _ := strings.Cut(v, "=")
+		if _, ok := vars[name]; !ok {
+			environ = append(environ, v)
+		}
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		environ = append(environ, name+"="+vars[name])
+	}
+	return environ
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins found on the PATH.",
+	Args:  cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewPluginListCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type PluginListCmd struct {
+	output string
+}
+
+func NewPluginListCmd(cmd *cobra.Command, args []string) (*PluginListCmd, error) {
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginListCmd{output: output}, nil
+}
+
+func (c *PluginListCmd) Run() error {
+	return printOutput(os.Stdout, c.output, 0, pluginList(findPlugins()))
+}
+
+type pluginList []plugin
+
+func (l pluginList) printText(w io.Writer, detailLevel int) {
+	if len(l) == 0 {
+		fmt.Fprintf(w, "No plugins found; they are the executables named %sNAME on the PATH\n",
+			pluginPrefix)
+		return
+	}
+	for _, p := range l {
+		fmt.Fprintf(w, "Name: %s\n", p.Name)
+		fmt.Fprintf(w, "Path: %s\n", p.Path)
+		for _, warning := range p.Warnings {
+			fmt.Fprintf(w, "Warning: %s\n", warning)
+		}
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l pluginList) header(wide bool) []string {
+	return []string{"NAME", "PATH", "WARNINGS"}
+}
+
+func (l pluginList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, p := range l {
+		warnings := "-"
+		if len(p.Warnings) > 0 {
+			warnings = strings.Join(p.Warnings, "; ")
+		}
+		rows = append(rows, []string{p.Name, p.Path, warnings})
+	}
+	return rows
+}
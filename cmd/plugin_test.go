@@ -0,0 +1,94 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindPluginCommand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"fleet-report", "artifacts"} {
+		path := filepath.Join(dir, pluginPrefix+name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	tests := map[string]struct {
+		args        []string
+		plugin      string
+		globalFlags []string
+		pluginArgs  []string
+	}{
+		"name": {
+			args:       []string{"fleet-report", "--daily"},
+			plugin:     "fleet-report",
+			pluginArgs: []string{"--daily"},
+		},
+		"words and global flags": {
+			args:        []string{"-k", "--server", "https://example.com", "fleet", "report", "x"},
+			plugin:      "fleet-report",
+			globalFlags: []string{"-k", "--server", "https://example.com"},
+			pluginArgs:  []string{"x"},
+		},
+		"built-in command": {
+			args: []string{"artifacts", "list"},
+		},
+		"help": {
+			args: []string{"help", "fleet-report"},
+		},
+		"unknown": {
+			args: []string{"fleet"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, globalFlags, args, ok := findPluginCommand(tc.args)
+			if tc.plugin == "" {
+				if ok {
+					t.Fatalf("expected no plugin, got %s", path)
+				}
+				return
+			}
+			if !ok || path != filepath.Join(dir, pluginPrefix+tc.plugin) {
+				t.Fatalf("expected the plugin %s, got %q", tc.plugin, path)
+			}
+			if len(globalFlags)+len(tc.globalFlags) > 0 &&
+				!reflect.DeepEqual(globalFlags, tc.globalFlags) {
+				t.Errorf("expected the global flags %q, got %q", tc.globalFlags, globalFlags)
+			}
+			if !reflect.DeepEqual(args, tc.pluginArgs) {
+				t.Errorf("expected the arguments %q, got %q", tc.pluginArgs, args)
+			}
+		})
+	}
+
+	// the global flags are only run through to find the plugin
+	for _, name := range []string{argRootSkipVerify, argRootServer} {
+		if flag := rootCmd.PersistentFlags().Lookup(name); flag.Changed {
+			t.Errorf("the flag %s was changed to %s", name, flag.Value)
+		}
+	}
+
+	plugins := findPlugins()
+	if len(plugins) != 2 || plugins[0].Name != "artifacts" || len(plugins[0].Warnings) != 1 ||
+		plugins[1].Name != "fleet-report" || len(plugins[1].Warnings) != 0 {
+		t.Errorf("unexpected plugins %+v", plugins)
+	}
+}
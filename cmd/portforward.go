@@ -54,7 +54,8 @@ var portForwardCmd = &cobra.Command{
 	Example: "  mender-cli port-forward DEVICE_ID 8000:8000\n" +
 		"  mender-cli port-forward DEVICE_ID udp/8000:8000\n" +
 		"  mender-cli port-forward DEVICE_ID tcp/8000:192.168.1.1:8000",
-	Args: cobra.MinimumNArgs(2),
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeAcceptedDevice,
 	Run: func(c *cobra.Command, args []string) {
 		cmd, err := NewPortForwardCmd(c, args)
 		CheckErr(err)
@@ -87,6 +88,7 @@ type portMapping struct {
 
 // PortForwardCmd handles the port-forward command
 type PortForwardCmd struct {
+	ctx          context.Context
 	server       string
 	token        string
 	skipVerify   bool
@@ -178,6 +180,7 @@ func NewPortForwardCmd(cmd *cobra.Command, args []string) (*PortForwardCmd, erro
 	}
 
 	return &PortForwardCmd{
+		ctx:          cmd.Context(),
 		server:       server,
 		token:        token,
 		skipVerify:   skipVerify,
@@ -205,7 +208,7 @@ func (c *PortForwardCmd) run() error {
 	client := deviceconnect.NewClient(c.server, c.token, c.skipVerify)
 
 	// check if the device is connected
-	device, err := client.GetDevice(c.deviceID)
+	device, err := client.GetDevice(c.ctx, c.deviceID)
 	if err != nil {
 		return errors.Wrap(err, "unable to get the device")
 	} else if device.Status != deviceconnect.CONNECTED {
@@ -213,7 +216,8 @@ func (c *PortForwardCmd) run() error {
 	}
 
 	// connect to the websocket and start the ping-pong connection health-check
-	err = client.Connect(c.deviceID, c.token)
+	fmt.Fprintf(os.Stderr, "Connecting to the device %s...\n", c.deviceID)
+	err = client.Connect(c.ctx, c.deviceID, c.token)
 	if err != nil {
 		return err
 	}
@@ -14,32 +14,50 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"golang.org/x/sys/unix"
 
+	"github.com/mendersoftware/mender-cli/client"
 	"github.com/mendersoftware/mender-cli/log"
 )
 
 var Version string
 
 const (
-	argRootServer     = "server"
-	argRootSkipVerify = "skip-verify"
-	argRootToken      = "token"
-	argRootTokenValue = "token-value"
-	argRootVerbose    = "verbose"
-	argRootGenerate   = "generate-autocomplete"
-	argRootVersion    = "version"
+	argRootServer         = "server"
+	argRootSkipVerify     = "skip-verify"
+	argRootCACert         = "ca-cert"
+	argRootClientCert     = "client-cert"
+	argRootClientKey      = "client-key"
+	argRootProxy          = "proxy"
+	argRootConnectTimeout = "connect-timeout"
+	argRootReadTimeout    = "read-timeout"
+	argRootKeepAlive      = "keep-alive"
+	argRootRetries        = "max-retries"
+	argRootRetryMaxDelay  = "retry-max-delay"
+	argRootRetryUnsafe    = "retry-non-idempotent"
+	argRootToken          = "token"
+	argRootTokenValue     = "token-value"
+	argRootVerbose        = "verbose"
+	argRootOutput         = "output"
+	argRootTraceFile      = "trace-file"
+	argRootGenerate       = "generate-autocomplete"
+	argRootVersion        = "version"
 )
 
 func init() {
-	viper.SetConfigName(".mender-clirc")
+	viper.SetConfigName(configFileName)
 	viper.SetConfigType("json")
 	viper.AddConfigPath("/etc/mender-cli/")
 	viper.AddConfigPath("$HOME/")
@@ -49,7 +67,7 @@ func init() {
 			log.Info(fmt.Sprintf("Failed to read config: %s", err))
 			os.Exit(1)
 		} else {
-			log.Info("Configuration file not found. Continuing.")
+			fmt.Fprintln(os.Stderr, "Configuration file not found. Continuing.")
 		}
 	} else {
 		fmt.Fprintf(os.Stderr, "Using configuration file: %s\n", viper.ConfigFileUsed())
@@ -69,8 +87,14 @@ var rootCmd = &cobra.Command{
 		if verbose {
 			log.Verb("verbose output is ON")
 		}
+		// the config commands must work even if the configuration is
+		// broken, so that it can be fixed
+		if err := applyConfig(cmd); err != nil && !isConfigCmd(cmd) {
+			CheckErr(err)
+		}
+		validateConfiguration()
+		CheckErr(startTrace(cmd))
 	},
-	ValidArgs: []string{"artifacts", "help", "login"},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -84,7 +108,7 @@ func Execute() {
 	}
 	b, _ := rootCmd.Flags().GetBool(argRootGenerate)
 	if b {
-		err := rootCmd.GenBashCompletionFile("./autocomplete/autocomplete.sh")
+		err := rootCmd.GenBashCompletionFileV2("./autocomplete/autocomplete.sh", true)
 		if err != nil {
 			log.Errf("Failed to generate the Bash autocompletion scripts: %s\n", err)
 		}
@@ -100,7 +124,28 @@ func Execute() {
 		fmt.Printf("mender-cli version %s\n", Version)
 		os.Exit(0)
 	}
-	CheckErr(rootCmd.Execute())
+	if path, globalFlags, args, ok := findPluginCommand(os.Args[1:]); ok {
+		CheckErr(runPlugin(path, globalFlags, args))
+	}
+	CheckErr(rootCmd.ExecuteContext(interruptContext()))
+	CheckErr(writeTrace())
+}
+
+// interruptContext returns the context of the commands, canceled on the
+// first interrupt so that the requests in flight stop; a command which
+// doesn't stop in time, e.g. reading the standard input, is terminated
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, unix.SIGINT, unix.SIGTERM)
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		cancel()
+		time.Sleep(interruptGracePeriod)
+		CheckErr(context.Canceled)
+	}()
+	return ctx
 }
 
 func validateConfiguration() {
@@ -108,7 +153,7 @@ func validateConfiguration() {
 	u, _ := url.Parse(server)
 	if u.Scheme == "" {
 		viper.Set(argRootServer, "https://"+server)
-		log.Info("Protocol is not specified, HTTPS is used by default.")
+		fmt.Fprintln(os.Stderr, "Protocol is not specified, HTTPS is used by default.")
 	}
 }
 
@@ -126,17 +171,53 @@ func init() {
 	_ = viper.BindPFlag(argRootServer, rootCmd.PersistentFlags().Lookup(argRootServer))
 	rootCmd.PersistentFlags().
 		BoolP(argRootSkipVerify, "k", false, "skip SSL certificate verification")
+	rootCmd.PersistentFlags().StringP(argRootCACert, "", "",
+		"CA certificate file (PEM) to trust in addition to the system ones")
+	rootCmd.PersistentFlags().StringP(argRootClientCert, "", "",
+		"client certificate file (PEM) for mutual TLS")
+	rootCmd.PersistentFlags().StringP(argRootClientKey, "", "",
+		"private key file (PEM) of the client certificate")
+	rootCmd.PersistentFlags().StringP(argRootProxy, "", "",
+		"HTTP, HTTPS or SOCKS5 proxy URL, e.g. 'http://proxy:3128' "+
+			"(default from HTTPS_PROXY, HTTP_PROXY and NO_PROXY)")
+	rootCmd.PersistentFlags().DurationP(argRootConnectTimeout, "", client.DefaultConnectTimeout,
+		"timeout for connecting to the server")
+	rootCmd.PersistentFlags().DurationP(argRootReadTimeout, "", 0,
+		"timeout for waiting for the server response; 0 means no timeout")
+	rootCmd.PersistentFlags().DurationP(argRootKeepAlive, "", client.DefaultKeepAlive,
+		"keep-alive period of the connections; 0 disables the keep-alives")
+	rootCmd.PersistentFlags().IntP(argRootRetries, "", client.DefaultRetries,
+		"number of retries after a transient failure; 0 disables the retries")
+	rootCmd.PersistentFlags().DurationP(argRootRetryMaxDelay, "", client.DefaultRetryMaxDelay,
+		"maximum delay between the retries")
+	rootCmd.PersistentFlags().BoolP(argRootRetryUnsafe, "", false,
+		"retry also the requests which are not idempotent, e.g. POST")
 	rootCmd.PersistentFlags().StringP(argRootToken, "", "", "JWT token file path")
 	rootCmd.PersistentFlags().StringP(argRootTokenValue, "", "", "JWT token value (API key)")
 	rootCmd.PersistentFlags().BoolP(argRootVerbose, "v", false, "print verbose output")
+	rootCmd.PersistentFlags().StringP(argRootContext, "", "",
+		"name of the context (server profile) to use instead of the current one")
+	_ = viper.BindPFlag(configCurrentContext, rootCmd.PersistentFlags().Lookup(argRootContext))
+	rootCmd.PersistentFlags().StringP(argRootOutput, "o", outputText,
+		"output format of the list and show commands: "+strings.Join(outputFormats, ", "))
+	rootCmd.PersistentFlags().StringP(argRootTraceFile, "", "",
+		"record the HTTP requests and responses, and the websocket messages, "+
+			"in this HAR file; the credentials are redacted")
 	rootCmd.Flags().Bool(argRootVersion, false, "print version")
 	rootCmd.Flags().Bool(argRootGenerate, false, "generate shell completion script")
 	_ = rootCmd.Flags().MarkHidden(argRootGenerate)
 	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
 	rootCmd.AddCommand(artifactsCmd)
+	rootCmd.AddCommand(deploymentsCmd)
 	rootCmd.AddCommand(devicesCmd)
+	rootCmd.AddCommand(groupsCmd)
 	rootCmd.AddCommand(terminalCmd)
 	rootCmd.AddCommand(portForwardCmd)
 	rootCmd.AddCommand(fileTransferCmd)
-	validateConfiguration()
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(tokensCmd)
+	rootCmd.AddCommand(tenantsCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(pluginCmd)
 }
@@ -0,0 +1,82 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var tenantSwitchCmd = &cobra.Command{
+	Use:   "switch [flags] TENANT_ID|NAME",
+	Short: "Log in to another tenant.",
+	Long: "Log in to another tenant.\n\n" +
+		"A new token is issued for the tenant and saved in place of the current\n" +
+		"one, so that the following commands operate on the chosen tenant. The\n" +
+		"credentials are asked for again, unless given by the flags or the\n" +
+		"configuration.",
+	Args: cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewTenantSwitchCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	addCredentialFlags(tenantSwitchCmd)
+}
+
+type TenantSwitchCmd struct {
+	login  *LoginCmd
+	tenant *useradm.Tenant
+}
+
+func NewTenantSwitchCmd(cmd *cobra.Command, args []string) (*TenantSwitchCmd, error) {
+	login, err := NewLoginCmd(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tenants are looked up with the current token
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+	client := useradm.NewClient(login.server, login.skipVerify)
+	tenants, err := client.ListTenants(cmd.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := useradm.FindTenant(tenants, args[0])
+	if err != nil {
+		return nil, err
+	}
+	login.tenant = tenant.ID
+
+	return &TenantSwitchCmd{
+		login:  login,
+		tenant: tenant,
+	}, nil
+}
+
+func (c *TenantSwitchCmd) Run() error {
+	if err := c.login.Run(); err != nil {
+		return err
+	}
+	log.Infof("switched to tenant %s (%s)\n", c.tenant.Name, c.tenant.ID)
+	return nil
+}
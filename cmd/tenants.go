@@ -0,0 +1,29 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var tenantsCmd = &cobra.Command{
+	Use:       "tenants",
+	Short:     "Operations on the tenants of the user.",
+	ValidArgs: []string{"list", "switch"},
+}
+
+func init() {
+	tenantsCmd.AddCommand(tenantsListCmd)
+	tenantsCmd.AddCommand(tenantSwitchCmd)
+}
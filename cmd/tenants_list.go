@@ -0,0 +1,140 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+)
+
+var tenantsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Get a list of the tenants you can log in to.",
+	Args:  cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewTenantsListCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type TenantsListCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	output     string
+}
+
+func NewTenantsListCmd(cmd *cobra.Command, args []string) (*TenantsListCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TenantsListCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		output:     output,
+	}, nil
+}
+
+func (c *TenantsListCmd) Run() error {
+	client := useradm.NewClient(c.server, c.skipVerify)
+	list, err := client.ListTenants(c.ctx, c.token)
+	if err != nil {
+		return err
+	}
+
+	// the tenant of the token in use
+	current := ""
+	if claims, err := useradm.ParseToken(c.token); err == nil {
+		current = claims.Tenant
+	}
+	tenants := make(tenantList, len(list))
+	for i, t := range list {
+		tenants[i] = tenant{Tenant: t, Current: t.ID == current}
+	}
+	return printOutput(os.Stdout, c.output, 0, tenants)
+}
+
+type tenant struct {
+	useradm.Tenant
+	Current bool `json:"current"`
+}
+
+type tenantList []tenant
+
+func (l tenantList) printText(w io.Writer, detailLevel int) {
+	for _, t := range l {
+		fmt.Fprintf(w, "ID: %s\n", t.ID)
+		fmt.Fprintf(w, "Name: %s\n", t.Name)
+		if t.Status != "" {
+			fmt.Fprintf(w, "Status: %s\n", t.Status)
+		}
+		if t.Current {
+			fmt.Fprintln(w, "Current: yes")
+		}
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l tenantList) header(wide bool) []string {
+	header := []string{"CURRENT", "ID", "NAME"}
+	if wide {
+		header = append(header, "STATUS")
+	}
+	return header
+}
+
+func (l tenantList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, t := range l {
+		current := ""
+		if t.Current {
+			current = "*"
+		}
+		row := []string{current, t.ID, t.Name}
+		if wide {
+			row = append(row, t.Status)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
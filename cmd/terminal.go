@@ -60,7 +60,8 @@ var terminalCmd = &cobra.Command{
 		"session with the remote device. The session can be saved locally " +
 		"using --record flag. When using --playback flag, no DEVICE_ID is " +
 		"required and no connection will be established.",
-	Args: cobra.RangeArgs(0, 1),
+	Args:              cobra.RangeArgs(0, 1),
+	ValidArgsFunction: completeAcceptedDevice,
 	Run: func(c *cobra.Command, args []string) {
 		cmd, err := NewTerminalCmd(c, args)
 		CheckErr(err)
@@ -76,6 +77,7 @@ func init() {
 
 // TerminalCmd handles the terminal command
 type TerminalCmd struct {
+	ctx                context.Context
 	server             string
 	token              string
 	skipVerify         bool
@@ -159,6 +161,7 @@ func NewTerminalCmd(cmd *cobra.Command, args []string) (*TerminalCmd, error) {
 	}
 
 	return &TerminalCmd{
+		ctx:                cmd.Context(),
 		server:             server,
 		token:              token,
 		skipVerify:         skipVerify,
@@ -343,7 +346,7 @@ func (c *TerminalCmd) Run() error {
 	client := deviceconnect.NewClient(c.server, c.token, c.skipVerify)
 
 	// check if the device is connected
-	device, err := client.GetDevice(c.deviceID)
+	device, err := client.GetDevice(c.ctx, c.deviceID)
 	if err != nil {
 		return errors.Wrap(err, "unable to get the device")
 	} else if device.Status != deviceconnect.CONNECTED {
@@ -351,7 +354,8 @@ func (c *TerminalCmd) Run() error {
 	}
 
 	// connect to the websocket and start the ping-pong connection health-check
-	err = client.Connect(c.deviceID, c.token)
+	fmt.Fprintf(os.Stderr, "Connecting to the device %s...\n", c.deviceID)
+	err = client.Connect(c.ctx, c.deviceID, c.token)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,176 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+const (
+	argTokenName    = "name"
+	argTokenExpires = "expires"
+	argTokenSave    = "save"
+)
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create [flags]",
+	Short: "Create a personal access token.",
+	Long: "Create a personal access token.\n\n" +
+		"The token is printed, or with --save written to the token file of the\n" +
+		"current context (or the --token path), so that the following commands\n" +
+		"use it. The token can't be retrieved from the server afterwards.",
+	Example: "  mender-cli tokens create --name ci-runner --expires 90d --context ci --save",
+	Args:    cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewTokenCreateCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+func init() {
+	tokenCreateCmd.Flags().StringP(argTokenName, "", "", "name of the token")
+	tokenCreateCmd.Flags().StringP(argTokenExpires, "", "30d",
+		"validity of the token, e.g. 12h, 90d or 1y")
+	tokenCreateCmd.Flags().BoolP(argTokenSave, "", false,
+		"save the token to the token file instead of printing it")
+	_ = tokenCreateCmd.MarkFlagRequired(argTokenName)
+}
+
+type TokenCreateCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	name       string
+	expiresIn  time.Duration
+	save       bool
+	tokenPath  string
+}
+
+func NewTokenCreateCmd(cmd *cobra.Command, args []string) (*TokenCreateCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cmd.Flags().GetString(argTokenName)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, errors.New("the token name must not be empty")
+	}
+
+	expires, err := cmd.Flags().GetString(argTokenExpires)
+	if err != nil {
+		return nil, err
+	}
+	expiresIn, err := parseExpiry(expires)
+	if err != nil {
+		return nil, err
+	}
+
+	save, err := cmd.Flags().GetBool(argTokenSave)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPath := ""
+	if save {
+		tokenPath, err = cmd.Flags().GetString(argRootToken)
+		if err != nil {
+			return nil, err
+		}
+		if tokenPath == "" {
+			tokenPath, err = getDefaultAuthTokenPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenCreateCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		name:       name,
+		expiresIn:  expiresIn,
+		save:       save,
+		tokenPath:  tokenPath,
+	}, nil
+}
+
+func (c *TokenCreateCmd) Run() error {
+	client := useradm.NewClient(c.server, c.skipVerify)
+	pat, err := client.CreateToken(c.ctx, c.name, c.expiresIn, c.token)
+	if err != nil {
+		return err
+	}
+
+	if !c.save {
+		fmt.Println(pat)
+		return nil
+	}
+	if err := writeTokenFile(c.tokenPath, []byte(pat)); err != nil {
+		return err
+	}
+	log.Infof("token %s saved to %s\n", c.name, c.tokenPath)
+	return nil
+}
+
+// parseExpiry parses a duration, accepting days (d) and years (y) in
+// addition to the units of time.ParseDuration
+func parseExpiry(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"y": 365 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n <= 0 {
+				return 0, errors.Errorf("invalid expiry %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Second {
+		return 0, errors.Errorf("invalid expiry %q, e.g. 12h, 90d or 1y", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/log"
+)
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke [flags] TOKEN_ID|NAME [TOKEN_ID|NAME...]",
+	Short: "Revoke personal access tokens.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewTokenRevokeCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type TokenRevokeCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	tokens     []string
+}
+
+func NewTokenRevokeCmd(cmd *cobra.Command, args []string) (*TokenRevokeCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenRevokeCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		tokens:     args,
+	}, nil
+}
+
+func (c *TokenRevokeCmd) Run() error {
+	client := useradm.NewClient(c.server, c.skipVerify)
+	list, err := client.ListTokens(c.ctx, c.token)
+	if err != nil {
+		return err
+	}
+
+	// resolve all the tokens first, so that nothing is revoked on a typo
+	ids := make([]string, 0, len(c.tokens))
+	for _, idOrName := range c.tokens {
+		id, err := findToken(list, idOrName)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	for i, id := range ids {
+		if err := client.RevokeToken(c.ctx, id, c.token); err != nil {
+			return err
+		}
+		log.Infof("token %s revoked\n", c.tokens[i])
+	}
+	return nil
+}
+
+// findToken returns the ID of the token with the given ID or name
+func findToken(list []useradm.PersonalAccessToken, idOrName string) (string, error) {
+	for _, t := range list {
+		if t.ID == idOrName {
+			return t.ID, nil
+		}
+	}
+	id := ""
+	for _, t := range list {
+		if t.Name == idOrName {
+			if id != "" {
+				return "", errors.Errorf("several tokens are named %q, use the ID", idOrName)
+			}
+			id = t.ID
+		}
+	}
+	if id == "" {
+		return "", errors.Errorf("token %q not found", idOrName)
+	}
+	return id, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/howeyc/gopass"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// the encrypted token file holds this prefix followed by the base64
+	// encoded salt, nonce and sealed token
+	encryptedTokenPrefix = "mender-cli-encrypted-token:v1:"
+
+	// environment variable holding the passphrase or key material
+	tokenPassphraseEnv = configEnvPrefix + "TOKEN_PASSPHRASE"
+
+	tokenSaltSize = 16
+	tokenKeySize  = 32
+
+	// scrypt parameters, as recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var errNoPassphrase = errors.New("the token is encrypted; set " + tokenPassphraseEnv +
+	" or run the command in a terminal to enter the passphrase")
+
+func isEncryptedToken(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedTokenPrefix))
+}
+
+func tokenKey(passphrase, salt []byte) ([]byte, error) {
+	return scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, tokenKeySize)
+}
+
+func newTokenCipher(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := tokenKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptToken seals the token with AES-GCM, using a key derived from the
+// passphrase with scrypt
+func encryptToken(token, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, tokenSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newTokenCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := append(salt, nonce...)
+	sealed = aead.Seal(sealed, nonce, token, []byte(encryptedTokenPrefix))
+	return []byte(encryptedTokenPrefix + base64.StdEncoding.EncodeToString(sealed) + "\n"),
+		nil
+}
+
+// decryptToken opens a token sealed by encryptToken
+func decryptToken(data, passphrase []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(
+		string(bytes.TrimSpace(bytes.TrimPrefix(data, []byte(encryptedTokenPrefix)))))
+	if err != nil {
+		return nil, errors.Wrap(err, "the encrypted token is corrupted")
+	}
+	if len(sealed) < tokenSaltSize {
+		return nil, errors.New("the encrypted token is corrupted")
+	}
+	salt := sealed[:tokenSaltSize]
+	aead, err := newTokenCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[tokenSaltSize:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("the encrypted token is corrupted")
+	}
+	token, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():],
+		[]byte(encryptedTokenPrefix))
+	if err != nil {
+		return nil, errors.New("cannot decrypt the token, wrong passphrase?")
+	}
+	return token, nil
+}
+
+// noPassphrasePrompt keeps the commands the shell runs in the background,
+// i.e. the completion of the arguments, from prompting for the passphrase
+var noPassphrasePrompt bool
+
+// tokenPassphrase returns the passphrase from the environment, or prompts
+// for it; a new passphrase is asked twice
+func tokenPassphrase(confirm bool) ([]byte, error) {
+	if passphrase, ok := os.LookupEnv(tokenPassphraseEnv); ok {
+		if passphrase == "" {
+			return nil, errors.Errorf("%s is empty", tokenPassphraseEnv)
+		}
+		return []byte(passphrase), nil
+	}
+	if noPassphrasePrompt || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, errNoPassphrase
+	}
+
+	fmt.Fprint(os.Stderr, "Token passphrase: ")
+	passphrase, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("the passphrase must not be empty")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat the passphrase: ")
+		again, err := gopass.GetPasswdMasked()
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, errors.New("the passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// sealTokenFile returns the token file contents: the token encrypted if
+// the encrypted token store is enabled, the plain token otherwise
+func sealTokenFile(token []byte) ([]byte, error) {
+	if !viper.GetBool(configKeyEncryptToken) {
+		return token, nil
+	}
+	passphrase, err := tokenPassphrase(true)
+	if err != nil {
+		return nil, err
+	}
+	return encryptToken(token, passphrase)
+}
+
+// openTokenFile returns the token from the token file contents, decrypting
+// it if needed
+func openTokenFile(data []byte) ([]byte, error) {
+	if !isEncryptedToken(data) {
+		return data, nil
+	}
+	passphrase, err := tokenPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	return decryptToken(data, passphrase)
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestEncryptToken(t *testing.T) {
+	token := []byte("header.claims.signature")
+	passphrase := []byte("secret")
+	data, err := encryptToken(token, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncryptedToken(data) || bytes.Contains(data, token) {
+		t.Fatalf("the token is not encrypted: %s", data)
+	}
+	if again, _ := encryptToken(token, passphrase); bytes.Equal(again, data) {
+		t.Error("the token was encrypted twice the same")
+	}
+	got, err := decryptToken(data, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, token) {
+		t.Errorf("expected the token %q, got %q", token, got)
+	}
+
+	sealed := data[len(encryptedTokenPrefix):]
+	tampered := append([]byte{}, data...)
+	tampered[len(encryptedTokenPrefix)+30] ^= 1
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase []byte
+	}{
+		{name: "wrong passphrase", data: data, passphrase: []byte("other")},
+		{name: "tampered", data: tampered, passphrase: passphrase},
+		{
+			name:       "truncated",
+			data:       []byte(encryptedTokenPrefix + string(sealed[:20])),
+			passphrase: passphrase,
+		},
+		{name: "no salt", data: []byte(encryptedTokenPrefix + "YWJj"), passphrase: passphrase},
+		{name: "not base64", data: []byte(encryptedTokenPrefix + "!!!"), passphrase: passphrase},
+	}
+	for _, tc := range tests {
+		if _, err := decryptToken(tc.data, tc.passphrase); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+func TestOpenTokenFile(t *testing.T) {
+	token := []byte("header.claims.signature")
+	data, err := encryptToken(token, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := openTokenFile(token); err != nil || !bytes.Equal(got, token) {
+		t.Errorf("the plain token was not kept: %q, %v", got, err)
+	}
+
+	t.Setenv(tokenPassphraseEnv, "secret")
+	if got, err := openTokenFile(data); err != nil || !bytes.Equal(got, token) {
+		t.Errorf("the token was not decrypted: %q, %v", got, err)
+	}
+
+	t.Setenv(tokenPassphraseEnv, "")
+	if _, err := openTokenFile(data); err == nil {
+		t.Error("expected an error with an empty passphrase")
+	}
+
+	// the tests don't run in a terminal, there's no prompt
+	old := noPassphrasePrompt
+	noPassphrasePrompt = true
+	defer func() { noPassphrasePrompt = old }()
+	os.Unsetenv(tokenPassphraseEnv)
+	if _, err := openTokenFile(data); !errors.Is(err, errNoPassphrase) {
+		t.Errorf("expected %v, got %v", errNoPassphrase, err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+)
+
+var tokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Get a list of your personal access tokens from the Mender server.",
+	Args:  cobra.NoArgs,
+	Run: func(c *cobra.Command, args []string) {
+		cmd, err := NewTokensListCmd(c, args)
+		CheckErr(err)
+		CheckErr(cmd.Run())
+	},
+}
+
+type TokensListCmd struct {
+	ctx        context.Context
+	server     string
+	skipVerify bool
+	token      string
+	output     string
+}
+
+func NewTokensListCmd(cmd *cobra.Command, args []string) (*TokensListCmd, error) {
+	server := viper.GetString(argRootServer)
+	if server == "" {
+		return nil, errors.New("No server")
+	}
+
+	skipVerify, err := cmd.Flags().GetBool(argRootSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAuthToken(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokensListCmd{
+		ctx:        cmd.Context(),
+		server:     server,
+		token:      token,
+		skipVerify: skipVerify,
+		output:     output,
+	}, nil
+}
+
+func (c *TokensListCmd) Run() error {
+	client := useradm.NewClient(c.server, c.skipVerify)
+	list, err := client.ListTokens(c.ctx, c.token)
+	if err != nil {
+		return err
+	}
+	return printOutput(os.Stdout, c.output, 0, tokenList(list))
+}
+
+type tokenList []useradm.PersonalAccessToken
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (l tokenList) printText(w io.Writer, detailLevel int) {
+	for _, t := range l {
+		fmt.Fprintf(w, "ID: %s\n", t.ID)
+		fmt.Fprintf(w, "Name: %s\n", t.Name)
+		fmt.Fprintf(w, "Created: %s\n", formatOptionalTime(t.CreatedTs))
+		fmt.Fprintf(w, "Expires: %s\n", formatOptionalTime(t.ExpirationDate))
+		fmt.Fprintf(w, "Last used: %s\n", formatOptionalTime(t.LastUsed))
+		fmt.Fprintln(w, textSeparator)
+	}
+}
+
+func (l tokenList) header(wide bool) []string {
+	header := []string{"ID", "NAME", "EXPIRES"}
+	if wide {
+		header = append(header, "CREATED", "LAST USED")
+	}
+	return header
+}
+
+func (l tokenList) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(l))
+	for _, t := range l {
+		row := []string{t.ID, t.Name, formatOptionalTime(t.ExpirationDate)}
+		if wide {
+			row = append(row, formatOptionalTime(t.CreatedTs), formatOptionalTime(t.LastUsed))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+func TestTraceFile(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	srv.AddArtifact(deployments.Artifact{Name: "release-1"}, []byte("data"))
+	path := filepath.Join(t.TempDir(), "trace.har")
+	resetFlags(t, argRootTraceFile)
+
+	runCommand(t, srv, "artifacts", "list", "--"+argRootTraceFile, path)
+	if err := writeTrace(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method  string `json:"method"`
+					URL     string `json:"url"`
+					Headers []struct {
+						Name  string `json:"name"`
+						Value string `json:"value"`
+					} `json:"headers"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("invalid trace: %s", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("expected one entry, got %s", data)
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.URL != srv.URL+"/api/management/v1/deployments/artifacts" ||
+		entry.Response.Status != 200 || entry.Response.Content.Text == "" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	for _, h := range entry.Request.Headers {
+		if h.Name == "Authorization" && h.Value != "REDACTED" {
+			t.Errorf("the token is not redacted: %s", h.Value)
+		}
+	}
+}
@@ -14,6 +14,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -21,16 +22,105 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/log"
 )
 
+const (
+	// the exit code of the shells for a command killed by SIGINT
+	exitCodeInterrupted = 130
+
+	// how long an interrupted command has to stop
+	interruptGracePeriod = 2 * time.Second
+)
+
+// exitError is an error which terminates the command with a specific
+// exit code instead of the default one
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 func CheckErr(e error) {
+	if errors.Is(e, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "FAILURE: interrupted")
+		exit(exitCodeInterrupted)
+	}
 	if e != nil {
 		fmt.Fprintf(os.Stderr, "FAILURE: %s\n", e.Error())
-		os.Exit(1)
+		var exitErr *exitError
+		if errors.As(e, &exitErr) {
+			exit(exitErr.code)
+		}
+		exit(1)
+	}
+}
+
+// exit exits with the code, writing the trace first, as the failures are
+// what it is recorded for
+func exit(code int) {
+	if err := writeTrace(); err != nil {
+		fmt.Fprintf(os.Stderr, "FAILURE: %s\n", err.Error())
+	}
+	os.Exit(code)
+}
+
+// the file the trace is written to on exit, if any; an interrupted command
+// may exit while the main goroutine exits too
+var (
+	traceFileMutex sync.Mutex
+	traceFile      *os.File
+)
+
+// startTrace starts recording the trace if --trace-file is given; the file
+// is created right away, so that a wrong path fails before any request
+func startTrace(cmd *cobra.Command) error {
+	traceFileMutex.Lock()
+	defer traceFileMutex.Unlock()
+	path, err := cmd.Flags().GetString(argRootTraceFile)
+	if err != nil || path == "" || traceFile != nil {
+		return err
+	}
+	// the trace holds the data of the user, though not the credentials
+	traceFile, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "cannot create the trace file")
+	}
+	client.StartTrace("mender-cli", Version)
+	return nil
+}
+
+// writeTrace writes the recorded trace to the trace file, once
+func writeTrace() error {
+	traceFileMutex.Lock()
+	defer traceFileMutex.Unlock()
+	if traceFile == nil {
+		return nil
+	}
+	f := traceFile
+	traceFile = nil
+	err := client.WriteTrace(f)
+	client.StopTrace()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
+	return errors.Wrapf(err, "cannot write the trace to %s", f.Name())
 }
 
 func migrateAuthToken(oldtoken string, token string) {
@@ -55,7 +145,9 @@ func migrateAuthToken(oldtoken string, token string) {
 	_ = os.Remove(filepath.Dir(oldtoken)) // err on non-empty, ignore.
 }
 
-func getDefaultAuthTokenPath() (string, error) {
+// userDirs returns the home directory of the user and their cache
+// directory
+func userDirs() (string, string, error) {
 	cachedir := ""
 	userhomedir := ""
 
@@ -64,7 +156,7 @@ func getDefaultAuthTokenPath() (string, error) {
 	} else if user, err := user.Current(); err == nil {
 		userhomedir = user.HomeDir
 	} else {
-		return "", errors.New("Not able to determine users cache dir")
+		return "", "", errors.New("Not able to determine users cache dir")
 	}
 
 	if cachehomeenv := os.Getenv("XDG_CACHE_HOME"); cachehomeenv != "" {
@@ -72,6 +164,19 @@ func getDefaultAuthTokenPath() (string, error) {
 	} else {
 		cachedir = path.Join(userhomedir, ".cache")
 	}
+	return userhomedir, cachedir, nil
+}
+
+func getDefaultAuthTokenPath() (string, error) {
+	userhomedir, cachedir, err := userDirs()
+	if err != nil {
+		return "", err
+	}
+
+	// each context keeps its own token
+	if token, err := getContextAuthTokenPath(cachedir); err != nil || token != "" {
+		return token, err
+	}
 
 	oldtoken := filepath.Join(userhomedir, ".mender", "authtoken")
 	token := filepath.Join(cachedir, "mender", "authtoken")
@@ -82,35 +187,124 @@ func getDefaultAuthTokenPath() (string, error) {
 }
 
 func getAuthToken(cmd *cobra.Command) (string, error) {
-	tokenValue, err := cmd.Flags().GetString(argRootTokenValue)
+	token, _, err := readAuthToken(cmd)
 	if err != nil {
 		return "", err
 	}
+	warnTokenExpiry(token)
+	return token, nil
+}
+
+// environment variable holding the token value, e.g. set for the plugins
+const tokenValueEnv = configEnvPrefix + "TOKEN_VALUE"
+
+// readAuthToken returns the token and the file it was read from, which is
+// empty if the token is given on the command line or in the environment
+func readAuthToken(cmd *cobra.Command) (string, string, error) {
+	tokenValue, err := cmd.Flags().GetString(argRootTokenValue)
+	if err != nil {
+		return "", "", err
+	}
 	tokenPath, err := cmd.Flags().GetString(argRootToken)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	if tokenValue != "" && tokenPath != "" {
-		return "", fmt.Errorf("cannot specify both --%s and --%s",
+	// the token path may also come from the configuration, which the token
+	// value given on the command line overrides
+	if tokenValue != "" && cmd.Flags().Changed(argRootToken) {
+		return "", "", fmt.Errorf("cannot specify both --%s and --%s",
 			argRootTokenValue, argRootToken)
 	}
 
+	if tokenValue == "" && !cmd.Flags().Changed(argRootToken) {
+		tokenValue = os.Getenv(tokenValueEnv)
+	}
 	if tokenValue != "" {
-		return tokenValue, nil
+		return tokenValue, "", nil
 	}
 
 	if tokenPath == "" {
 		tokenPath, err = getDefaultAuthTokenPath()
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 	}
 
-	token, err := ioutil.ReadFile(tokenPath)
+	data, err := ioutil.ReadFile(tokenPath)
 	if err != nil {
-		return "", errors.Wrap(err, "Please Login first")
+		return "", "", errors.Wrap(err, "Please Login first")
+	}
+	token, err := openTokenFile(data)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "cannot read the token from %s", tokenPath)
 	}
 	tokenValue = strings.TrimSpace(string(token))
-	return tokenValue, nil
+	return tokenValue, tokenPath, nil
+}
+
+// tokenExpiryWarning returns how long before the expiry of the token to
+// warn about it; zero disables the warning
+func tokenExpiryWarning() time.Duration {
+	value := viper.GetString(configKeyTokenExpiryWarning)
+	if value == "0" {
+		return 0
+	}
+	window, err := parseExpiry(value)
+	if err != nil {
+		log.Verbf("invalid %s: %s\n", configKeyTokenExpiryWarning, err)
+		return 0
+	}
+	return window
+}
+
+// warnTokenExpiry warns if the token has expired or expires soon; tokens
+// which aren't JWTs are not checked
+func warnTokenExpiry(token string) {
+	window := tokenExpiryWarning()
+	if window == 0 {
+		return
+	}
+	claims, err := useradm.ParseToken(token)
+	if err != nil {
+		log.Verbf("not checking the token expiry: %s\n", err)
+		return
+	}
+	left, ok := claims.ExpiresIn()
+	if !ok || left > window {
+		return
+	}
+	if left <= 0 {
+		log.Errf("WARNING: the token expired at %s, run mender-cli login\n",
+			claims.ExpiresAt.Format(time.RFC3339))
+		return
+	}
+	log.Errf("WARNING: the token expires in %s (%s), run mender-cli login to renew it\n",
+		left.Round(time.Minute), claims.ExpiresAt.Format(time.RFC3339))
+}
+
+// writeTokenFile saves the token, readable by the user only and encrypted
+// if the encrypted token store is enabled
+func writeTokenFile(tokenPath string, t []byte) error {
+	dir := filepath.Dir(tokenPath)
+	log.Verbf("creating directory: %v\n", dir)
+
+	err := os.MkdirAll(dir, os.ModeDir|0700)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dir)
+	}
+
+	t, err = sealTokenFile(t)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(tokenPath, t, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file %s", tokenPath)
+	}
+
+	log.Verb("saved token to: " + tokenPath)
+
+	return nil
 }
@@ -0,0 +1,52 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"bytes"
+
+	"github.com/mendersoftware/mender-artifact/artifact"
+	"github.com/mendersoftware/mender-artifact/awriter"
+	"github.com/mendersoftware/mender-artifact/handlers"
+	"github.com/pkg/errors"
+)
+
+// ArtifactUpdateType is the update module of the artifacts NewArtifact
+// writes
+const ArtifactUpdateType = "fakeserver"
+
+// NewArtifact returns a valid artifact, with no payload files, for the
+// device types, to upload to the server
+func NewArtifact(name string, deviceTypes ...string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := awriter.NewWriter(&buf, artifact.NewCompressorNone())
+	err := w.WriteArtifact(&awriter.WriteArtifactArgs{
+		Format:  "mender",
+		Version: 3,
+		Devices: deviceTypes,
+		Name:    name,
+		Updates: &awriter.Updates{
+			Updates: []handlers.Composer{handlers.NewModuleImage(ArtifactUpdateType)},
+		},
+		Provides: &artifact.ArtifactProvides{ArtifactName: name},
+		Depends:  &artifact.ArtifactDepends{CompatibleDevices: deviceTypes},
+		TypeInfoV3: &artifact.TypeInfoV3{
+			Type: ArtifactUpdateType,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot write the artifact")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,656 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mendersoftware/mender-artifact/areader"
+	"github.com/pkg/errors"
+
+	"github.com/mendersoftware/mender-cli/client/deployments"
+)
+
+const (
+	artifactsPath    = "/api/management/v1/deployments/artifacts"
+	directUploadPath = artifactsPath + "/directupload"
+	deploymentsPath  = "/api/management/v1/deployments/deployments"
+
+	// the pre-signed links point to the storage, which needs no token
+	storageArtifactsPath = "/storage/artifacts"
+	storageUploadsPath   = "/storage/uploads"
+
+	// UploadIDHeader is the header the pre-signed upload links require,
+	// so that the tests check the clients send the link headers
+	UploadIDHeader = "X-Fakeserver-Upload-Id"
+
+	linkLifetime = time.Hour
+
+	deploymentStatusPending    = "pending"
+	deploymentStatusInProgress = "inprogress"
+)
+
+// the device deployment statuses, all of which the statistics hold
+var deviceStatuses = []string{
+	"pending",
+	"downloading",
+	"pause_before_installing",
+	"installing",
+	"pause_before_committing",
+	"rebooting",
+	"pause_before_rebooting",
+	"success",
+	"already-installed",
+	"noartifact",
+	"failure",
+	"aborted",
+	"decommissioned",
+}
+
+var deviceStatusFinal = map[string]bool{
+	"success":           true,
+	"already-installed": true,
+	"noartifact":        true,
+	"failure":           true,
+	"aborted":           true,
+	"decommissioned":    true,
+}
+
+type storedArtifact struct {
+	deployments.Artifact
+	data []byte
+}
+
+type directUpload struct {
+	data     []byte
+	uploaded bool
+}
+
+type deployment struct {
+	deployments.Deployment
+	devices []*deploymentDevice
+}
+
+type deploymentDevice struct {
+	deployments.DeploymentDevice
+	log string
+}
+
+func (s *Server) deploymentsRoutes() {
+	s.handle(http.MethodGet, artifactsPath, s.listArtifacts)
+	s.handle(http.MethodPost, artifactsPath, s.uploadArtifact)
+	s.handle(http.MethodGet, artifactsPath+"/:id", s.getArtifact)
+	s.handle(http.MethodDelete, artifactsPath+"/:id", s.deleteArtifact)
+	s.handle(http.MethodGet, artifactsPath+"/:id/download", s.artifactDownloadLink)
+	s.handle(http.MethodPost, directUploadPath, s.directUploadLink)
+	s.handle(http.MethodPost, directUploadPath+"/:id/complete", s.completeDirectUpload)
+	s.handlePublic(http.MethodGet, storageArtifactsPath+"/:id", s.downloadFromStorage)
+	s.handlePublic(http.MethodPut, storageUploadsPath+"/:id", s.uploadToStorage)
+
+	s.handle(http.MethodGet, deploymentsPath, s.listDeployments)
+	s.handle(http.MethodPost, deploymentsPath, s.createDeployment)
+	s.handle(http.MethodPost, deploymentsPath+"/group/:name", s.createDeployment)
+	s.handle(http.MethodGet, deploymentsPath+"/:id", s.getDeployment)
+	s.handle(http.MethodPut, deploymentsPath+"/:id/status", s.abortDeployment)
+	s.handle(http.MethodGet, deploymentsPath+"/:id/statistics", s.deploymentStatistics)
+	s.handle(http.MethodGet, deploymentsPath+"/:id/devices", s.listDeploymentDevices)
+	s.handle(http.MethodGet, deploymentsPath+"/:id/devices/:device/log", s.deploymentDeviceLog)
+}
+
+// AddArtifact adds the artifact with the file contents, which don't need
+// to be a valid artifact; the ID and the size are set if they are empty.
+// It returns the artifact ID
+func (s *Server) AddArtifact(a deployments.Artifact, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addArtifact(a, data).ID
+}
+
+func (s *Server) addArtifact(a deployments.Artifact, data []byte) *storedArtifact {
+	if a.ID == "" {
+		a.ID = uuid.NewString()
+	}
+	if a.Size == 0 {
+		a.Size = int64(len(data))
+	}
+	if a.Modified.IsZero() {
+		a.Modified = time.Now().UTC()
+	}
+	stored := &storedArtifact{Artifact: a, data: data}
+	s.artifacts = append(s.artifacts, stored)
+	return stored
+}
+
+// Artifacts returns the artifacts on the server
+func (s *Server) Artifacts() []deployments.Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]deployments.Artifact, 0, len(s.artifacts))
+	for _, a := range s.artifacts {
+		list = append(list, a.Artifact)
+	}
+	return list
+}
+
+// ArtifactData returns the file contents of the artifact
+func (s *Server) ArtifactData(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.findArtifact(id); a != nil {
+		return a.data, true
+	}
+	return nil, false
+}
+
+func (s *Server) findArtifact(id string) *storedArtifact {
+	for _, a := range s.artifacts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// parseArtifact reads the artifact metadata from the header of the file,
+// as the server does on upload
+func parseArtifact(data []byte) (*deployments.Artifact, error) {
+	ar := areader.NewReader(bytes.NewReader(data))
+	if err := ar.ReadArtifactHeaders(); err != nil {
+		return nil, errors.Wrap(err, "cannot parse the artifact file")
+	}
+	info := ar.GetInfo()
+	a := &deployments.Artifact{
+		Name:                  ar.GetArtifactName(),
+		DeviceTypesCompatible: ar.GetCompatibleDevices(),
+		Info:                  deployments.ArtifactInfo{Format: info.Format, Version: info.Version},
+		Signed:                ar.IsSigned,
+		ArtifactProvides:      deployments.ArtifactProvides{ArtifactName: ar.GetArtifactName()},
+		ArtifactDepends: deployments.ArtifactDepends{
+			DeviceType: ar.GetCompatibleDevices(),
+		},
+		Size: int64(len(data)),
+	}
+	for _, u := range ar.GetUpdates() {
+		updateType := u.Type
+		a.Updates = append(a.Updates, deployments.Update{
+			TypeInfo: deployments.ArtifactUpdateTypeInfo{Type: &updateType},
+		})
+	}
+	return a, nil
+}
+
+// storeArtifact adds the uploaded artifact unless one with the same name
+// and a common device type exists already; it returns the status code
+func (s *Server) storeArtifact(a *deployments.Artifact, data []byte) (int, string) {
+	for _, other := range s.artifacts {
+		if other.Name == a.Name &&
+			overlap(other.DeviceTypesCompatible, a.DeviceTypesCompatible) {
+			return http.StatusConflict, "Artifact not unique"
+		}
+	}
+	s.addArtifact(*a, data)
+	return http.StatusCreated, ""
+}
+
+func overlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) listArtifacts(w http.ResponseWriter, _ *http.Request, _ params) {
+	writeJSON(w, http.StatusOK, s.Artifacts())
+}
+
+func (s *Server) uploadArtifact(w http.ResponseWriter, r *http.Request, _ params) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var description string
+	var data []byte
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch part.FormName() {
+		case "description":
+			description = string(value)
+		case "artifact":
+			data = value
+		}
+	}
+	if data == nil {
+		writeError(w, http.StatusBadRequest, "artifact: cannot be blank.")
+		return
+	}
+	a, err := parseArtifact(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	a.ID = uuid.NewString()
+	a.Description = description
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, message := s.storeArtifact(a, data); status != http.StatusCreated {
+		writeError(w, status, message)
+		return
+	}
+	w.Header().Set("Location", artifactsPath+"/"+a.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) getArtifact(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.findArtifact(p["id"])
+	if a == nil {
+		writeError(w, http.StatusNotFound, "Artifact not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, a.Artifact)
+}
+
+func (s *Server) deleteArtifact(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.artifacts {
+		if a.ID == p["id"] {
+			s.artifacts = append(s.artifacts[:i], s.artifacts[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Artifact not found")
+}
+
+func (s *Server) artifactDownloadLink(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findArtifact(p["id"]) == nil {
+		writeError(w, http.StatusNotFound, "Artifact not found")
+		return
+	}
+	expire := time.Now().Add(linkLifetime).UTC()
+	writeJSON(w, http.StatusOK, deployments.DownloadLink{
+		Uri: fmt.Sprintf("%s%s/%s?expires=%d",
+			s.URL, storageArtifactsPath, p["id"], expire.Unix()),
+		Expire: expire,
+	})
+}
+
+func (s *Server) downloadFromStorage(w http.ResponseWriter, r *http.Request, p params) {
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		writeError(w, http.StatusForbidden, "Request has expired")
+		return
+	}
+	data, ok := s.ArtifactData(p["id"])
+	if !ok {
+		writeError(w, http.StatusNotFound, "The specified key does not exist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.mender-artifact")
+	w.Header().Set("ETag", fmt.Sprintf("\"%x\"", sha256.Sum256(data)))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (s *Server) directUploadLink(w http.ResponseWriter, _ *http.Request, _ params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.NewString()
+	s.uploads[id] = &directUpload{}
+	writeJSON(w, http.StatusOK, deployments.UploadLink{
+		ArtifactID: id,
+		Link: deployments.Link{
+			Uri:    s.URL + storageUploadsPath + "/" + id,
+			Header: map[string]string{UploadIDHeader: id},
+		},
+	})
+}
+
+func (s *Server) uploadToStorage(w http.ResponseWriter, r *http.Request, p params) {
+	if r.Header.Get(UploadIDHeader) != p["id"] {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[p["id"]]
+	if !ok {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+	upload.data = data
+	upload.uploaded = true
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) completeDirectUpload(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[p["id"]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "upload intent not found")
+		return
+	}
+	if !upload.uploaded {
+		writeError(w, http.StatusConflict, "artifact not uploaded")
+		return
+	}
+	delete(s.uploads, p["id"])
+
+	a, err := parseArtifact(upload.data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	a.ID = p["id"]
+	if status, message := s.storeArtifact(a, upload.data); status != http.StatusCreated {
+		writeError(w, status, message)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Deployments returns the deployments, the newest first
+func (s *Server) Deployments() []deployments.Deployment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]deployments.Deployment, 0, len(s.deployments))
+	for i := len(s.deployments) - 1; i >= 0; i-- {
+		list = append(list, s.deployments[i].Deployment)
+	}
+	return list
+}
+
+// DeploymentDevices returns the devices of the deployment and their status
+func (s *Server) DeploymentDevices(id string) []deployments.DeploymentDevice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(id)
+	if d == nil {
+		return nil
+	}
+	return d.deviceList()
+}
+
+// SetDeploymentDeviceStatus moves the device of the deployment to the
+// status, as if the device reported it, and keeps the log if it's not
+// empty; the deployment finishes once all the devices have finished
+func (s *Server) SetDeploymentDeviceStatus(deploymentID, deviceID, status, log string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(deploymentID)
+	if d == nil {
+		panic("fakeserver: no deployment " + deploymentID)
+	}
+	for _, dev := range d.devices {
+		if dev.ID == deviceID {
+			dev.setStatus(status)
+			if log != "" {
+				dev.log = log
+				dev.Log = true
+			}
+			d.updateStatus()
+			return
+		}
+	}
+	panic("fakeserver: no device " + deviceID + " in deployment " + deploymentID)
+}
+
+func (s *Server) findDeployment(id string) *deployment {
+	for _, d := range s.deployments {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (d *deployment) deviceList() []deployments.DeploymentDevice {
+	list := make([]deployments.DeploymentDevice, 0, len(d.devices))
+	for _, dev := range d.devices {
+		list = append(list, dev.DeploymentDevice)
+	}
+	return list
+}
+
+func (dev *deploymentDevice) setStatus(status string) {
+	dev.Status = status
+	if deviceStatusFinal[status] && dev.Finished == nil {
+		now := time.Now().UTC()
+		dev.Finished = &now
+	}
+}
+
+func (d *deployment) updateStatus() {
+	finished := 0
+	for _, dev := range d.devices {
+		if deviceStatusFinal[dev.Status] {
+			finished++
+		} else if dev.Status != deploymentStatusPending {
+			d.Status = deploymentStatusInProgress
+		}
+	}
+	if finished == len(d.devices) && d.Finished == nil {
+		now := time.Now().UTC()
+		d.Status = deployments.DeploymentStatusFinished
+		d.Finished = &now
+	} else if finished > 0 {
+		d.Status = deploymentStatusInProgress
+	}
+}
+
+func (s *Server) listDeployments(w http.ResponseWriter, r *http.Request, _ params) {
+	status := r.URL.Query().Get("status")
+	list := []deployments.Deployment{}
+	for _, d := range s.Deployments() {
+		if status == "" || d.Status == status {
+			list = append(list, d)
+		}
+	}
+	start, end, ok := paginate(w, r, deploymentsPath, len(list))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, list[start:end])
+}
+
+func (s *Server) createDeployment(w http.ResponseWriter, r *http.Request, p params) {
+	var req deployments.NewDeployment
+	if !readJSON(w, r, &req) {
+		return
+	}
+	req.Group = p["name"]
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for _, a := range s.artifacts {
+		found = found || a.Name == req.ArtifactName
+	}
+	if !found {
+		writeError(w, http.StatusUnprocessableEntity, "No artifact for the deployment")
+		return
+	}
+
+	deviceIDs := req.Devices
+	if req.AllDevices {
+		deviceIDs = s.acceptedDeviceIDs()
+	} else if req.Group != "" {
+		deviceIDs = s.groupDeviceIDs(req.Group)
+	}
+	if len(deviceIDs) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "No devices for the deployment")
+		return
+	}
+
+	d := &deployment{Deployment: deployments.Deployment{
+		ID:           uuid.NewString(),
+		Name:         req.Name,
+		ArtifactName: req.ArtifactName,
+		Created:      time.Now().UTC(),
+		Status:       deploymentStatusPending,
+		DeviceCount:  len(deviceIDs),
+		Type:         "software",
+	}}
+	if req.Group != "" {
+		d.Groups = []string{req.Group}
+	}
+	for _, id := range deviceIDs {
+		created := d.Created
+		dev := &deploymentDevice{DeploymentDevice: deployments.DeploymentDevice{
+			ID:      id,
+			Status:  deploymentStatusPending,
+			Created: &created,
+		}}
+		if inv, ok := s.inventory[id]; ok {
+			if deviceType, ok := inv.Attribute("inventory", "device_type"); ok {
+				dev.DeviceType = fmt.Sprint(deviceType)
+			}
+		}
+		d.devices = append(d.devices, dev)
+	}
+	s.deployments = append(s.deployments, d)
+	w.Header().Set("Location", deploymentsPath+"/"+d.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) getDeployment(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "Deployment not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, d.Deployment)
+}
+
+func (s *Server) abortDeployment(w http.ResponseWriter, r *http.Request, p params) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Status != deployments.DeploymentStatusAborted {
+		writeError(w, http.StatusBadRequest, "status: must be a valid value.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "Deployment not found")
+		return
+	}
+	if d.Status == deployments.DeploymentStatusFinished {
+		writeError(w, http.StatusUnprocessableEntity, "Deployment already finished")
+		return
+	}
+	for _, dev := range d.devices {
+		if !deviceStatusFinal[dev.Status] {
+			dev.setStatus(deployments.DeploymentStatusAborted)
+		}
+	}
+	d.updateStatus()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deploymentStatistics(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "Deployment not found")
+		return
+	}
+	stats := deployments.DeploymentStatistics{}
+	for _, status := range deviceStatuses {
+		stats[status] = 0
+	}
+	for _, dev := range d.devices {
+		stats[dev.Status]++
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) listDeploymentDevices(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "Deployment not found")
+		return
+	}
+	list := d.deviceList()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) deploymentDeviceLog(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "Deployment not found")
+		return
+	}
+	for _, dev := range d.devices {
+		if dev.ID == p["device"] && dev.Log {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(dev.log))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Deployment log not found")
+}
@@ -0,0 +1,361 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+const (
+	devauthDevicesPath = "/api/management/v2/devauth/devices"
+
+	deviceStatusPreauthorized = "preauthorized"
+
+	// the public key of the auth sets added without one
+	fakePubKey = "-----BEGIN PUBLIC KEY-----\nZmFrZXNlcnZlcg==\n-----END PUBLIC KEY-----\n"
+)
+
+func (s *Server) devauthRoutes() {
+	s.handle(http.MethodGet, devauthDevicesPath, s.listDevices)
+	s.handle(http.MethodPost, devauthDevicesPath, s.preauthorizeDevice)
+	s.handle(http.MethodGet, devauthDevicesPath+"/:id", s.getDevice)
+	s.handle(http.MethodDelete, devauthDevicesPath+"/:id", s.decommissionDevice)
+	s.handle(http.MethodDelete, devauthDevicesPath+"/:id/auth/:aid", s.dismissAuthSet)
+	s.handle(http.MethodPut, devauthDevicesPath+"/:id/auth/:aid/status", s.setAuthSetStatus)
+}
+
+// AddDevice adds the device, with an inventory holding its identity, and
+// returns the device ID. The ID, the timestamps and the status, which is
+// pending, are set if they are empty, and so is an auth set with the
+// status of the device if there is none
+func (s *Server) AddDevice(d devices.Device) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addDevice(d).ID
+}
+
+func (s *Server) addDevice(d devices.Device) *devices.Device {
+	now := time.Now().UTC().Format(time.RFC3339)
+	if d.ID == "" {
+		d.ID = uuid.NewString()
+	}
+	if d.Status == "" {
+		d.Status = devices.AuthSetStatusPending
+	}
+	if d.CreatedTs == "" {
+		d.CreatedTs = now
+	}
+	if d.UpdatedTs == "" {
+		d.UpdatedTs = now
+	}
+	if len(d.AuthSets) == 0 {
+		d.AuthSets = []devices.AuthSet{{
+			ID:           uuid.NewString(),
+			PubKey:       fakePubKey,
+			IdentityData: d.IdentityData,
+			Status:       d.Status,
+			Ts:           d.CreatedTs,
+		}}
+	}
+	device := &d
+	s.devices = append(s.devices, device)
+
+	inv := &devices.InventoryDevice{ID: d.ID, UpdatedTs: now}
+	for name, value := range identityMap(d.IdentityData) {
+		inv.Attributes = append(inv.Attributes, devices.InventoryAttribute{
+			Name:  name,
+			Scope: devices.ScopeIdentity,
+			Value: value,
+		})
+	}
+	s.inventory[d.ID] = inv
+	return device
+}
+
+// Devices returns the devices known to the device authentication
+func (s *Server) Devices() []devices.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]devices.Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		list = append(list, *d)
+	}
+	return list
+}
+
+// Device returns the device with the ID
+func (s *Server) Device(id string) (devices.Device, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := s.findDevice(id); d != nil {
+		return *d, true
+	}
+	return devices.Device{}, false
+}
+
+func (s *Server) findDevice(id string) *devices.Device {
+	for _, d := range s.devices {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (s *Server) acceptedDeviceIDs() []string {
+	ids := []string{}
+	for _, d := range s.devices {
+		if d.Status == devices.AuthSetStatusAccepted {
+			ids = append(ids, d.ID)
+		}
+	}
+	return ids
+}
+
+func (s *Server) removeDevice(id string) {
+	for i, d := range s.devices {
+		if d.ID == id {
+			s.devices = append(s.devices[:i], s.devices[i+1:]...)
+			break
+		}
+	}
+	delete(s.inventory, id)
+	delete(s.connected, id)
+	delete(s.deviceFiles, id)
+	for _, d := range s.deployments {
+		for _, dev := range d.devices {
+			if dev.ID == id && !deviceStatusFinal[dev.Status] {
+				dev.setStatus("decommissioned")
+			}
+		}
+		d.updateStatus()
+	}
+}
+
+func identityMap(identity devices.IdentityData) map[string]string {
+	m := map[string]string{}
+	for name := range identity {
+		if value := identity.Get(name); value != "" {
+			m[name] = value
+		}
+	}
+	return m
+}
+
+// deviceStatus derives the status of the device from its auth sets
+func deviceStatus(d *devices.Device) string {
+	status := devices.AuthSetStatusRejected
+	for _, a := range d.AuthSets {
+		switch a.Status {
+		case devices.AuthSetStatusAccepted, deviceStatusPreauthorized:
+			return a.Status
+		case devices.AuthSetStatusPending:
+			status = devices.AuthSetStatusPending
+		}
+	}
+	return status
+}
+
+func (s *Server) listDevices(w http.ResponseWriter, r *http.Request, _ params) {
+	list := []devices.Device{}
+	for _, d := range s.Devices() {
+		if status := r.URL.Query().Get("status"); status == "" || d.Status == status {
+			list = append(list, d)
+		}
+	}
+	start, end, ok := paginate(w, r, devauthDevicesPath, len(list))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, list[start:end])
+}
+
+// paginate returns the range of the list of the given length on the page
+// asked for, and adds the Link headers of the pages; it writes the error
+// response on bad page queries
+func paginate(w http.ResponseWriter, r *http.Request, path string, length int) (int, int, bool) {
+	q := r.URL.Query()
+	page, perPage := 1, 20
+	var err error
+	if v := q.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			writeError(w, http.StatusBadRequest, "invalid page query")
+			return 0, 0, false
+		}
+	}
+	if v := q.Get("per_page"); v != "" {
+		if perPage, err = strconv.Atoi(v); err != nil || perPage < 1 {
+			writeError(w, http.StatusBadRequest, "invalid per_page query")
+			return 0, 0, false
+		}
+	}
+
+	start := (page - 1) * perPage
+	if start > length {
+		start = length
+	}
+	end := start + perPage
+	if end > length {
+		end = length
+	}
+
+	link := func(page int, rel string) string {
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(perPage))
+		u := url.URL{Path: path, RawQuery: q.Encode()}
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+	w.Header().Add("Link", link(1, "first"))
+	if end < length {
+		w.Header().Add("Link", link(page+1, "next"))
+	}
+	return start, end, true
+}
+
+func (s *Server) getDevice(w http.ResponseWriter, _ *http.Request, p params) {
+	d, ok := s.Device(p["id"])
+	if !ok {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+type preauthRequest struct {
+	IdentityData devices.IdentityData `json:"identity_data"`
+	PubKey       string               `json:"pubkey"`
+}
+
+func (s *Server) preauthorizeDevice(w http.ResponseWriter, r *http.Request, _ params) {
+	var req preauthRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if len(identityMap(req.IdentityData)) == 0 || req.PubKey == "" {
+		writeError(w, http.StatusBadRequest, "identity_data and pubkey: cannot be blank.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.devices {
+		if reflect.DeepEqual(identityMap(d.IdentityData), identityMap(req.IdentityData)) {
+			writeError(w, http.StatusConflict, "device already exists")
+			return
+		}
+	}
+	s.addDevice(devices.Device{
+		IdentityData: req.IdentityData,
+		Status:       deviceStatusPreauthorized,
+		AuthSets: []devices.AuthSet{{
+			ID:           uuid.NewString(),
+			PubKey:       req.PubKey,
+			IdentityData: req.IdentityData,
+			Status:       deviceStatusPreauthorized,
+			Ts:           time.Now().UTC().Format(time.RFC3339),
+		}},
+	})
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) decommissionDevice(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findDevice(p["id"]) == nil {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	}
+	s.removeDevice(p["id"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) dismissAuthSet(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDevice(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	}
+	for i, a := range d.AuthSets {
+		if a.ID != p["aid"] {
+			continue
+		}
+		d.AuthSets = append(d.AuthSets[:i], d.AuthSets[i+1:]...)
+		if len(d.AuthSets) == 0 {
+			s.removeDevice(d.ID)
+		} else {
+			d.Status = deviceStatus(d)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeError(w, http.StatusNotFound, "authentication set not found")
+}
+
+func (s *Server) setAuthSetStatus(w http.ResponseWriter, r *http.Request, p params) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	switch req.Status {
+	case devices.AuthSetStatusAccepted, devices.AuthSetStatusRejected,
+		devices.AuthSetStatusPending:
+	default:
+		writeError(w, http.StatusBadRequest, "status: must be a valid value.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDevice(p["id"])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	}
+	target := -1
+	for i, a := range d.AuthSets {
+		if a.ID == p["aid"] {
+			target = i
+		}
+	}
+	if target < 0 {
+		writeError(w, http.StatusNotFound, "authentication set not found")
+		return
+	}
+	for i := range d.AuthSets {
+		a := &d.AuthSets[i]
+		if i == target {
+			a.Status = req.Status
+		} else if req.Status == devices.AuthSetStatusAccepted &&
+			a.Status == devices.AuthSetStatusAccepted {
+			// only one auth set of a device is accepted at a time
+			a.Status = devices.AuthSetStatusRejected
+		}
+	}
+	d.Status = deviceStatus(d)
+	d.UpdatedTs = time.Now().UTC().Format(time.RFC3339)
+	w.WriteHeader(http.StatusNoContent)
+}
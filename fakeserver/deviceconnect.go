@@ -0,0 +1,534 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/mendersoftware/go-lib-micro/ws"
+	wspf "github.com/mendersoftware/go-lib-micro/ws/portforward"
+	wsshell "github.com/mendersoftware/go-lib-micro/ws/shell"
+	"github.com/vmihailenco/msgpack"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+const (
+	deviceconnectPath = "/api/management/v1/deviceconnect/devices"
+
+	deviceConnected    = "connected"
+	deviceDisconnected = "disconnected"
+
+	writeWait   = 10 * time.Second
+	dialTimeout = 10 * time.Second
+)
+
+// ShellHandler runs a remote terminal session of the device: it reads the
+// input of the user from stdin and writes the output to stdout. stdin is
+// closed when the user stops the session, and the session stops when the
+// handler returns
+type ShellHandler func(deviceID string, stdin io.Reader, stdout io.Writer) error
+
+// EchoShell is a ShellHandler writing the input back
+func EchoShell(_ string, stdin io.Reader, stdout io.Writer) error {
+	_, err := io.Copy(stdout, stdin)
+	return err
+}
+
+// File is a file on a device
+type File struct {
+	Data []byte
+	Mode os.FileMode
+}
+
+func (s *Server) deviceconnectRoutes() {
+	s.handle(http.MethodGet, deviceconnectPath+"/:id", s.getConnectDevice)
+	s.handle(http.MethodGet, deviceconnectPath+"/:id/connect", s.connectDevice)
+	s.handle(http.MethodPut, deviceconnectPath+"/:id/upload", s.uploadDeviceFile)
+	s.handle(http.MethodGet, deviceconnectPath+"/:id/download", s.downloadDeviceFile)
+}
+
+// SetConnected connects or disconnects the device; the accepted devices
+// are connected unless set otherwise
+func (s *Server) SetConnected(deviceID string, connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected[deviceID] = connected
+}
+
+func (s *Server) isConnected(deviceID string) bool {
+	d := s.findDevice(deviceID)
+	if d == nil {
+		return false
+	}
+	if connected, ok := s.connected[deviceID]; ok {
+		return connected
+	}
+	return d.Status == devices.AuthSetStatusAccepted
+}
+
+// AddDeviceFile puts the file on the device at the path
+func (s *Server) AddDeviceFile(deviceID, path string, f File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deviceFiles[deviceID] == nil {
+		s.deviceFiles[deviceID] = map[string]*File{}
+	}
+	s.deviceFiles[deviceID][path] = &f
+}
+
+// DeviceFile returns the file at the path on the device
+func (s *Server) DeviceFile(deviceID, path string) (File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.deviceFiles[deviceID][path]
+	if !ok {
+		return File{}, false
+	}
+	return *f, true
+}
+
+// Close closes the device sessions and shuts the server down
+func (s *Server) Close() {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = nil
+	s.mu.Unlock()
+	for _, session := range sessions {
+		session.conn.Close()
+	}
+	s.Server.Close()
+}
+
+func (s *Server) getConnectDevice(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findDevice(p["id"]) == nil {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	}
+	status := deviceDisconnected
+	if s.isConnected(p["id"]) {
+		status = deviceConnected
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id": p["id"], "status": status})
+}
+
+func (s *Server) uploadDeviceFile(w http.ResponseWriter, r *http.Request, p params) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	path := r.FormValue("path")
+	if path == "" {
+		writeError(w, http.StatusBadRequest, "path: cannot be blank.")
+		return
+	}
+	mode := uint64(0644)
+	if v := r.FormValue("mode"); v != "" {
+		var err error
+		if mode, err = strconv.ParseUint(v, 8, 32); err != nil {
+			writeError(w, http.StatusBadRequest, "mode: must be an octal number.")
+			return
+		}
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file: cannot be blank.")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	connected := s.isConnected(p["id"])
+	s.mu.Unlock()
+	if !connected {
+		writeError(w, http.StatusConflict, "device not connected")
+		return
+	}
+	s.AddDeviceFile(p["id"], path, File{Data: data, Mode: os.FileMode(mode)})
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) downloadDeviceFile(w http.ResponseWriter, r *http.Request, p params) {
+	path := r.URL.Query().Get("path")
+	s.mu.Lock()
+	connected := s.isConnected(p["id"])
+	s.mu.Unlock()
+	if !connected {
+		writeError(w, http.StatusConflict, "device not connected")
+		return
+	}
+	f, ok := s.DeviceFile(p["id"], path)
+	if !ok {
+		writeError(w, http.StatusBadRequest,
+			fmt.Sprintf("failed to open file %s: no such file or directory", path))
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("X-MEN-FILE-PATH", path)
+	w.Header().Set("X-MEN-FILE-MODE", fmt.Sprintf("%o", f.Mode.Perm()))
+	w.Header().Set("X-MEN-FILE-SIZE", strconv.Itoa(len(f.Data)))
+	_, _ = w.Write(f.Data)
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// deviceSession is the websocket connection of a user to a device, on
+// which the fake device runs the shell and the port forwarding
+type deviceSession struct {
+	server   *Server
+	deviceID string
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+
+	shellID    string
+	shellInput *io.PipeWriter
+	shellMu    sync.Mutex
+
+	forwardID string
+	forwards  map[string]net.Conn
+	forwardMu sync.Mutex
+}
+
+func (s *Server) connectDevice(w http.ResponseWriter, r *http.Request, p params) {
+	s.mu.Lock()
+	exists := s.findDevice(p["id"]) != nil
+	connected := s.isConnected(p["id"])
+	s.mu.Unlock()
+	if !exists {
+		writeError(w, http.StatusNotFound, "device not found")
+		return
+	} else if !connected {
+		writeError(w, http.StatusConflict, "device not connected")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader wrote the error response already
+		return
+	}
+	session := &deviceSession{
+		server:   s,
+		deviceID: p["id"],
+		conn:     conn,
+		forwards: map[string]net.Conn{},
+	}
+	s.mu.Lock()
+	s.sessions = append(s.sessions, session)
+	s.mu.Unlock()
+
+	session.run()
+
+	s.mu.Lock()
+	for i, other := range s.sessions {
+		if other == session {
+			s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
+}
+
+func (d *deviceSession) run() {
+	defer d.close()
+	for {
+		_, data, err := d.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		m := &ws.ProtoMsg{}
+		if err := msgpack.Unmarshal(data, m); err != nil {
+			return
+		}
+		switch m.Header.Proto {
+		case ws.ProtoTypeControl:
+			if !d.handleControl(m) {
+				return
+			}
+		case ws.ProtoTypeShell:
+			d.handleShell(m)
+		case ws.ProtoTypePortForward:
+			d.handlePortForward(m)
+		}
+	}
+}
+
+func (d *deviceSession) close() {
+	d.stopShell("")
+	d.forwardMu.Lock()
+	for id, conn := range d.forwards {
+		conn.Close()
+		delete(d.forwards, id)
+	}
+	d.forwardMu.Unlock()
+	d.conn.Close()
+}
+
+func (d *deviceSession) write(m *ws.ProtoMsg) {
+	data, err := msgpack.Marshal(m)
+	if err != nil {
+		return
+	}
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	_ = d.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	_ = d.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// handleControl handles the session control messages; it returns false
+// once the session is closed
+func (d *deviceSession) handleControl(m *ws.ProtoMsg) bool {
+	switch m.Header.MsgType {
+	case ws.MessageTypeOpen:
+		d.forwardID = uuid.NewString()
+		body, _ := msgpack.Marshal(ws.Accept{
+			Version: ws.ProtocolVersion,
+			Protocols: []ws.ProtoType{
+				ws.ProtoTypeShell,
+				ws.ProtoTypeFileTransfer,
+				ws.ProtoTypePortForward,
+			},
+		})
+		d.write(&ws.ProtoMsg{
+			Header: ws.ProtoHdr{
+				Proto:     ws.ProtoTypeControl,
+				MsgType:   ws.MessageTypeAccept,
+				SessionID: d.forwardID,
+			},
+			Body: body,
+		})
+	case ws.MessageTypePing:
+		d.write(&ws.ProtoMsg{
+			Header: ws.ProtoHdr{
+				Proto:     ws.ProtoTypeControl,
+				MsgType:   ws.MessageTypePong,
+				SessionID: m.Header.SessionID,
+			},
+		})
+	case ws.MessageTypeClose:
+		return false
+	}
+	return true
+}
+
+// shellOutput sends what the shell writes to the user
+type shellOutput struct {
+	session   *deviceSession
+	sessionID string
+}
+
+func (o *shellOutput) Write(p []byte) (int, error) {
+	o.session.write(&ws.ProtoMsg{
+		Header: ws.ProtoHdr{
+			Proto:     ws.ProtoTypeShell,
+			MsgType:   wsshell.MessageTypeShellCommand,
+			SessionID: o.sessionID,
+		},
+		Body: append([]byte{}, p...),
+	})
+	return len(p), nil
+}
+
+func (d *deviceSession) handleShell(m *ws.ProtoMsg) {
+	switch m.Header.MsgType {
+	case wsshell.MessageTypeSpawnShell:
+		d.shellMu.Lock()
+		running := d.shellInput != nil
+		d.shellMu.Unlock()
+		if running {
+			d.write(&ws.ProtoMsg{
+				Header: ws.ProtoHdr{
+					Proto:   ws.ProtoTypeShell,
+					MsgType: wsshell.MessageTypeSpawnShell,
+					Properties: map[string]interface{}{
+						"status": int64(wsshell.ErrorMessage),
+					},
+				},
+				Body: []byte("a shell session is running already"),
+			})
+			return
+		}
+		sessionID := uuid.NewString()
+		stdin, input := io.Pipe()
+		d.shellMu.Lock()
+		d.shellID = sessionID
+		d.shellInput = input
+		d.shellMu.Unlock()
+		d.write(&ws.ProtoMsg{
+			Header: ws.ProtoHdr{
+				Proto:     ws.ProtoTypeShell,
+				MsgType:   wsshell.MessageTypeSpawnShell,
+				SessionID: sessionID,
+				Properties: map[string]interface{}{
+					"status": int64(wsshell.NormalMessage),
+				},
+			},
+		})
+		go func() {
+			_ = d.server.ShellHandler(d.deviceID, stdin,
+				&shellOutput{session: d, sessionID: sessionID})
+			stdin.Close()
+			d.stopShell(sessionID)
+			d.write(&ws.ProtoMsg{
+				Header: ws.ProtoHdr{
+					Proto:     ws.ProtoTypeShell,
+					MsgType:   wsshell.MessageTypeStopShell,
+					SessionID: sessionID,
+				},
+			})
+		}()
+	case wsshell.MessageTypeShellCommand:
+		d.shellMu.Lock()
+		input := d.shellInput
+		if m.Header.SessionID != d.shellID {
+			input = nil
+		}
+		d.shellMu.Unlock()
+		if input != nil {
+			_, _ = input.Write(m.Body)
+		}
+	case wsshell.MessageTypePingShell:
+		d.write(&ws.ProtoMsg{
+			Header: ws.ProtoHdr{
+				Proto:     ws.ProtoTypeShell,
+				MsgType:   wsshell.MessageTypePongShell,
+				SessionID: m.Header.SessionID,
+			},
+		})
+	case wsshell.MessageTypeStopShell:
+		d.stopShell(m.Header.SessionID)
+	}
+}
+
+// stopShell closes the input of the shell session, or of any session if
+// the ID is empty
+func (d *deviceSession) stopShell(sessionID string) {
+	d.shellMu.Lock()
+	defer d.shellMu.Unlock()
+	if d.shellInput != nil && (sessionID == "" || sessionID == d.shellID) {
+		d.shellInput.Close()
+		d.shellInput = nil
+		d.shellID = ""
+	}
+}
+
+func (d *deviceSession) forwardMessage(msgType, connectionID string, body []byte) *ws.ProtoMsg {
+	return &ws.ProtoMsg{
+		Header: ws.ProtoHdr{
+			Proto:     ws.ProtoTypePortForward,
+			MsgType:   msgType,
+			SessionID: d.forwardID,
+			Properties: map[string]interface{}{
+				wspf.PropertyConnectionID: connectionID,
+			},
+		},
+		Body: body,
+	}
+}
+
+func (d *deviceSession) handlePortForward(m *ws.ProtoMsg) {
+	connectionID, _ := m.Header.Properties[wspf.PropertyConnectionID].(string)
+	switch m.Header.MsgType {
+	case wspf.MessageTypePortForwardNew:
+		if err := d.openForward(connectionID, m.Body); err != nil {
+			body, _ := msgpack.Marshal(ws.Error{
+				Error:        err.Error(),
+				MessageProto: ws.ProtoTypePortForward,
+				MessageType:  wspf.MessageTypePortForwardNew,
+			})
+			d.write(d.forwardMessage(wspf.MessageTypeError, connectionID, body))
+			d.write(d.forwardMessage(wspf.MessageTypePortForwardStop, connectionID, nil))
+		}
+	case wspf.MessageTypePortForward:
+		d.forwardMu.Lock()
+		conn, ok := d.forwards[connectionID]
+		d.forwardMu.Unlock()
+		if !ok {
+			return
+		}
+		if _, err := conn.Write(m.Body); err == nil {
+			d.write(d.forwardMessage(wspf.MessageTypePortForwardAck, connectionID, nil))
+		}
+	case wspf.MessageTypePortForwardStop:
+		d.forwardMu.Lock()
+		if conn, ok := d.forwards[connectionID]; ok {
+			conn.Close()
+			delete(d.forwards, connectionID)
+		}
+		d.forwardMu.Unlock()
+	}
+}
+
+// openForward connects to the host and port of the request and forwards
+// what it receives from there to the user until either side stops
+func (d *deviceSession) openForward(connectionID string, body []byte) error {
+	var req wspf.PortForwardNew
+	if err := msgpack.Unmarshal(body, &req); err != nil {
+		return err
+	}
+	if req.Protocol == nil || *req.Protocol != wspf.PortForwardProtocolTCP ||
+		req.RemoteHost == nil || req.RemotePort == nil {
+		return fmt.Errorf("only the TCP port forwarding is supported")
+	}
+	address := net.JoinHostPort(*req.RemoteHost, strconv.Itoa(int(*req.RemotePort)))
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return err
+	}
+	d.forwardMu.Lock()
+	d.forwards[connectionID] = conn
+	d.forwardMu.Unlock()
+
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				d.write(d.forwardMessage(wspf.MessageTypePortForward, connectionID,
+					append([]byte{}, buf[:n]...)))
+			}
+			if err != nil {
+				break
+			}
+		}
+		d.forwardMu.Lock()
+		_, open := d.forwards[connectionID]
+		delete(d.forwards, connectionID)
+		d.forwardMu.Unlock()
+		conn.Close()
+		// the user didn't stop the connection, so tell them it's closed
+		if open {
+			d.write(d.forwardMessage(wspf.MessageTypePortForwardStop, connectionID, nil))
+		}
+	}()
+	return nil
+}
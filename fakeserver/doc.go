@@ -0,0 +1,38 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+
+// Package fakeserver provides an in-process fake of the Mender server for
+// the tests of the clients and the commands, so that they don't need a
+// real backend.
+//
+// The server keeps its state in memory and implements the parts of the API
+// the clients use: the useradm login, personal access tokens and tenants,
+// the artifacts including the direct upload to the storage, the
+// deployments, the device authentication, the inventory and groups, and
+// the deviceconnect remote terminal, port forwarding and file transfer.
+// Each accepted device behaves as if it was connected: its shell runs the
+// ShellHandler, port forwarding connects to the real local host and the
+// files copied to it are kept in memory.
+//
+// The tests seed the state, run the client against URL and inspect the
+// state afterwards:
+//
+//	srv := fakeserver.New()
+//	defer srv.Close()
+//	token := srv.Token("user@example.com")
+//	id := srv.AddDevice(devices.Device{Status: "accepted"})
+//
+//	c := devices.NewClient(srv.URL, false)
+//	device, err := c.GetDevice(ctx, id, token)
+package fakeserver
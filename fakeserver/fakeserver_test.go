@@ -0,0 +1,600 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mendersoftware/go-lib-micro/ws"
+	wspf "github.com/mendersoftware/go-lib-micro/ws/portforward"
+	wsshell "github.com/mendersoftware/go-lib-micro/ws/shell"
+	"github.com/vmihailenco/msgpack"
+
+	"github.com/mendersoftware/mender-cli/client"
+	"github.com/mendersoftware/mender-cli/client/deployments"
+	"github.com/mendersoftware/mender-cli/client/deviceconnect"
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/client/useradm"
+	"github.com/mendersoftware/mender-cli/fakeserver"
+)
+
+const testUser = "user@example.com"
+
+func writeArtifact(t *testing.T, name string, deviceTypes ...string) string {
+	t.Helper()
+	data, err := fakeserver.NewArtifact(name, deviceTypes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name+".mender")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLogin(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	srv.AddUser(testUser, "secret")
+	srv.AddTenant("t1", "acme")
+	srv.AddTenant("t2", "acme-lab")
+	ctx := context.Background()
+	c := useradm.NewClient(srv.URL, false)
+
+	if _, err := c.Login(ctx, testUser, "wrong", "", ""); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	token, err := c.Login(ctx, testUser, "secret", "", "acme-lab")
+	if err == nil {
+		t.Fatal("login to a tenant by name succeeded")
+	}
+	token, err = c.Login(ctx, testUser, "secret", "", "t2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := useradm.ParseToken(string(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Tenant != "t2" || claims.ExpiresAt == nil {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	tenants, err := c.ListTenants(ctx, string(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tenants) != 2 {
+		t.Errorf("expected 2 tenants, got %v", tenants)
+	}
+
+	if err := c.Logout(ctx, string(token)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ListTenants(ctx, string(token))
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("expected the token to be rejected after logout, got %v", err)
+	}
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := useradm.NewClient(srv.URL, false)
+
+	pat, err := c.CreateToken(ctx, "ci", time.Hour, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateToken(ctx, "ci", time.Hour, token); err == nil {
+		t.Error("creating a token with the same name succeeded")
+	}
+	list, err := c.ListTokens(ctx, pat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "ci" || list[0].LastUsed == nil {
+		t.Fatalf("unexpected tokens %+v", list)
+	}
+	if err := c.RevokeToken(ctx, list[0].ID, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListTokens(ctx, pat); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("expected the revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	path := writeArtifact(t, "release-1", "rpi4")
+	if err := c.UploadArtifact(ctx, "first", path, token, nil); err != nil {
+		t.Fatal(err)
+	}
+	err := c.UploadArtifact(ctx, "again", path, token, nil)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("expected a conflict uploading the artifact again, got %v", err)
+	}
+
+	list, err := c.ListArtifacts(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "release-1" || list[0].Description != "first" ||
+		len(list[0].DeviceTypesCompatible) != 1 {
+		t.Fatalf("unexpected artifacts %+v", list)
+	}
+
+	dir := t.TempDir()
+	if err := c.DownloadArtifact(ctx, dir, list[0].ID, token, nil); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(path)
+	got, err := os.ReadFile(filepath.Join(dir, "release-1.mender"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("the downloaded artifact differs from the uploaded one")
+	}
+
+	if err := c.DeleteArtifact(ctx, list[0].ID, token); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Artifacts()) != 0 {
+		t.Error("the artifact was not deleted")
+	}
+}
+
+func TestDirectUpload(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	path := writeArtifact(t, "release-2", "rpi4", "bbb")
+	link, err := c.DirectUploadLink(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transferred int64
+	progress := func(n, _ int64) { transferred = n }
+	err = c.DirectUpload(ctx, token, path, link.ArtifactID, link.Uri, link.Header, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := c.GetArtifact(ctx, link.ArtifactID, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "release-2" || a.Size != transferred {
+		t.Errorf("unexpected artifact %+v after uploading %d bytes", a, transferred)
+	}
+
+	// the storage requires the headers of the link
+	link, err = c.DirectUploadLink(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DirectUpload(ctx, token, path, link.ArtifactID, link.Uri, nil, nil); err == nil {
+		t.Error("uploading without the link headers succeeded")
+	}
+}
+
+func TestFail(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	srv.Fail(http.MethodGet, "/api/management/v1/deployments/artifacts", http.StatusNotFound, 1)
+	if _, err := c.ListArtifacts(ctx, token); err == nil {
+		t.Error("the request didn't fail")
+	}
+	if _, err := c.ListArtifacts(ctx, token); err != nil {
+		t.Error(err)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+
+	const artifacts = "/api/management/v1/deployments/artifacts"
+	srv.Fail(http.MethodGet, artifacts, http.StatusTooManyRequests, 1)
+	rsp, err := http.Get(srv.URL + artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusTooManyRequests || rsp.Header.Get("Retry-After") != "0" {
+		t.Errorf("unexpected response %d, Retry-After %q",
+			rsp.StatusCode, rsp.Header.Get("Retry-After"))
+	}
+
+	srv.Fail(http.MethodGet, artifacts, fakeserver.ConnectionLost, 1)
+	tr := &http.Transport{DisableKeepAlives: true}
+	defer tr.CloseIdleConnections()
+	_, err = (&http.Client{Transport: tr}).Get(srv.URL + artifacts)
+	if err == nil {
+		t.Error("the connection was not closed")
+	}
+}
+
+func TestDeployments(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := deployments.NewClient(srv.URL, false)
+
+	srv.AddArtifact(deployments.Artifact{Name: "release-1"}, []byte("data"))
+	dev1 := srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+	dev2 := srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+	srv.AddDevice(devices.Device{})
+
+	id, err := c.CreateDeployment(ctx, &deployments.NewDeployment{
+		Name:         "update",
+		ArtifactName: "release-1",
+		AllDevices:   true,
+	}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := c.GetDeployment(ctx, id, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.DeviceCount != 2 || d.Status != "pending" {
+		t.Errorf("unexpected deployment %+v", d)
+	}
+
+	srv.SetDeploymentDeviceStatus(id, dev1, "success", "")
+	srv.SetDeploymentDeviceStatus(id, dev2, "failure", "it broke")
+	stats, err := c.GetDeploymentStatistics(ctx, id, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Finished() != 2 || stats.Failed() != 1 {
+		t.Errorf("unexpected statistics %v", stats)
+	}
+	log, err := c.GetDeploymentDeviceLog(ctx, id, dev2, token)
+	if err != nil || log != "it broke" {
+		t.Errorf("unexpected log %q: %v", log, err)
+	}
+	list, err := c.ListDeployments(ctx, token, deployments.ListDeploymentsOptions{
+		Status: deployments.DeploymentStatusFinished,
+	})
+	if err != nil || len(list) != 1 {
+		t.Errorf("expected the finished deployment, got %v: %v", list, err)
+	}
+	if err := c.AbortDeployment(ctx, id, token); err == nil {
+		t.Error("aborting a finished deployment succeeded")
+	}
+}
+
+func TestDevices(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := devices.NewClient(srv.URL, false)
+
+	for _, mac := range []string{"00:01", "00:02", "00:03", "00:04", "00:05"} {
+		srv.AddDevice(devices.Device{IdentityData: devices.IdentityData{devices.IdentityMac: mac}})
+	}
+	// the client follows the Link header to the following pages
+	list, err := c.ListDevices(ctx, token, devices.ListDevicesOptions{PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 5 {
+		t.Fatalf("expected 5 devices, got %d", len(list))
+	}
+
+	d := list[0]
+	err = c.SetAuthSetStatus(ctx, d.ID, d.AuthSets[0].ID, devices.AuthSetStatusAccepted, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted, err := c.ListDevices(ctx, token, devices.ListDevicesOptions{
+		Status: devices.AuthSetStatusAccepted,
+	})
+	if err != nil || len(accepted) != 1 || accepted[0].ID != d.ID {
+		t.Errorf("expected the accepted device, got %v: %v", accepted, err)
+	}
+
+	err = c.PreauthorizeDevice(ctx, map[string]string{"mac": "00:06"}, "KEY", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all the identity attributes are kept, and can filter the list
+	preauthorized, err := c.ListDevices(ctx, token, devices.ListDevicesOptions{
+		Identity: map[string]string{devices.IdentityMac: "00:06"},
+	})
+	if err != nil || len(preauthorized) != 1 {
+		t.Fatalf("expected the preauthorized device, got %v: %v", preauthorized, err)
+	}
+	err = c.PreauthorizeDevice(ctx,
+		map[string]string{"mac": "00:07", "board": "rpi4"}, "KEY", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boards, err := c.ListDevices(ctx, token, devices.ListDevicesOptions{
+		Identity: map[string]string{"board": "rpi4"},
+	})
+	if err != nil || len(boards) != 1 || boards[0].IdentityData.Get("board") != "rpi4" {
+		t.Errorf("expected the device with the board attribute, got %v: %v", boards, err)
+	}
+	err = c.PreauthorizeDevice(ctx, map[string]string{"mac": "00:06"}, "KEY", token)
+	if err == nil {
+		t.Error("preauthorizing the same device twice succeeded")
+	}
+
+	if err := c.DecommissionDevice(ctx, d.ID, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetDevice(ctx, d.ID, token); err == nil {
+		t.Error("the decommissioned device still exists")
+	}
+	if n := len(srv.Devices()); n != 6 {
+		t.Errorf("expected 6 devices, got %d", n)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	token := srv.Token(testUser)
+	ctx := context.Background()
+	c := devices.NewClient(srv.URL, false)
+
+	ids := []string{}
+	for _, deviceType := range []string{"rpi4", "rpi4", "bbb"} {
+		id := srv.AddDevice(devices.Device{})
+		srv.SetInventory(id, devices.InventoryAttribute{
+			Name:  "device_type",
+			Scope: devices.ScopeInventory,
+			Value: deviceType,
+		})
+		ids = append(ids, id)
+	}
+
+	if err := c.AddToGroup(ctx, "prod", ids[1:], token); err != nil {
+		t.Fatal(err)
+	}
+	err := c.CreateDynamicGroup(ctx, "pis", []devices.InventoryFilter{{
+		Scope:     devices.ScopeInventory,
+		Attribute: "device_type",
+		Type:      devices.FilterEqual,
+		Value:     "rpi4",
+	}}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := c.ListGroups(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	for _, g := range groups {
+		found, err := c.ListGroupDevices(ctx, &g, token, 0, 0)
+		if err != nil || len(found) != 2 {
+			t.Errorf("expected 2 devices in group %s, got %v: %v", g.Name, found, err)
+		}
+	}
+
+	if err := c.RemoveFromGroup(ctx, "prod", ids[2:], token); err != nil {
+		t.Fatal(err)
+	}
+	inv, _ := srv.Inventory(ids[2])
+	if _, ok := inv.Attribute(devices.ScopeSystem, "group"); ok {
+		t.Error("the device is still in the group")
+	}
+	for i := range groups {
+		if err := c.DeleteGroup(ctx, &groups[i], token); err != nil {
+			t.Error(err)
+		}
+	}
+	if groups, _ := c.ListGroups(ctx, token); len(groups) != 0 {
+		t.Errorf("expected no groups, got %+v", groups)
+	}
+}
+
+func connect(t *testing.T, srv *fakeserver.Server, deviceID string) *deviceconnect.Client {
+	t.Helper()
+	token := srv.Token(testUser)
+	c := deviceconnect.NewClient(srv.URL, token, false)
+	if err := c.Connect(context.Background(), deviceID, token); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func readMessage(t *testing.T, c *deviceconnect.Client, msgType string) *ws.ProtoMsg {
+	t.Helper()
+	m, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Header.MsgType != msgType {
+		t.Fatalf("expected a %q message, got %+v", msgType, m.Header)
+	}
+	return m
+}
+
+func TestShell(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	id := srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+	c := connect(t, srv, id)
+	defer c.Close()
+
+	err := c.WriteMessage(&ws.ProtoMsg{Header: ws.ProtoHdr{
+		Proto:   ws.ProtoTypeShell,
+		MsgType: wsshell.MessageTypeSpawnShell,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := readMessage(t, c, wsshell.MessageTypeSpawnShell)
+	sessionID := m.Header.SessionID
+
+	err = c.WriteMessage(&ws.ProtoMsg{
+		Header: ws.ProtoHdr{
+			Proto:     ws.ProtoTypeShell,
+			MsgType:   wsshell.MessageTypeShellCommand,
+			SessionID: sessionID,
+		},
+		Body: []byte("echo hello\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = readMessage(t, c, wsshell.MessageTypeShellCommand)
+	if string(m.Body) != "echo hello\n" {
+		t.Errorf("unexpected output %q", m.Body)
+	}
+
+	err = c.WriteMessage(&ws.ProtoMsg{Header: ws.ProtoHdr{
+		Proto:     ws.ProtoTypeShell,
+		MsgType:   wsshell.MessageTypeStopShell,
+		SessionID: sessionID,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readMessage(t, c, wsshell.MessageTypeStopShell)
+}
+
+func TestPortForward(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	id := srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+
+	// the fake device connects to the local echo server
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			_, _ = io.Copy(conn, conn)
+			conn.Close()
+		}
+	}()
+
+	c := connect(t, srv, id)
+	defer c.Close()
+	body, _ := msgpack.Marshal(ws.Open{Versions: []int{ws.ProtocolVersion}})
+	err = c.WriteMessage(&ws.ProtoMsg{
+		Header: ws.ProtoHdr{Proto: ws.ProtoTypeControl, MsgType: ws.MessageTypeOpen},
+		Body:   body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := readMessage(t, c, ws.MessageTypeAccept).Header.SessionID
+
+	host := "127.0.0.1"
+	port := uint16(l.Addr().(*net.TCPAddr).Port)
+	protocol := wspf.PortForwardProtocol(wspf.PortForwardProtocolTCP)
+	body, _ = msgpack.Marshal(wspf.PortForwardNew{
+		RemoteHost: &host,
+		RemotePort: &port,
+		Protocol:   &protocol,
+	})
+	send := func(msgType string, body []byte) {
+		t.Helper()
+		err := c.WriteMessage(&ws.ProtoMsg{
+			Header: ws.ProtoHdr{
+				Proto:      ws.ProtoTypePortForward,
+				MsgType:    msgType,
+				SessionID:  sessionID,
+				Properties: map[string]interface{}{wspf.PropertyConnectionID: "c1"},
+			},
+			Body: body,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(wspf.MessageTypePortForwardNew, body)
+	send(wspf.MessageTypePortForward, []byte("ping"))
+
+	readMessage(t, c, wspf.MessageTypePortForwardAck)
+	m := readMessage(t, c, wspf.MessageTypePortForward)
+	if string(m.Body) != "ping" {
+		t.Errorf("unexpected data %q", m.Body)
+	}
+	send(wspf.MessageTypePortForwardStop, nil)
+}
+
+func TestFileTransfer(t *testing.T) {
+	srv := fakeserver.New()
+	defer srv.Close()
+	id := srv.AddDevice(devices.Device{Status: devices.AuthSetStatusAccepted})
+	ctx := context.Background()
+	c := deviceconnect.NewFileTransferClient(srv.URL, srv.Token(testUser), false)
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "config")
+	if err := os.WriteFile(local, []byte("key=value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	spec := &deviceconnect.DeviceSpec{DeviceID: id, DevicePath: "/etc/app/config"}
+	if err := c.Upload(ctx, local, spec); err != nil {
+		t.Fatal(err)
+	}
+	f, ok := srv.DeviceFile(id, "/etc/app/config")
+	if !ok || string(f.Data) != "key=value\n" || f.Mode != 0600 {
+		t.Fatalf("unexpected file on the device %+v", f)
+	}
+
+	copied := filepath.Join(dir, "copy")
+	if err := c.Download(ctx, spec, copied); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(copied)
+	if err != nil || string(data) != "key=value\n" {
+		t.Errorf("unexpected download %q: %v", data, err)
+	}
+
+	srv.SetConnected(id, false)
+	if err := c.Download(ctx, spec, copied); err == nil {
+		t.Error("downloading from a disconnected device succeeded")
+	}
+}
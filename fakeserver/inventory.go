@@ -0,0 +1,336 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+)
+
+const (
+	inventoryDevicesPath = "/api/management/v1/inventory/devices"
+	inventoryGroupsPath  = "/api/management/v1/inventory/groups"
+	inventoryFilterPath  = "/api/management/v2/inventory/filters"
+	inventorySearchPath  = inventoryFilterPath + "/search"
+
+	groupAttribute = "group"
+)
+
+type savedFilter struct {
+	ID    string                    `json:"id"`
+	Name  string                    `json:"name"`
+	Terms []devices.InventoryFilter `json:"terms"`
+}
+
+type inventorySearch struct {
+	Page    int                       `json:"page"`
+	PerPage int                       `json:"per_page"`
+	Filters []devices.InventoryFilter `json:"filters"`
+}
+
+func (s *Server) inventoryRoutes() {
+	s.handle(http.MethodGet, inventoryDevicesPath+"/:id", s.getInventoryDevice)
+	s.handle(http.MethodPost, inventorySearchPath, s.searchInventory)
+	s.handle(http.MethodGet, inventoryGroupsPath, s.listGroups)
+	s.handle(http.MethodDelete, inventoryGroupsPath+"/:name", s.deleteGroup)
+	s.handle(http.MethodPatch, inventoryGroupsPath+"/:name/devices", s.addToGroup)
+	s.handle(http.MethodDelete, inventoryGroupsPath+"/:name/devices", s.removeFromGroup)
+	s.handle(http.MethodGet, inventoryFilterPath, s.listFilters)
+	s.handle(http.MethodPost, inventoryFilterPath, s.createFilter)
+	s.handle(http.MethodDelete, inventoryFilterPath+"/:id", s.deleteFilter)
+}
+
+// SetInventory sets the attributes of the device, replacing those with the
+// same scope and name
+func (s *Server) SetInventory(deviceID string, attrs ...devices.InventoryAttribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.inventory[deviceID]
+	if !ok {
+		inv = &devices.InventoryDevice{ID: deviceID}
+		s.inventory[deviceID] = inv
+	}
+	for _, attr := range attrs {
+		setAttribute(inv, attr)
+	}
+}
+
+// Inventory returns the inventory of the device
+func (s *Server) Inventory(deviceID string) (devices.InventoryDevice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.inventory[deviceID]
+	if !ok {
+		return devices.InventoryDevice{}, false
+	}
+	return copyInventory(inv), true
+}
+
+func copyInventory(inv *devices.InventoryDevice) devices.InventoryDevice {
+	c := *inv
+	c.Attributes = append([]devices.InventoryAttribute{}, inv.Attributes...)
+	return c
+}
+
+func setAttribute(inv *devices.InventoryDevice, attr devices.InventoryAttribute) {
+	inv.UpdatedTs = time.Now().UTC().Format(time.RFC3339)
+	for i, a := range inv.Attributes {
+		if a.Scope == attr.Scope && a.Name == attr.Name {
+			inv.Attributes[i] = attr
+			return
+		}
+	}
+	inv.Attributes = append(inv.Attributes, attr)
+}
+
+func removeAttribute(inv *devices.InventoryDevice, scope, name string) {
+	for i, a := range inv.Attributes {
+		if a.Scope == scope && a.Name == name {
+			inv.Attributes = append(inv.Attributes[:i], inv.Attributes[i+1:]...)
+			inv.UpdatedTs = time.Now().UTC().Format(time.RFC3339)
+			return
+		}
+	}
+}
+
+// sortedInventory returns the inventory of the devices sorted by ID
+func (s *Server) sortedInventory() []*devices.InventoryDevice {
+	list := make([]*devices.InventoryDevice, 0, len(s.inventory))
+	for _, inv := range s.inventory {
+		list = append(list, inv)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (s *Server) groupDeviceIDs(group string) []string {
+	ids := []string{}
+	for _, inv := range s.sortedInventory() {
+		if v, ok := inv.Attribute(devices.ScopeSystem, groupAttribute); ok && v == group {
+			ids = append(ids, inv.ID)
+		}
+	}
+	return ids
+}
+
+// matchFilter tells whether the device matches the filter; a list value
+// matches if one of its elements does
+func matchFilter(inv *devices.InventoryDevice, f devices.InventoryFilter) (bool, error) {
+	value, ok := inv.Attribute(f.Scope, f.Attribute)
+	equal := false
+	if ok {
+		if list, isList := value.([]interface{}); isList {
+			for _, v := range list {
+				equal = equal || fmt.Sprint(v) == fmt.Sprint(f.Value)
+			}
+		} else {
+			equal = fmt.Sprint(value) == fmt.Sprint(f.Value)
+		}
+	}
+	switch f.Type {
+	case devices.FilterEqual:
+		return equal, nil
+	case devices.FilterNotEqual:
+		return !equal, nil
+	}
+	return false, fmt.Errorf("unsupported filter type %q", f.Type)
+}
+
+func (s *Server) getInventoryDevice(w http.ResponseWriter, _ *http.Request, p params) {
+	inv, ok := s.Inventory(p["id"])
+	if !ok {
+		writeError(w, http.StatusNotFound, "Device not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+func (s *Server) searchInventory(w http.ResponseWriter, r *http.Request, _ params) {
+	req := inventorySearch{Page: 1, PerPage: 20}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Page < 1 || req.PerPage < 1 {
+		writeError(w, http.StatusBadRequest, "invalid pagination parameters")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := []devices.InventoryDevice{}
+	for _, inv := range s.sortedInventory() {
+		match := true
+		for _, f := range req.Filters {
+			ok, err := matchFilter(inv, f)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			match = match && ok
+		}
+		if match {
+			found = append(found, copyInventory(inv))
+		}
+	}
+	start := (req.Page - 1) * req.PerPage
+	if start > len(found) {
+		start = len(found)
+	}
+	end := start + req.PerPage
+	if end > len(found) {
+		end = len(found)
+	}
+	w.Header().Set("X-Total-Count", fmt.Sprint(len(found)))
+	writeJSON(w, http.StatusOK, found[start:end])
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, _ *http.Request, _ params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := map[string]bool{}
+	names := []string{}
+	for _, inv := range s.inventory {
+		if v, ok := inv.Attribute(devices.ScopeSystem, groupAttribute); ok {
+			name := fmt.Sprint(v)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+type groupUpdate struct {
+	UpdatedCount int `json:"updated_count"`
+	MatchedCount int `json:"matched_count"`
+}
+
+func (s *Server) addToGroup(w http.ResponseWriter, r *http.Request, p params) {
+	var ids []string
+	if !readJSON(w, r, &ids) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := groupUpdate{}
+	for _, id := range ids {
+		inv, ok := s.inventory[id]
+		if !ok {
+			continue
+		}
+		result.MatchedCount++
+		if v, _ := inv.Attribute(devices.ScopeSystem, groupAttribute); v != p["name"] {
+			result.UpdatedCount++
+			setAttribute(inv, devices.InventoryAttribute{
+				Name:  groupAttribute,
+				Scope: devices.ScopeSystem,
+				Value: p["name"],
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) removeFromGroup(w http.ResponseWriter, r *http.Request, p params) {
+	var ids []string
+	if !readJSON(w, r, &ids) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := groupUpdate{}
+	for _, id := range ids {
+		inv, ok := s.inventory[id]
+		if !ok {
+			continue
+		}
+		if v, _ := inv.Attribute(devices.ScopeSystem, groupAttribute); v == p["name"] {
+			result.MatchedCount++
+			result.UpdatedCount++
+			removeAttribute(inv, devices.ScopeSystem, groupAttribute)
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.groupDeviceIDs(p["name"])
+	if len(ids) == 0 {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+	for _, id := range ids {
+		removeAttribute(s.inventory[id], devices.ScopeSystem, groupAttribute)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listFilters(w http.ResponseWriter, _ *http.Request, _ params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []savedFilter{}
+	for _, f := range s.filters {
+		list = append(list, *f)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) createFilter(w http.ResponseWriter, r *http.Request, _ params) {
+	var req savedFilter
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" || len(req.Terms) == 0 {
+		writeError(w, http.StatusBadRequest, "name and terms: cannot be blank.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.filters {
+		if f.Name == req.Name {
+			writeError(w, http.StatusConflict, "a filter with the same name already exists")
+			return
+		}
+	}
+	req.ID = uuid.NewString()
+	s.filters = append(s.filters, &req)
+	w.Header().Set("Location", inventoryFilterPath+"/"+req.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) deleteFilter(w http.ResponseWriter, _ *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.filters {
+		if f.ID == p["id"] {
+			s.filters = append(s.filters[:i], s.filters[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "filter not found")
+}
@@ -0,0 +1,271 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/mender-cli/client/devices"
+	"github.com/mendersoftware/mender-cli/client/useradm"
+)
+
+const requestIDHeader = "X-MEN-RequestID"
+
+// Server is a fake Mender server listening on a local address; URL is the
+// server URL for the clients
+type Server struct {
+	*httptest.Server
+
+	// ShellHandler runs the remote terminal sessions; the default echoes
+	// the input back
+	ShellHandler ShellHandler
+
+	mu     sync.Mutex
+	routes []route
+
+	requests []string
+	failures []*failure
+
+	users   map[string]*user
+	tokens  map[string]*token
+	tenants []useradm.Tenant
+
+	artifacts   []*storedArtifact
+	uploads     map[string]*directUpload
+	deployments []*deployment
+
+	devices     []*devices.Device
+	inventory   map[string]*devices.InventoryDevice
+	filters     []*savedFilter
+	connected   map[string]bool
+	deviceFiles map[string]map[string]*File
+	sessions    []*deviceSession
+}
+
+type route struct {
+	method  string
+	pattern []string
+	// public routes don't require a token
+	public  bool
+	handler func(w http.ResponseWriter, r *http.Request, p params)
+}
+
+// params holds the values of the :name elements of the route pattern
+type params map[string]string
+
+type failure struct {
+	method string
+	path   string
+	status int
+	count  int
+}
+
+// New starts a fake server with no users, artifacts or devices
+func New() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewTLS starts a fake server serving HTTPS with a self-signed
+// certificate; the clients need to skip the verification, or to trust
+// Certificate() as a CA
+func NewTLS() *Server {
+	s := newServer()
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func newServer() *Server {
+	s := &Server{
+		ShellHandler: EchoShell,
+		users:        map[string]*user{},
+		tokens:       map[string]*token{},
+		uploads:      map[string]*directUpload{},
+		inventory:    map[string]*devices.InventoryDevice{},
+		connected:    map[string]bool{},
+		deviceFiles:  map[string]map[string]*File{},
+	}
+	s.useradmRoutes()
+	s.deploymentsRoutes()
+	s.devauthRoutes()
+	s.inventoryRoutes()
+	s.deviceconnectRoutes()
+	return s
+}
+
+func (s *Server) handle(
+	method, pattern string,
+	handler func(w http.ResponseWriter, r *http.Request, p params),
+) {
+	s.routes = append(s.routes, route{
+		method:  method,
+		pattern: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler: handler,
+	})
+}
+
+func (s *Server) handlePublic(
+	method, pattern string,
+	handler func(w http.ResponseWriter, r *http.Request, p params),
+) {
+	s.handle(method, pattern, handler)
+	s.routes[len(s.routes)-1].public = true
+}
+
+func (r *route) match(path string) (params, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != len(r.pattern) {
+		return nil, false
+	}
+	p := params{}
+	for i, part := range r.pattern {
+		if strings.HasPrefix(part, ":") {
+			p[part[1:]] = parts[i]
+		} else if part != parts[i] {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(requestIDHeader, uuid.NewString())
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	status := s.takeFailure(r.Method, r.URL.Path)
+	s.mu.Unlock()
+	if status == ConnectionLost {
+		closeConnection(w)
+		return
+	} else if status != 0 {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+
+	pathMatched := false
+	for i := range s.routes {
+		rt := &s.routes[i]
+		p, ok := rt.match(r.URL.Path)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if rt.method != r.Method {
+			continue
+		}
+		if !rt.public && !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		rt.handler(w, r, p)
+		return
+	}
+	if pathMatched {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeError(w, http.StatusNotFound, "not found")
+}
+
+func (s *Server) takeFailure(method, path string) int {
+	for i, f := range s.failures {
+		if f.method == method && f.path == path {
+			f.count--
+			if f.count <= 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+			return f.status
+		}
+	}
+	return 0
+}
+
+// ConnectionLost is the status making Fail close the connection without
+// sending a response
+const ConnectionLost = -1
+
+// Fail makes the next count requests with the method and path fail with
+// the status, before they reach the API; the client is asked to retry the
+// requests rejected with 429 at once
+func (s *Server) Fail(method, path string, status, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &failure{
+		method: method,
+		path:   path,
+		status: status,
+		count:  count,
+	})
+}
+
+// closeConnection closes the connection of the request, as if it was lost
+func closeConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic("fakeserver: the connection can't be closed")
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic("fakeserver: cannot close the connection: " + err.Error())
+	}
+	conn.Close()
+}
+
+// Requests returns the method and path of the requests received so far,
+// e.g. "GET /api/management/v1/deployments/artifacts"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// writeError writes an error response the way the Mender services do
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"error":      message,
+		"request_id": w.Header().Get(requestIDHeader),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("fakeserver: cannot encode the response: %s", err))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// readJSON decodes the request body, writing the error response if it
+// can't
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed request body: "+err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,314 @@
+// Copyright 2023 Northern.tech AS
+//
+//	Licensed under the Apache License, Version 2.0 (the "License");
+//	you may not use this file except in compliance with the License.
+//	You may obtain a copy of the License at
+//
+//	    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	Unless required by applicable law or agreed to in writing, software
+//	distributed under the License is distributed on an "AS IS" BASIS,
+//	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	See the License for the specific language governing permissions and
+//	limitations under the License.
+package fakeserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mendersoftware/mender-cli/client/useradm"
+)
+
+const (
+	loginPath   = "/api/management/v1/useradm/auth/login"
+	logoutPath  = "/api/management/v1/useradm/auth/logout"
+	tokensPath  = "/api/management/v1/useradm/settings/tokens"
+	tenantsPath = "/api/management/v1/tenantadm/user/tenants"
+
+	// TokenLifetime is how long the tokens issued on login are valid
+	TokenLifetime = 7 * 24 * time.Hour
+)
+
+type user struct {
+	id       string
+	email    string
+	password string
+}
+
+type token struct {
+	value    string
+	userID   string
+	tenantID string
+	expires  time.Time
+
+	// set for the personal access tokens only
+	pat *useradm.PersonalAccessToken
+}
+
+func (t *token) expired() bool {
+	return !t.expires.IsZero() && time.Now().After(t.expires)
+}
+
+func (s *Server) useradmRoutes() {
+	s.handlePublic(http.MethodPost, loginPath, s.login)
+	s.handle(http.MethodPost, logoutPath, s.logout)
+	s.handle(http.MethodPost, tokensPath, s.createPAT)
+	s.handle(http.MethodGet, tokensPath, s.listPATs)
+	s.handle(http.MethodDelete, tokensPath+"/:id", s.revokePAT)
+	s.handle(http.MethodGet, tenantsPath, s.listTenants)
+}
+
+// AddUser adds the user who can log in with the password and returns the
+// user ID
+func (s *Server) AddUser(email, password string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUser(email, password).id
+}
+
+func (s *Server) addUser(email, password string) *user {
+	u := &user{id: uuid.NewString(), email: email, password: password}
+	s.users[email] = u
+	return u
+}
+
+// AddTenant adds a tenant the users can log in to; the first tenant is the
+// one they log in to by default
+func (s *Server) AddTenant(id, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants = append(s.tenants, useradm.Tenant{ID: id, Name: name, Status: "active"})
+}
+
+// Token returns a new token of the user, adding the user if needed, as if
+// they logged in
+func (s *Server) Token(email string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[email]
+	if !ok {
+		u = s.addUser(email, "")
+	}
+	return s.issueToken(u, s.defaultTenant(), time.Now().Add(TokenLifetime), nil).value
+}
+
+// ExpireToken makes the server reject the token from now on
+func (s *Server) ExpireToken(value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tokens[value]; ok {
+		t.expires = time.Now().Add(-time.Second)
+	}
+}
+
+func (s *Server) hasTenant(id string) bool {
+	for _, t := range s.tenants {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) defaultTenant() string {
+	if len(s.tenants) > 0 {
+		return s.tenants[0].ID
+	}
+	return ""
+}
+
+// issueToken returns a JWT with the claims the clients read; the signature
+// isn't checked by anyone, so it's a placeholder
+func (s *Server) issueToken(
+	u *user,
+	tenantID string,
+	expires time.Time,
+	pat *useradm.PersonalAccessToken,
+) *token {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"jti":         uuid.NewString(),
+		"sub":         u.id,
+		"iss":         "Mender Users",
+		"scp":         "mender.*",
+		"iat":         now.Unix(),
+		"mender.user": true,
+	}
+	if tenantID != "" {
+		claims["mender.tenant"] = tenantID
+		claims["mender.plan"] = "enterprise"
+	}
+	if !expires.IsZero() {
+		claims["exp"] = expires.Unix()
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	enc := base64.RawURLEncoding
+	value := enc.EncodeToString(header) + "." + enc.EncodeToString(payload) + "." +
+		enc.EncodeToString([]byte("fakeserver"))
+
+	t := &token{
+		value:    value,
+		userID:   u.id,
+		tenantID: tenantID,
+		expires:  expires,
+		pat:      pat,
+	}
+	s.tokens[value] = t
+	return t
+}
+
+// authorized tells whether the request has a valid token
+func (s *Server) authorized(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.requestToken(r)
+	if t == nil {
+		return false
+	}
+	if t.pat != nil {
+		now := time.Now().UTC()
+		t.pat.LastUsed = &now
+	}
+	return true
+}
+
+// requestToken returns the valid token of the request or nil
+func (s *Server) requestToken(r *http.Request) *token {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil
+	}
+	t, ok := s.tokens[strings.TrimPrefix(auth, "Bearer ")]
+	if !ok || t.expired() {
+		return nil
+	}
+	return t
+}
+
+type loginRequest struct {
+	Token2FA string `json:"token2fa"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request, _ params) {
+	var req loginRequest
+	if r.ContentLength != 0 && r.Body != http.NoBody {
+		if !readJSON(w, r, &req) {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	email, password, _ := r.BasicAuth()
+	u, ok := s.users[email]
+	if !ok || u.password == "" || u.password != password {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	tenantID := s.defaultTenant()
+	if req.TenantID != "" {
+		if !s.hasTenant(req.TenantID) {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		tenantID = req.TenantID
+	}
+	t := s.issueToken(u, tenantID, time.Now().Add(TokenLifetime), nil)
+	w.Header().Set("Content-Type", "application/jwt")
+	_, _ = w.Write([]byte(t.value))
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request, _ params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.requestToken(r); t != nil {
+		delete(s.tokens, t.value)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type newPAT struct {
+	Name      string `json:"name"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+func (s *Server) createPAT(w http.ResponseWriter, r *http.Request, _ params) {
+	var req newPAT
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name: cannot be blank.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner := s.requestToken(r)
+	for _, t := range s.tokens {
+		if t.pat != nil && t.userID == owner.userID && t.pat.Name == req.Name {
+			writeError(w, http.StatusConflict,
+				"Personal Access Token with a given name already exists")
+			return
+		}
+	}
+	now := time.Now().UTC()
+	pat := &useradm.PersonalAccessToken{
+		ID:        uuid.NewString(),
+		Name:      req.Name,
+		CreatedTs: &now,
+	}
+	var expires time.Time
+	if req.ExpiresIn > 0 {
+		expires = now.Add(time.Duration(req.ExpiresIn) * time.Second)
+		pat.ExpirationDate = &expires
+	}
+	t := s.issueToken(&user{id: owner.userID}, owner.tenantID, expires, pat)
+	w.Header().Set("Content-Type", "application/jwt")
+	_, _ = w.Write([]byte(t.value))
+}
+
+func (s *Server) listPATs(w http.ResponseWriter, r *http.Request, _ params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner := s.requestToken(r)
+	list := []useradm.PersonalAccessToken{}
+	for _, t := range s.tokens {
+		if t.pat != nil && t.userID == owner.userID {
+			list = append(list, *t.pat)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedTs.Before(*list[j].CreatedTs)
+	})
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) revokePAT(w http.ResponseWriter, r *http.Request, p params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner := s.requestToken(r)
+	for value, t := range s.tokens {
+		if t.pat != nil && t.userID == owner.userID && t.pat.ID == p["id"] {
+			delete(s.tokens, value)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Personal Access Token not found")
+}
+
+func (s *Server) listTenants(w http.ResponseWriter, _ *http.Request, _ params) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, append([]useradm.Tenant{}, s.tenants...))
+}